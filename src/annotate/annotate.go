@@ -0,0 +1,203 @@
+// Package annotate draws detection results onto images.
+//
+// Boxes are drawn in the color of their class, given by onnx.ClassColor, with a label
+// written with a built-in bitmap font, so no font file is needed:
+//
+//	annotated := annotate.Draw(img, boxes, annotate.Options{FillLabels: true})
+//	err := annotate.SaveFile("annotated.png", annotated)
+package annotate
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+// Options configures how detections are drawn.
+// Zero fields are replaced by the defaults documented on each field.
+type Options struct {
+	// LineWidth is the thickness of box outlines in pixels. It defaults to 2.
+	LineWidth int
+	// FontScale is the size of a font pixel in image pixels. It defaults to one per 320 pixels
+	// of the shortest image side, and at least 1.
+	FontScale int
+	// FillLabels draws labels on a background of the class color instead of in the class color.
+	FillLabels bool
+	// HideConfidence writes only the label, without the confidence.
+	HideConfidence bool
+	// MaskOpacity is the opacity of instance masks in [0, 1]. It defaults to 0.4.
+	MaskOpacity float64
+	// KeypointRadius is the radius of keypoint dots in pixels. It defaults to LineWidth + 1.
+	KeypointRadius int
+	// KeypointThreshold is the confidence below which keypoints are not drawn.
+	KeypointThreshold float32
+	// Skeleton lists the pairs of keypoint indices joined by a line, e.g. the limbs of a person.
+	Skeleton [][2]int
+}
+
+// withDefaults returns a copy of the options with zero fields replaced by their default for img.
+func (o Options) withDefaults(bounds image.Rectangle) Options {
+	if o.LineWidth <= 0 {
+		o.LineWidth = 2
+	}
+	if o.FontScale <= 0 {
+		o.FontScale = max(1, min(bounds.Dx(), bounds.Dy())/320)
+	}
+	if o.MaskOpacity <= 0 {
+		o.MaskOpacity = 0.4
+	}
+	if o.KeypointRadius <= 0 {
+		o.KeypointRadius = o.LineWidth + 1
+	}
+	return o
+}
+
+// Draw returns a copy of img with the boxes drawn onto it. Masks are drawn first, then
+// outlines and keypoints, then labels, so labels stay readable where boxes overlap.
+func Draw(img image.Image, boxes []onnx.BoundingBox, options Options) *image.RGBA {
+	bounds := img.Bounds()
+	options = options.withDefaults(bounds)
+	out := image.NewRGBA(bounds)
+	draw.Draw(out, bounds, img, bounds.Min, draw.Src)
+
+	for _, box := range boxes {
+		if box.Mask != nil {
+			drawMask(out, box.Mask, onnx.ClassColor(box.ClassID), options.MaskOpacity)
+		}
+	}
+	for _, box := range boxes {
+		c := onnx.ClassColor(box.ClassID)
+		drawOutline(out, box.ToRect(), options.LineWidth, c)
+		drawKeypoints(out, box.Keypoints, options, c)
+	}
+	for _, box := range boxes {
+		drawLabel(out, &box, options)
+	}
+	return out
+}
+
+// Label returns the text written next to a box.
+func Label(box *onnx.BoundingBox, hideConfidence bool) string {
+	if hideConfidence {
+		return box.Label
+	}
+	return fmt.Sprintf("%s %.2f", box.Label, box.Confidence)
+}
+
+// drawMask blends c onto the pixels of dst covered by mask.
+func drawMask(dst *image.RGBA, mask *image.Alpha, c color.RGBA, opacity float64) {
+	area := mask.Bounds().Intersect(dst.Bounds())
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			a := mask.AlphaAt(x, y).A
+			if a == 0 {
+				continue
+			}
+			alpha := opacity * float64(a) / 255
+			dst.SetRGBA(x, y, onnx.Blend(dst.RGBAAt(x, y), c, alpha))
+		}
+	}
+}
+
+// drawOutline draws the outline of r, width pixels thick, inside r.
+func drawOutline(dst *image.RGBA, r image.Rectangle, width int, c color.RGBA) {
+	width = min(width, r.Dx(), r.Dy())
+	if width <= 0 {
+		return
+	}
+	fillRect(dst, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+width), c)
+	fillRect(dst, image.Rect(r.Min.X, r.Max.Y-width, r.Max.X, r.Max.Y), c)
+	fillRect(dst, image.Rect(r.Min.X, r.Min.Y, r.Min.X+width, r.Max.Y), c)
+	fillRect(dst, image.Rect(r.Max.X-width, r.Min.Y, r.Max.X, r.Max.Y), c)
+}
+
+// fillRect fills the part of r inside dst with c.
+func fillRect(dst *image.RGBA, r image.Rectangle, c color.RGBA) {
+	draw.Draw(dst, r.Intersect(dst.Bounds()), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// drawKeypoints draws the skeleton lines and the dots of the confident keypoints.
+func drawKeypoints(dst *image.RGBA, keypoints []onnx.Keypoint, options Options, c color.RGBA) {
+	visible := func(i int) bool {
+		return i >= 0 && i < len(keypoints) && keypoints[i].Confidence >= options.KeypointThreshold
+	}
+	for _, bone := range options.Skeleton {
+		if visible(bone[0]) && visible(bone[1]) {
+			from, to := keypoints[bone[0]], keypoints[bone[1]]
+			drawLine(dst, from.X, from.Y, to.X, to.Y, options.LineWidth, c)
+		}
+	}
+	for i, kp := range keypoints {
+		if visible(i) {
+			fillCircle(dst, kp.X, kp.Y, options.KeypointRadius, c)
+		}
+	}
+}
+
+// drawLine draws a line of the given width by stamping squares along it.
+func drawLine(dst *image.RGBA, x1, y1, x2, y2 float32, width int, c color.RGBA) {
+	steps := int(math.Ceil(math.Max(math.Abs(float64(x2-x1)), math.Abs(float64(y2-y1)))))
+	half := width / 2
+	for i := 0; i <= steps; i++ {
+		t := float32(0)
+		if steps > 0 {
+			t = float32(i) / float32(steps)
+		}
+		x := int(math.Round(float64(x1 + (x2-x1)*t)))
+		y := int(math.Round(float64(y1 + (y2-y1)*t)))
+		fillRect(dst, image.Rect(x-half, y-half, x-half+width, y-half+width), c)
+	}
+}
+
+// fillCircle fills a disc of the given radius centered on (cx, cy).
+func fillCircle(dst *image.RGBA, cx, cy float32, radius int, c color.RGBA) {
+	x0, y0 := int(math.Round(float64(cx))), int(math.Round(float64(cy)))
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			p := image.Pt(x0+dx, y0+dy)
+			if dx*dx+dy*dy <= radius*radius && p.In(dst.Bounds()) {
+				dst.SetRGBA(p.X, p.Y, c)
+			}
+		}
+	}
+}
+
+// drawLabel writes the label of box above its top left corner, or inside the box when there
+// is no room above it.
+func drawLabel(dst *image.RGBA, box *onnx.BoundingBox, options Options) {
+	text := Label(box, options.HideConfidence)
+	if text == "" {
+		return
+	}
+	c := onnx.ClassColor(box.ClassID)
+	padding := options.FontScale
+	size := textSize(text, options.FontScale).Add(image.Pt(2*padding, 2*padding))
+
+	r := box.ToRect()
+	origin := image.Pt(r.Min.X, r.Min.Y-size.Y)
+	if origin.Y < dst.Bounds().Min.Y {
+		origin.Y = r.Min.Y
+	}
+	// Keep the label inside the image horizontally when possible.
+	origin.X = max(dst.Bounds().Min.X, min(origin.X, dst.Bounds().Max.X-size.X))
+
+	textColor := c
+	if options.FillLabels {
+		fillRect(dst, image.Rectangle{Min: origin, Max: origin.Add(size)}, c)
+		textColor = contrastColor(c)
+	}
+	drawText(dst, origin.Add(image.Pt(padding, padding)), text, options.FontScale, textColor)
+}
+
+// contrastColor returns black or white, whichever is more readable on c.
+func contrastColor(c color.RGBA) color.RGBA {
+	luminance := 0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B)
+	if luminance > 150 {
+		return color.RGBA{A: 255}
+	}
+	return color.RGBA{R: 255, G: 255, B: 255, A: 255}
+}
@@ -0,0 +1,111 @@
+package annotate_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/annotate"
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+var white = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+func whiteImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	return img
+}
+
+func TestDraw_OutlineInClassColor(t *testing.T) {
+	img := whiteImage(100, 100)
+	box := onnx.BoundingBox{Label: "dog", ClassID: 16, Confidence: 0.9, X1: 20, Y1: 40, X2: 80, Y2: 90}
+
+	out := annotate.Draw(img, []onnx.BoundingBox{box}, annotate.Options{LineWidth: 3})
+
+	want := onnx.ClassColor(16)
+	for _, p := range []image.Point{{20, 60}, {22, 60}, {79, 60}, {50, 89}} {
+		if got := out.RGBAAt(p.X, p.Y); got != want {
+			t.Errorf("Expected outline color %v at %v, got %v", want, p, got)
+		}
+	}
+	if got := out.RGBAAt(50, 60); got != white {
+		t.Errorf("Expected the inside of the box untouched, got %v", got)
+	}
+	if got := img.RGBAAt(20, 60); got != white {
+		t.Errorf("Expected the source image untouched, got %v", got)
+	}
+}
+
+func TestDraw_LabelAboveBox(t *testing.T) {
+	img := whiteImage(200, 100)
+	box := onnx.BoundingBox{Label: "cat", ClassID: 3, Confidence: 0.5, X1: 10, Y1: 50, X2: 90, Y2: 90}
+
+	out := annotate.Draw(img, []onnx.BoundingBox{box}, annotate.Options{FillLabels: true, FontScale: 1})
+
+	// The filled label background covers the area just above the box.
+	if got := out.RGBAAt(10, 49); got != onnx.ClassColor(3) {
+		t.Errorf("Expected the label background above the box, got %v", got)
+	}
+	// The text is black or white on the background.
+	textPixels := 0
+	for y := 41; y < 48; y++ {
+		for x := 11; x < 30; x++ {
+			if c := out.RGBAAt(x, y); c == (color.RGBA{A: 255}) || c == white {
+				textPixels++
+			}
+		}
+	}
+	if textPixels == 0 {
+		t.Error("Expected label text drawn over the background")
+	}
+}
+
+func TestDraw_MaskAndKeypoints(t *testing.T) {
+	img := whiteImage(50, 50)
+	mask := image.NewAlpha(img.Bounds())
+	mask.SetAlpha(25, 25, color.Alpha{A: 255})
+	box := onnx.BoundingBox{
+		ClassID:   1,
+		X1:        0,
+		Y1:        0,
+		X2:        50,
+		Y2:        50,
+		Mask:      mask,
+		Keypoints: []onnx.Keypoint{{X: 10, Y: 40, Confidence: 0.9}, {X: 40, Y: 40, Confidence: 0.1}},
+	}
+
+	out := annotate.Draw(img, []onnx.BoundingBox{box}, annotate.Options{
+		HideConfidence:    true,
+		KeypointThreshold: 0.5,
+		Skeleton:          [][2]int{{0, 1}},
+	})
+
+	if got := out.RGBAAt(25, 25); got == white || got == onnx.ClassColor(1) {
+		t.Errorf("Expected the mask blended with the image, got %v", got)
+	}
+	if got := out.RGBAAt(24, 25); got != white {
+		t.Errorf("Expected pixels outside the mask untouched, got %v", got)
+	}
+	if got := out.RGBAAt(10, 40); got != onnx.ClassColor(1) {
+		t.Errorf("Expected a confident keypoint dot, got %v", got)
+	}
+	if got := out.RGBAAt(40, 40); got != white {
+		t.Errorf("Expected no dot for a low confidence keypoint, got %v", got)
+	}
+	if got := out.RGBAAt(25, 40); got != white {
+		t.Errorf("Expected no skeleton line to a hidden keypoint, got %v", got)
+	}
+}
+
+func TestLabel(t *testing.T) {
+	box := onnx.BoundingBox{Label: "person", Confidence: 0.876}
+	if got := annotate.Label(&box, false); got != "person 0.88" {
+		t.Errorf("Label = %q", got)
+	}
+	if got := annotate.Label(&box, true); got != "person" {
+		t.Errorf("Label without confidence = %q", got)
+	}
+}
@@ -0,0 +1,66 @@
+package annotate
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format is an image encoding.
+type Format string
+
+const (
+	// FormatPNG is a lossless encoding, best for annotated images.
+	FormatPNG Format = "png"
+	// FormatJPEG is a smaller, lossy encoding.
+	FormatJPEG Format = "jpeg"
+)
+
+// jpegQuality is the quality of JPEG encodings, high enough to keep labels readable.
+const jpegQuality = 90
+
+// FormatFromPath returns the format matching the extension of path.
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return FormatPNG, nil
+	case ".jpg", ".jpeg":
+		return FormatJPEG, nil
+	default:
+		return "", fmt.Errorf("unsupported image extension %q, expected .png, .jpg or .jpeg", filepath.Ext(path))
+	}
+}
+
+// Encode writes img to w in the given format.
+func Encode(w io.Writer, img image.Image, format Format) error {
+	switch format {
+	case FormatPNG:
+		return png.Encode(w, img)
+	case FormatJPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	default:
+		return fmt.Errorf("unsupported image format %q", format)
+	}
+}
+
+// SaveFile writes img to path, in the format given by its extension.
+func SaveFile(path string, img image.Image) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create image: %w", err)
+	}
+	if err := Encode(file, img, format); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,57 @@
+package annotate_test
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"path/filepath"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/annotate"
+)
+
+func TestFormatFromPath(t *testing.T) {
+	tests := map[string]annotate.Format{"a.png": annotate.FormatPNG, "b.JPG": annotate.FormatJPEG, "c.jpeg": annotate.FormatJPEG}
+	for path, want := range tests {
+		if got, err := annotate.FormatFromPath(path); err != nil || got != want {
+			t.Errorf("FormatFromPath(%q) = %q, %v", path, got, err)
+		}
+	}
+	if _, err := annotate.FormatFromPath("d.gif"); err == nil {
+		t.Error("Expected an error for an unsupported extension")
+	}
+}
+
+func TestEncode(t *testing.T) {
+	img := whiteImage(8, 4)
+	var buf bytes.Buffer
+	if err := annotate.Encode(&buf, img, annotate.FormatPNG); err != nil {
+		t.Fatal(err)
+	}
+	if decoded, err := png.Decode(&buf); err != nil || decoded.Bounds() != img.Bounds() {
+		t.Errorf("Expected a decodable PNG, got %v", err)
+	}
+
+	buf.Reset()
+	if err := annotate.Encode(&buf, img, annotate.FormatJPEG); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jpeg.Decode(&buf); err != nil {
+		t.Errorf("Expected a decodable JPEG, got %v", err)
+	}
+
+	if err := annotate.Encode(&buf, img, "bmp"); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestSaveFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.png")
+	if err := annotate.SaveFile(path, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatalf("SaveFile returned error: %v", err)
+	}
+	if err := annotate.SaveFile(filepath.Join(t.TempDir(), "out.txt"), whiteImage(1, 1)); err == nil {
+		t.Error("Expected an error for an unsupported extension")
+	}
+}
@@ -0,0 +1,242 @@
+// Package batcher groups concurrent single-item requests into batches for a model session.
+//
+// A Dispatcher sits in front of a batched inference function, such as
+// detector.Detector.AnalyzeImages, and collects requests until the batch is full or the
+// oldest request has waited long enough. It then runs the batch once and fans the
+// results back to each caller:
+//
+//	dispatcher := batcher.New(model.AnalyzeImages, batcher.Config{
+//		MaxBatchSize: model.Options().BatchSize,
+//		MaxWait:      5 * time.Millisecond,
+//	})
+//	defer dispatcher.Close()
+//	boxes, err := dispatcher.Do(ctx, img)
+package batcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrClosed is returned when submitting a request to a closed Dispatcher.
+var ErrClosed = errors.New("dispatcher is closed")
+
+// BatchFunc runs a batch of inputs and returns one output per input, in the same order.
+type BatchFunc[In, Out any] func(inputs []In) ([]Out, error)
+
+// Config holds the batching parameters of a Dispatcher.
+type Config struct {
+	// MaxBatchSize is the largest number of requests run together.
+	MaxBatchSize int
+	// MaxWait is how long the first request of a batch waits for others to join it.
+	MaxWait time.Duration
+	// QueueSize is the number of requests which can wait for a batch before Submit blocks.
+	// It defaults to four batches.
+	QueueSize int
+}
+
+// Result is the outcome of a single request.
+type Result[Out any] struct {
+	Value Out
+	Err   error
+}
+
+// Stats holds counters describing the activity of a Dispatcher.
+type Stats struct {
+	// QueueDepth is the number of requests waiting for a batch.
+	QueueDepth int
+	// Requests is the number of requests accepted so far.
+	Requests uint64
+	// Batches is the number of batches run so far.
+	Batches uint64
+	// BatchedRequests is the number of requests which went through a batch run.
+	BatchedRequests uint64
+	// Cancelled is the number of requests dropped because their context ended before their batch ran.
+	Cancelled uint64
+	// Failed is the number of requests whose batch returned an error.
+	Failed uint64
+}
+
+// AverageBatchSize returns the mean number of requests per batch run.
+func (s Stats) AverageBatchSize() float64 {
+	if s.Batches == 0 {
+		return 0
+	}
+	return float64(s.BatchedRequests) / float64(s.Batches)
+}
+
+// request is a single input waiting for its batch, with the channel to answer on.
+type request[In, Out any] struct {
+	ctx    context.Context
+	input  In
+	result chan Result[Out]
+}
+
+// Dispatcher collects concurrent requests into batches and runs them with a BatchFunc.
+// It is safe for concurrent use.
+type Dispatcher[In, Out any] struct {
+	run    BatchFunc[In, Out]
+	config Config
+	queue  chan request[In, Out]
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+
+	requests        atomic.Uint64
+	batches         atomic.Uint64
+	batchedRequests atomic.Uint64
+	cancelled       atomic.Uint64
+	failed          atomic.Uint64
+}
+
+// New creates a Dispatcher running batches with run and starts its worker.
+// Close must be called to stop the worker.
+func New[In, Out any](run BatchFunc[In, Out], config Config) *Dispatcher[In, Out] {
+	if config.MaxBatchSize < 1 {
+		config.MaxBatchSize = 1
+	}
+	if config.QueueSize < 1 {
+		config.QueueSize = 4 * config.MaxBatchSize
+	}
+	d := &Dispatcher[In, Out]{
+		run:    run,
+		config: config,
+		queue:  make(chan request[In, Out], config.QueueSize),
+	}
+	d.wg.Add(1)
+	go d.loop()
+	return d
+}
+
+// Submit queues input for the next batch and returns the channel its result will be sent on.
+// The channel is buffered, so the dispatcher never blocks on callers which stopped listening.
+// Submit blocks while the queue is full, until ctx ends.
+func (d *Dispatcher[In, Out]) Submit(ctx context.Context, input In) (<-chan Result[Out], error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return nil, ErrClosed
+	}
+
+	req := request[In, Out]{ctx: ctx, input: input, result: make(chan Result[Out], 1)}
+	select {
+	case d.queue <- req:
+		d.requests.Add(1)
+		return req.result, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("request cancelled while queueing: %w", ctx.Err())
+	}
+}
+
+// Do submits input and waits for its result, or for ctx to end.
+func (d *Dispatcher[In, Out]) Do(ctx context.Context, input In) (Out, error) {
+	var zero Out
+	result, err := d.Submit(ctx, input)
+	if err != nil {
+		return zero, err
+	}
+	select {
+	case r := <-result:
+		return r.Value, r.Err
+	case <-ctx.Done():
+		return zero, fmt.Errorf("request cancelled while waiting for its batch: %w", ctx.Err())
+	}
+}
+
+// Stats returns a snapshot of the dispatcher counters.
+func (d *Dispatcher[In, Out]) Stats() Stats {
+	return Stats{
+		QueueDepth:      len(d.queue),
+		Requests:        d.requests.Load(),
+		Batches:         d.batches.Load(),
+		BatchedRequests: d.batchedRequests.Load(),
+		Cancelled:       d.cancelled.Load(),
+		Failed:          d.failed.Load(),
+	}
+}
+
+// Close stops accepting requests, runs the requests already queued and waits for the worker to exit.
+func (d *Dispatcher[In, Out]) Close() {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+// loop collects requests into batches until the queue is closed and drained.
+func (d *Dispatcher[In, Out]) loop() {
+	defer d.wg.Done()
+	timer := time.NewTimer(d.config.MaxWait)
+	timer.Stop()
+
+	for first := range d.queue {
+		batch := []request[In, Out]{first}
+		timer.Reset(d.config.MaxWait)
+	collect:
+		for len(batch) < d.config.MaxBatchSize {
+			select {
+			case req, ok := <-d.queue:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, req)
+			case <-timer.C:
+				break collect
+			}
+		}
+		if !timer.Stop() {
+			// Drain a fired timer so the next Reset starts from a clean state.
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		d.runBatch(batch)
+	}
+}
+
+// runBatch drops the cancelled requests of batch, runs the others and sends each its result.
+func (d *Dispatcher[In, Out]) runBatch(batch []request[In, Out]) {
+	live := batch[:0]
+	for _, req := range batch {
+		if err := req.ctx.Err(); err != nil {
+			d.cancelled.Add(1)
+			req.result <- Result[Out]{Err: fmt.Errorf("request cancelled before its batch ran: %w", err)}
+			continue
+		}
+		live = append(live, req)
+	}
+	if len(live) == 0 {
+		return
+	}
+
+	inputs := make([]In, len(live))
+	for i, req := range live {
+		inputs[i] = req.input
+	}
+
+	d.batches.Add(1)
+	d.batchedRequests.Add(uint64(len(live)))
+	outputs, err := d.run(inputs)
+	if err == nil && len(outputs) != len(inputs) {
+		err = fmt.Errorf("batch function returned %d results for %d inputs", len(outputs), len(inputs))
+	}
+	if err != nil {
+		d.failed.Add(uint64(len(live)))
+		for _, req := range live {
+			req.result <- Result[Out]{Err: fmt.Errorf("batch of %d requests failed: %w", len(live), err)}
+		}
+		return
+	}
+	for i, req := range live {
+		req.result <- Result[Out]{Value: outputs[i]}
+	}
+}
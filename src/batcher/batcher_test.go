@@ -0,0 +1,157 @@
+package batcher_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/batcher"
+)
+
+func TestDispatcher_BatchesConcurrentRequests(t *testing.T) {
+	var mu sync.Mutex
+	var sizes []int
+	double := func(inputs []int) ([]int, error) {
+		mu.Lock()
+		sizes = append(sizes, len(inputs))
+		mu.Unlock()
+		outputs := make([]int, len(inputs))
+		for i, v := range inputs {
+			outputs[i] = v * 2
+		}
+		return outputs, nil
+	}
+
+	d := batcher.New(double, batcher.Config{MaxBatchSize: 4, MaxWait: 50 * time.Millisecond})
+	defer d.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(v int) {
+			defer wg.Done()
+			got, err := d.Do(context.Background(), v)
+			if err != nil {
+				t.Errorf("Do(%d) returned error: %v", v, err)
+			}
+			if got != v*2 {
+				t.Errorf("Do(%d) = %d, want %d", v, got, v*2)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	stats := d.Stats()
+	if stats.Requests != 8 || stats.BatchedRequests != 8 {
+		t.Errorf("Expected 8 batched requests, got %+v", stats)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, size := range sizes {
+		if size > 4 {
+			t.Errorf("Expected batches of at most 4 requests, got %d", size)
+		}
+	}
+	if stats.Batches >= 8 || stats.AverageBatchSize() <= 1 {
+		t.Errorf("Expected requests to share batches, got %d batches", stats.Batches)
+	}
+}
+
+func TestDispatcher_MaxWaitFlushesPartialBatch(t *testing.T) {
+	identity := func(inputs []string) ([]string, error) { return inputs, nil }
+	d := batcher.New(identity, batcher.Config{MaxBatchSize: 16, MaxWait: 10 * time.Millisecond})
+	defer d.Close()
+
+	start := time.Now()
+	got, err := d.Do(context.Background(), "alone")
+	if err != nil || got != "alone" {
+		t.Fatalf("Do returned %q, %v", got, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected a lone request to be flushed after MaxWait, took %v", elapsed)
+	}
+}
+
+func TestDispatcher_CancelledRequestIsDropped(t *testing.T) {
+	var calls [][]int
+	var mu sync.Mutex
+	release := make(chan struct{})
+	blocking := func(inputs []int) ([]int, error) {
+		mu.Lock()
+		calls = append(calls, inputs)
+		mu.Unlock()
+		<-release
+		return inputs, nil
+	}
+
+	d := batcher.New(blocking, batcher.Config{MaxBatchSize: 1})
+
+	// The first request occupies the worker while the second one is cancelled in the queue.
+	first, err := d.Submit(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Submit returned error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	second, err := d.Submit(ctx, 2)
+	if err != nil {
+		t.Fatalf("Submit returned error: %v", err)
+	}
+	cancel()
+	close(release)
+
+	if r := <-first; r.Err != nil || r.Value != 1 {
+		t.Errorf("Expected first result 1, got %+v", r)
+	}
+	if r := <-second; !errors.Is(r.Err, context.Canceled) {
+		t.Errorf("Expected the cancelled request to fail with context.Canceled, got %+v", r)
+	}
+	d.Close()
+
+	if stats := d.Stats(); stats.Cancelled != 1 || stats.Batches != 1 {
+		t.Errorf("Expected 1 cancelled request and 1 batch, got %+v", stats)
+	}
+	if len(calls) != 1 {
+		t.Errorf("Expected the cancelled request never to reach the model, got calls %v", calls)
+	}
+}
+
+func TestDispatcher_DoHonoursContextDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	slow := func(inputs []int) ([]int, error) {
+		<-release
+		return inputs, nil
+	}
+	d := batcher.New(slow, batcher.Config{MaxBatchSize: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := d.Do(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestDispatcher_BatchErrors(t *testing.T) {
+	failing := func(inputs []int) ([]int, error) { return nil, errors.New("session failed") }
+	d := batcher.New(failing, batcher.Config{MaxBatchSize: 2})
+	if _, err := d.Do(context.Background(), 1); err == nil {
+		t.Errorf("Expected the batch error to reach the caller")
+	}
+
+	short := func(inputs []int) ([]int, error) { return inputs[:0], nil }
+	d2 := batcher.New(short, batcher.Config{MaxBatchSize: 2})
+	if _, err := d2.Do(context.Background(), 1); err == nil {
+		t.Errorf("Expected an error when the batch function drops results")
+	}
+
+	d.Close()
+	d2.Close()
+	if _, err := d.Submit(context.Background(), 1); !errors.Is(err, batcher.ErrClosed) {
+		t.Errorf("Expected ErrClosed after Close, got %v", err)
+	}
+	if stats := d.Stats(); stats.Failed != 1 {
+		t.Errorf("Expected 1 failed request, got %+v", stats)
+	}
+}
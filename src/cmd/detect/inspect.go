@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/detector"
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// inspection describes a model file, as printed by the inspect subcommand.
+type inspection struct {
+	Model          string             `json:"model"`
+	RuntimeVersion string             `json:"onnxruntime_version"`
+	Inputs         []onnx.TensorInfo  `json:"inputs"`
+	Outputs        []onnx.TensorInfo  `json:"outputs"`
+	Metadata       onnx.ModelMetadata `json:"metadata"`
+	// Manifest is the suggested manifest of the model, or ManifestError why none is suggested.
+	Manifest      *detector.Manifest `json:"suggested_manifest,omitempty"`
+	ManifestError string             `json:"suggested_manifest_error,omitempty"`
+}
+
+// inspect runs the inspect subcommand with args, the arguments following it.
+func inspect(args []string) error {
+	flags := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: detect inspect -library onnxruntime.so [-json] model.onnx")
+		flags.PrintDefaults()
+	}
+	libraryPath := flags.String("library", "", "path to the onnxruntime shared library (required)")
+	asJSON := flags.Bool("json", false, "print the inspection as JSON")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+	if *libraryPath == "" || flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("%w: -library and a single model are required", errUsage)
+	}
+
+	modelPath := flags.Arg(0)
+	runtime := onnx.NewOnnxRuntime(modelPath, *libraryPath, onnx.TensorInputShape{}, onnx.TensorOutputShape{})
+	inputs, outputs, err := onnx.ReadTensorInfo(runtime)
+	if err != nil {
+		return err
+	}
+	metadata, err := onnx.ReadModelMetadata(runtime)
+	if err != nil {
+		return err
+	}
+	result := inspection{
+		Model:          modelPath,
+		RuntimeVersion: ort.GetVersion(),
+		Inputs:         inputs,
+		Outputs:        outputs,
+		Metadata:       metadata,
+	}
+	// The manifest refers to the model by its file name, to be saved next to it.
+	result.Manifest, err = detector.SuggestManifest(filepath.Base(modelPath), inputs, outputs, metadata)
+	if err != nil {
+		result.ManifestError = err.Error()
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	return writeInspection(os.Stdout, result)
+}
+
+// writeInspection writes the inspection of a model as text.
+func writeInspection(w io.Writer, result inspection) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Model:        %s\n", result.Model)
+	fmt.Fprintf(&b, "ONNX Runtime: %s\n", result.RuntimeVersion)
+	fmt.Fprintf(&b, "Producer:     %s\n", result.Metadata.Producer)
+	fmt.Fprintf(&b, "Graph:        %s\n", result.Metadata.GraphName)
+	fmt.Fprintf(&b, "Domain:       %s\n", result.Metadata.Domain)
+	fmt.Fprintf(&b, "Version:      %d\n", result.Metadata.Version)
+	if result.Metadata.Description != "" {
+		fmt.Fprintf(&b, "Description:  %s\n", result.Metadata.Description)
+	}
+	b.WriteString("\nInputs:\n")
+	for _, tensor := range result.Inputs {
+		fmt.Fprintf(&b, "  %s\n", tensor)
+	}
+	b.WriteString("Outputs:\n")
+	for _, tensor := range result.Outputs {
+		fmt.Fprintf(&b, "  %s\n", tensor)
+	}
+	if len(result.Metadata.Custom) > 0 {
+		b.WriteString("\nMetadata:\n")
+		keys := make([]string, 0, len(result.Metadata.Custom))
+		for key := range result.Metadata.Custom {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&b, "  %s: %s\n", key, result.Metadata.Custom[key])
+		}
+	}
+
+	if result.Manifest == nil {
+		fmt.Fprintf(&b, "\nNo suggested manifest: %s\n", result.ManifestError)
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	manifest, err := json.MarshalIndent(result.Manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the suggested manifest: %w", err)
+	}
+	fmt.Fprintf(&b, "\nSuggested manifest:\n%s\n", manifest)
+	_, err = io.WriteString(w, b.String())
+	return err
+}
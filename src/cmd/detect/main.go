@@ -0,0 +1,447 @@
+// Command detect runs a detection model over images and prints or saves the detections:
+//
+//	detect -manifest yolo11s.json -library onnxruntime.so -format jsonl photos/ extra/*.jpg
+//
+// Images are given as files, glob patterns or directories, which are searched recursively for
+// JPEG and PNG files. The model is described by a manifest, or by -model with the settings of
+// the other flags. Flags set on the command line override those of the manifest.
+//
+// Detections are written in one of the formats:
+//   - text: one line per detection, grouped by image
+//   - json: an array of results, each with the path of its image
+//   - jsonl: one result per line
+//   - coco: a COCO dataset with the detections as scored annotations
+//
+// With -annotate, a copy of each image with its detections drawn is written to a directory,
+// under its path relative to the directory given as argument, or its name for files.
+//
+// The inspect subcommand prints the tensors, metadata and onnxruntime version of a model, with
+// a suggested manifest to start from when wiring a new model, as text or with -json as JSON:
+//
+//	detect inspect -library onnxruntime.so [-json] model.onnx
+//
+// Commands exit with status 1 when the model cannot be run, an image fails or the command is
+// interrupted, after writing the detections of the other images, and with status 2 on invalid flags.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/annotate"
+	"github.com/deadelus/go-clean-onnxruntime/src/dataset"
+	"github.com/deadelus/go-clean-onnxruntime/src/detector"
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+// errUsage reports invalid flags or arguments, for which the command exits with status 2.
+var errUsage = errors.New("invalid usage")
+
+// imageExtensions are the extensions of the files read from directories.
+var imageExtensions = []string{".jpg", ".jpeg", ".png"}
+
+func main() {
+	var err error
+	if len(os.Args) > 1 && os.Args[1] == "inspect" {
+		err = inspect(os.Args[2:])
+	} else {
+		err = run()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "detect: %v\n", err)
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	manifestPath := flag.String("manifest", "", "path to the model manifest")
+	modelPath := flag.String("model", "", "path to the .onnx model, when no manifest is given")
+	libraryPath := flag.String("library", "", "path to the onnxruntime shared library (required)")
+	labelsPath := flag.String("labels", "", "path to the class labels (default: the manifest or model metadata)")
+	classes := flag.String("classes", "", "comma separated labels of the classes to detect (default: all)")
+	confidence := flag.Float64("confidence", 0, "minimum confidence of a detection, in (0, 1] (default 0.25)")
+	iou := flag.Float64("iou", 0, "IoU above which overlapping detections are merged, in (0, 1] (default 0.7)")
+	thresholdsPath := flag.String("thresholds", "", "path to a thresholds file, see the tune command")
+	size := flag.String("size", "", "model input size, as 640 or WIDTHxHEIGHT (default 640)")
+	resize := flag.String("resize", "", "how images are fitted to the input, stretch or letterbox")
+	decoder := flag.String("decoder", "", "output layout of the model, yolov8 or yolov5 (default yolov8)")
+	format := flag.String("format", "text", "output format: text, json, jsonl or coco")
+	out := flag.String("out", "", "path of the output file (default: standard output)")
+	annotateDir := flag.String("annotate", "", "directory where annotated copies of the images are written")
+	flag.Parse()
+
+	if (*manifestPath == "") == (*modelPath == "") || *libraryPath == "" || flag.NArg() == 0 {
+		flag.Usage()
+		return fmt.Errorf("%w: one of -manifest and -model, -library and at least one image are required", errUsage)
+	}
+	write, ok := writers[*format]
+	if !ok {
+		return fmt.Errorf("%w: unknown format %q, expected text, json, jsonl or coco", errUsage, *format)
+	}
+
+	var options detector.Options
+	if *manifestPath != "" {
+		manifest, err := detector.LoadManifest(*manifestPath)
+		if err != nil {
+			return err
+		}
+		if err := manifest.VerifyModel(); err != nil {
+			return err
+		}
+		options = manifest.Options(*libraryPath)
+	} else {
+		options = detector.Options{ModelPath: *modelPath, LibraryPath: *libraryPath}
+	}
+
+	// Apply the flags set on the command line, in the order in which they depend on each other.
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["labels"] {
+		labels, err := onnx.LoadLabels(*labelsPath)
+		if err != nil {
+			return err
+		}
+		options.Classes = labels.Names()
+	}
+	if set["thresholds"] {
+		thresholds, err := detector.LoadThresholds(*thresholdsPath)
+		if err != nil {
+			return err
+		}
+		options = options.WithThresholds(thresholds)
+	}
+	// Zero thresholds stand for the defaults in the options, so they cannot be asked for here.
+	if set["confidence"] {
+		if *confidence <= 0 || *confidence > 1 {
+			return fmt.Errorf("%w: confidence %v is outside (0, 1]", errUsage, *confidence)
+		}
+		options.ConfidenceThreshold = float32(*confidence)
+	}
+	if set["iou"] {
+		if *iou <= 0 || *iou > 1 {
+			return fmt.Errorf("%w: IoU %v is outside (0, 1]", errUsage, *iou)
+		}
+		options.IoUThreshold = float32(*iou)
+	}
+	if set["classes"] {
+		options.ClassFilter = onnx.ClassFilter{Include: splitList(*classes)}
+	}
+	if set["size"] {
+		width, height, err := parseSize(*size)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errUsage, err)
+		}
+		// The number of candidate detections depends on the input size and the decoder, it is
+		// computed again by detector.New once every flag is applied.
+		options.InputWidth, options.InputHeight, options.Detections = width, height, 0
+	}
+	if set["resize"] {
+		mode, err := onnx.ParseResizeMode(*resize)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errUsage, err)
+		}
+		options.Resize = mode
+	}
+	if set["decoder"] {
+		options.Decoder = detector.Decoder(*decoder)
+		if options.Decoder != detector.DecoderYOLOv8 && options.Decoder != detector.DecoderYOLOv5 {
+			return fmt.Errorf("%w: unknown decoder %q, expected %q or %q", errUsage, *decoder, detector.DecoderYOLOv8, detector.DecoderYOLOv5)
+		}
+	}
+
+	inputs, err := expandInputs(flag.Args())
+	if err != nil {
+		return err
+	}
+	if *annotateDir != "" {
+		if err := checkNames(inputs); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(*annotateDir, 0o755); err != nil {
+			return fmt.Errorf("failed to create annotation directory: %w", err)
+		}
+	}
+
+	d, err := detector.New(options)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	results := make([]imageResult, 0, len(inputs))
+	failed := 0
+	for _, in := range inputs {
+		result, err := detect(ctx, d, in, *annotateDir)
+		if ctx.Err() != nil {
+			// The image in progress is dropped, the detections of the previous ones are written.
+			break
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "detect: %s: %v\n", in.Path, err)
+			failed++
+			continue
+		}
+		results = append(results, imageResult{Image: in.Path, Result: result})
+	}
+	// A second interrupt stops the command while the detections are written.
+	stop()
+
+	err = writeOutput(*out, func(w io.Writer) error {
+		return write(w, results, d.Options())
+	})
+	if err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("interrupted after %d of %d images: %w", len(results)+failed, len(inputs), ctx.Err())
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d images failed", failed, len(inputs))
+	}
+	return nil
+}
+
+// writeOutput calls write with the file at path, replaced if it exists, or with the standard
+// output when path is empty.
+func writeOutput(path string, write func(w io.Writer) error) error {
+	if path == "" {
+		return write(os.Stdout)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output: %w", err)
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// imageResult is the result of the detector on the image at path Image.
+type imageResult struct {
+	Image string `json:"image"`
+	*detector.Result
+}
+
+// detect runs d on the image of in and, when annotateDir is set, writes the annotated image
+// there under the name of in.
+func detect(ctx context.Context, d *detector.Detector, in input, annotateDir string) (*detector.Result, error) {
+	img, err := loadImage(in.Path)
+	if err != nil {
+		return nil, err
+	}
+	result, err := d.AnalyzeContext(ctx, img)
+	if err != nil {
+		return nil, err
+	}
+	if annotateDir != "" {
+		annotated := annotate.Draw(img, result.Detections, annotate.Options{FillLabels: true})
+		path := filepath.Join(annotateDir, in.Name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create annotation directory: %w", err)
+		}
+		if err := annotate.SaveFile(path, annotated); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// input is an image file to analyze.
+type input struct {
+	// Path is the path of the file.
+	Path string
+	// Name is the path of the file relative to the directory given as argument, or its base
+	// name when the file was given itself. Annotated images are written under it.
+	Name string
+}
+
+// expandInputs returns the image files named by args: files, glob patterns, matched here for
+// shells which do not, and directories, searched recursively for JPEG and PNG files.
+func expandInputs(args []string) ([]input, error) {
+	var inputs []input
+	for _, arg := range args {
+		matches := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			var err error
+			matches, err = filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid pattern %q: %w", errUsage, arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no image matches %q", arg)
+			}
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				inputs = append(inputs, input{Path: match, Name: filepath.Base(match)})
+				continue
+			}
+			err = filepath.WalkDir(match, func(path string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if entry.IsDir() || !slices.Contains(imageExtensions, strings.ToLower(filepath.Ext(path))) {
+					return nil
+				}
+				name, err := filepath.Rel(match, path)
+				if err != nil {
+					return err
+				}
+				inputs = append(inputs, input{Path: path, Name: name})
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list images: %w", err)
+			}
+		}
+	}
+	if len(inputs) == 0 {
+		return nil, errors.New("no image found")
+	}
+	return inputs, nil
+}
+
+// checkNames returns an error when two different inputs have the same name, so that their
+// annotated images would overwrite each other.
+func checkNames(inputs []input) error {
+	paths := make(map[string]string, len(inputs))
+	for _, in := range inputs {
+		if other, ok := paths[in.Name]; ok && other != in.Path {
+			return fmt.Errorf("%w: %s and %s would both be annotated as %s", errUsage, other, in.Path, in.Name)
+		}
+		paths[in.Name] = in.Path
+	}
+	return nil
+}
+
+// parseSize parses an input size given as a single side, e.g. 640, or as WIDTHxHEIGHT.
+func parseSize(s string) (width, height int, err error) {
+	w, h, found := strings.Cut(s, "x")
+	if !found {
+		h = w
+	}
+	width, errWidth := strconv.Atoi(w)
+	height, errHeight := strconv.Atoi(h)
+	if errWidth != nil || errHeight != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("invalid input size %q, expected 640 or WIDTHxHEIGHT", s)
+	}
+	return width, height, nil
+}
+
+// splitList splits a comma separated list, ignoring spaces around its items and empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// writers write the results of the detector configured by options, by format name.
+var writers = map[string]func(w io.Writer, results []imageResult, options detector.Options) error{
+	"text":  writeText,
+	"json":  writeJSON,
+	"jsonl": writeJSONL,
+	"coco":  writeCOCO,
+}
+
+// writeText writes a line per image with its detection count, followed by a line per detection.
+func writeText(w io.Writer, results []imageResult, _ detector.Options) error {
+	for _, result := range results {
+		_, err := fmt.Fprintf(w, "%s: %d detections in %v\n", result.Image, len(result.Detections), result.Timings.Total)
+		if err != nil {
+			return err
+		}
+		for _, box := range result.Detections {
+			_, err := fmt.Fprintf(w, "  %s %.2f [%.0f %.0f %.0f %.0f]\n", box.Label, box.Confidence, box.X1, box.Y1, box.X2, box.Y2)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeJSON writes the results as an indented JSON array.
+func writeJSON(w io.Writer, results []imageResult, _ detector.Options) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
+
+// writeJSONL writes each result as JSON on its own line.
+func writeJSONL(w io.Writer, results []imageResult, _ detector.Options) error {
+	encoder := json.NewEncoder(w)
+	for _, result := range results {
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeCOCO writes the results as a COCO dataset, numbering the images from 1 in input order.
+// Categories are the classes output by the detector, once remapped.
+func writeCOCO(w io.Writer, results []imageResult, options detector.Options) error {
+	images := make([]dataset.ImageDetections, len(results))
+	for i, result := range results {
+		images[i] = dataset.ImageDetections{
+			Image: dataset.Image{
+				ID:       int64(i + 1),
+				FileName: filepath.ToSlash(result.Image),
+				Width:    result.ImageSize.Width,
+				Height:   result.ImageSize.Height,
+			},
+			Boxes: result.Detections,
+		}
+	}
+	categories := dataset.NewCategories(onnx.NewLabelSet(options.Classes).Remap(options.Remap).Names())
+	coco, err := dataset.NewCOCODataset(images, categories)
+	if err != nil {
+		return err
+	}
+	return coco.Write(w)
+}
+
+// loadImage decodes the JPEG or PNG image at path.
+func loadImage(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return img, nil
+}
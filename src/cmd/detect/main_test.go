@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/dataset"
+	"github.com/deadelus/go-clean-onnxruntime/src/detector"
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+// touch creates empty files at paths under dir, with their parent directories.
+func touch(t *testing.T, dir string, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExpandInputs(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "photos/a.jpg", "photos/sub/b.PNG", "photos/notes.txt", "extra/a.jpg", "extra/c.jpeg", "single.png")
+	join := func(path string) string { return filepath.Join(dir, path) }
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		want []input
+	}{
+		{
+			name: "file",
+			args: []string{join("single.png")},
+			want: []input{{Path: join("single.png"), Name: "single.png"}},
+		},
+		{
+			name: "directory",
+			args: []string{join("photos")},
+			want: []input{
+				{Path: join("photos/a.jpg"), Name: "a.jpg"},
+				{Path: join("photos/sub/b.PNG"), Name: filepath.Join("sub", "b.PNG")},
+			},
+		},
+		{
+			name: "glob",
+			args: []string{join("extra/*.jp*g")},
+			want: []input{
+				{Path: join("extra/a.jpg"), Name: "a.jpg"},
+				{Path: join("extra/c.jpeg"), Name: "c.jpeg"},
+			},
+		},
+		{
+			name: "in argument order",
+			args: []string{join("single.png"), join("extra/c.jpeg")},
+			want: []input{
+				{Path: join("single.png"), Name: "single.png"},
+				{Path: join("extra/c.jpeg"), Name: "c.jpeg"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := expandInputs(tc.args)
+			if err != nil {
+				t.Fatalf("expandInputs returned error: %v", err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("expandInputs(%q) = %v, want %v", tc.args, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExpandInputs_Errors(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "notes.txt")
+
+	for _, tc := range []struct {
+		name  string
+		args  []string
+		usage bool
+	}{
+		{name: "missing file", args: []string{filepath.Join(dir, "missing.jpg")}},
+		{name: "no match", args: []string{filepath.Join(dir, "*.jpg")}},
+		{name: "no image in directory", args: []string{dir}},
+		{name: "invalid pattern", args: []string{filepath.Join(dir, "[.jpg")}, usage: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := expandInputs(tc.args)
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if errors.Is(err, errUsage) != tc.usage {
+				t.Errorf("Expected a usage error %v, got %v", tc.usage, err)
+			}
+		})
+	}
+}
+
+func TestCheckNames(t *testing.T) {
+	unique := []input{{Path: "photos/a.jpg", Name: "a.jpg"}, {Path: "photos/sub/a.jpg", Name: "sub/a.jpg"}}
+	if err := checkNames(unique); err != nil {
+		t.Errorf("Expected distinct names to be accepted, got %v", err)
+	}
+	// The same file given twice is annotated once.
+	if err := checkNames(append(unique, input{Path: "photos/a.jpg", Name: "a.jpg"})); err != nil {
+		t.Errorf("Expected a repeated file to be accepted, got %v", err)
+	}
+	err := checkNames(append(unique, input{Path: "extra/a.jpg", Name: "a.jpg"}))
+	if !errors.Is(err, errUsage) {
+		t.Errorf("Expected a usage error for colliding names, got %v", err)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for _, tc := range []struct {
+		s             string
+		width, height int
+		wantErr       bool
+	}{
+		{s: "640", width: 640, height: 640},
+		{s: "1280x736", width: 1280, height: 736},
+		{s: "", wantErr: true},
+		{s: "x640", wantErr: true},
+		{s: "640x", wantErr: true},
+		{s: "0", wantErr: true},
+		{s: "-640", wantErr: true},
+		{s: "640x480x3", wantErr: true},
+		{s: "large", wantErr: true},
+	} {
+		width, height, err := parseSize(tc.s)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("parseSize(%q) = %d, %d, expected an error", tc.s, width, height)
+			}
+			continue
+		}
+		if err != nil || width != tc.width || height != tc.height {
+			t.Errorf("parseSize(%q) = %d, %d, %v, want %d, %d", tc.s, width, height, err, tc.width, tc.height)
+		}
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want []string
+	}{
+		{s: "", want: nil},
+		{s: "person", want: []string{"person"}},
+		{s: "person, car,truck", want: []string{"person", "car", "truck"}},
+		{s: " traffic light ,, ", want: []string{"traffic light"}},
+	} {
+		if got := splitList(tc.s); !slices.Equal(got, tc.want) {
+			t.Errorf("splitList(%q) = %q, want %q", tc.s, got, tc.want)
+		}
+	}
+}
+
+// testResults returns the results of two images, a dog and a merged vehicle in the first.
+func testResults() []imageResult {
+	return []imageResult{
+		{Image: "photos/a.jpg", Result: &detector.Result{
+			Detections: []onnx.BoundingBox{
+				{Label: "dog", ClassID: 1, Confidence: 0.9, X1: 10, Y1: 20, X2: 30, Y2: 60},
+				{Label: "vehicle", ClassID: 2, Confidence: 0.5, X1: 0, Y1: 0, X2: 100, Y2: 50},
+			},
+			Timings:   detector.Timings{Total: 12 * time.Millisecond},
+			ImageSize: detector.Size{Width: 200, Height: 100},
+		}},
+		{Image: "photos/b.jpg", Result: &detector.Result{
+			Timings:   detector.Timings{Total: 8 * time.Millisecond},
+			ImageSize: detector.Size{Width: 50, Height: 50},
+		}},
+	}
+}
+
+var testOptions = detector.Options{
+	Classes: []string{"cat", "dog", "car", "truck"},
+	Remap:   onnx.ClassRemap{"car": "vehicle", "truck": "vehicle"},
+}
+
+func TestWriters(t *testing.T) {
+	for _, tc := range []struct {
+		format string
+		check  func(t *testing.T, out string)
+	}{
+		{
+			format: "text",
+			check: func(t *testing.T, out string) {
+				want := "photos/a.jpg: 2 detections in 12ms\n" +
+					"  dog 0.90 [10 20 30 60]\n" +
+					"  vehicle 0.50 [0 0 100 50]\n" +
+					"photos/b.jpg: 0 detections in 8ms\n"
+				if out != want {
+					t.Errorf("Wrote\n%s\nwant\n%s", out, want)
+				}
+			},
+		},
+		{
+			format: "json",
+			check: func(t *testing.T, out string) {
+				var decoded []imageResult
+				if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+					t.Fatalf("Failed to decode %s: %v", out, err)
+				}
+				if len(decoded) != 2 || decoded[0].Image != "photos/a.jpg" || len(decoded[0].Detections) != 2 || decoded[1].ImageSize.Width != 50 {
+					t.Errorf("Unexpected results %s", out)
+				}
+			},
+		},
+		{
+			format: "jsonl",
+			check: func(t *testing.T, out string) {
+				lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+				if len(lines) != 2 {
+					t.Fatalf("Expected a line per image, got %q", out)
+				}
+				var decoded imageResult
+				if err := json.Unmarshal([]byte(lines[1]), &decoded); err != nil {
+					t.Fatalf("Failed to decode %s: %v", lines[1], err)
+				}
+				if decoded.Image != "photos/b.jpg" || len(decoded.Detections) != 0 {
+					t.Errorf("Unexpected second result %s", lines[1])
+				}
+			},
+		},
+		{
+			format: "coco",
+			check: func(t *testing.T, out string) {
+				coco, err := dataset.ReadCOCODataset(strings.NewReader(out))
+				if err != nil {
+					t.Fatalf("Failed to read %s: %v", out, err)
+				}
+				if len(coco.Images) != 2 || coco.Images[0].ID != 1 || coco.Images[1].FileName != "photos/b.jpg" {
+					t.Errorf("Unexpected images %+v", coco.Images)
+				}
+				// Categories are the remapped classes: cat, dog and vehicle.
+				var names []string
+				for _, category := range coco.Categories {
+					names = append(names, category.Name)
+				}
+				if !slices.Equal(names, []string{"cat", "dog", "vehicle"}) {
+					t.Errorf("Unexpected categories %q", names)
+				}
+				if len(coco.Annotations) != 2 || coco.Annotations[1].CategoryID != 3 || coco.Annotations[1].Score != 0.5 {
+					t.Errorf("Unexpected annotations %+v", coco.Annotations)
+				}
+			},
+		},
+	} {
+		t.Run(tc.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writers[tc.format](&buf, testResults(), testOptions); err != nil {
+				t.Fatalf("Writer returned error: %v", err)
+			}
+			tc.check(t, buf.String())
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// Command tune picks the decoding thresholds of a model from a COCO labeled dataset and writes
+// a thresholds file, which manifests load with thresholds_file:
+//
+//	tune -manifest yolo11s.json -library onnxruntime.so -dataset val.json -out thresholds.json
+//
+// Each image runs through the model once; thresholds are swept on the cached raw outputs.
+// With -cache, the raw outputs are saved to a file and reused by later runs of the same model on
+// the same dataset.
+package main
+
+import (
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/dataset"
+	"github.com/deadelus/go-clean-onnxruntime/src/detector"
+	"github.com/deadelus/go-clean-onnxruntime/src/tune"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "tune: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	manifestPath := flag.String("manifest", "", "path to the model manifest (required)")
+	libraryPath := flag.String("library", "", "path to the onnxruntime shared library (required)")
+	datasetPath := flag.String("dataset", "", "path to the COCO dataset with the ground truth (required)")
+	imagesDir := flag.String("images", "", "directory of the dataset images (default: the dataset directory)")
+	objective := flag.String("objective", string(tune.ObjectiveF1), "objective, f1 or precision")
+	targetPrecision := flag.Float64("target-precision", 0, "precision to reach with the precision objective")
+	minConfidence := flag.Float64("min-confidence", 0, "lowest confidence threshold tried (default 0.01)")
+	matchIoU := flag.Float64("match-iou", 0, "IoU from which a detection matches the ground truth (default 0.5)")
+	out := flag.String("out", "", "path of the thresholds file (default: standard output)")
+	report := flag.String("report", "", "path of a JSON report of the sweep")
+	cache := flag.String("cache", "", "path of a raw outputs file, written on the first run and read by the next ones")
+	flag.Parse()
+
+	if *manifestPath == "" || *libraryPath == "" || *datasetPath == "" {
+		flag.Usage()
+		return errors.New("-manifest, -library and -dataset are required")
+	}
+	if *imagesDir == "" {
+		*imagesDir = filepath.Dir(*datasetPath)
+	}
+
+	manifest, err := detector.LoadManifest(*manifestPath)
+	if err != nil {
+		return err
+	}
+	d, err := detector.NewFromManifest(manifest, *libraryPath)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	coco, err := dataset.LoadCOCODataset(*datasetPath)
+	if err != nil {
+		return err
+	}
+	images, err := coco.Detections()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	outputs, err := rawOutputs(ctx, d, images, *imagesDir, *cache)
+	if err != nil {
+		return err
+	}
+	samples := make([]tune.Sample, len(images))
+	for i, labeled := range images {
+		samples[i] = tune.Sample{Output: outputs[i], GroundTruth: labeled.Boxes, Crowd: labeled.Crowd}
+	}
+
+	result, err := tune.Sweep(ctx, d.Options(), samples, tune.Options{
+		Objective:       tune.Objective(*objective),
+		TargetPrecision: *targetPrecision,
+		MinConfidence:   float32(*minConfidence),
+		MatchIoU:        *matchIoU,
+	})
+	if err != nil {
+		return err
+	}
+	for _, choice := range append([]tune.Choice{result.Global}, result.Classes...) {
+		if !choice.Met {
+			fmt.Fprintf(os.Stderr, "tune: %s: no threshold reaches precision %v, using the best F1\n", choice.Label, *targetPrecision)
+		}
+	}
+
+	if *report != "" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*report, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+	if *out == "" {
+		return detector.WriteThresholds(os.Stdout, result.Thresholds)
+	}
+	return detector.SaveThresholds(*out, result.Thresholds)
+}
+
+// rawCache is the content of a -cache file: the raw outputs of the dataset images, with the
+// checksum of the model and the file names of the images they were computed from.
+type rawCache struct {
+	ModelSHA256 string
+	Images      []string
+	Outputs     []*detector.RawOutput
+}
+
+// rawOutputs returns the raw output of each image, read from the cache file when it holds valid
+// outputs of the model and images, or computed by running the model and saved to the cache file
+// when one is given.
+func rawOutputs(ctx context.Context, d *detector.Detector, images []dataset.ImageDetections, imagesDir, cache string) ([]*detector.RawOutput, error) {
+	key := rawCache{ModelSHA256: d.Options().ModelSHA256, Images: make([]string, len(images))}
+	for i, labeled := range images {
+		key.Images[i] = labeled.Image.FileName
+	}
+	if cache != "" {
+		outputs, err := loadCache(cache, key)
+		if err == nil {
+			return outputs, nil
+		}
+		// A stale or corrupted cache is dropped: the outputs are computed again and replace it.
+		if !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "tune: dropping cache: %v\n", err)
+		}
+	}
+
+	key.Outputs = make([]*detector.RawOutput, 0, len(images))
+	for _, labeled := range images {
+		img, err := loadImage(filepath.Join(imagesDir, labeled.Image.FileName))
+		if err != nil {
+			return nil, err
+		}
+		output, err := d.InferContext(ctx, img)
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze %s: %w", labeled.Image.FileName, err)
+		}
+		key.Outputs = append(key.Outputs, output)
+	}
+	if cache != "" {
+		if err := saveCache(cache, key); err != nil {
+			return nil, err
+		}
+	}
+	return key.Outputs, nil
+}
+
+// loadCache reads the raw outputs of the cache file at path, checking that they were computed
+// by the model and from the images of key. The error wraps os.ErrNotExist when there is no file.
+func loadCache(path string, key rawCache) ([]*detector.RawOutput, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache: %w", err)
+	}
+	defer file.Close()
+
+	var cache rawCache
+	if err := gob.NewDecoder(file).Decode(&cache); err != nil {
+		return nil, fmt.Errorf("failed to decode cache %s: %w", path, err)
+	}
+	switch {
+	case cache.ModelSHA256 != key.ModelSHA256:
+		return nil, fmt.Errorf("cache %s holds the outputs of model %s, not %s", path, cache.ModelSHA256, key.ModelSHA256)
+	case !slices.Equal(cache.Images, key.Images):
+		return nil, fmt.Errorf("cache %s holds the outputs of other images than the dataset", path)
+	case len(cache.Outputs) != len(cache.Images):
+		return nil, fmt.Errorf("corrupted cache %s: %d outputs for %d images", path, len(cache.Outputs), len(cache.Images))
+	}
+	for i, output := range cache.Outputs {
+		if err := output.Validate(); err != nil {
+			return nil, fmt.Errorf("corrupted cache %s: output of %s: %w", path, cache.Images[i], err)
+		}
+	}
+	return cache.Outputs, nil
+}
+
+// saveCache writes cache to the file at path, replacing it if it exists.
+func saveCache(path string, cache rawCache) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create cache: %w", err)
+	}
+	if err := gob.NewEncoder(file).Encode(cache); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to save cache: %w", err)
+	}
+	return file.Close()
+}
+
+// loadImage decodes the JPEG or PNG image at path.
+func loadImage(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return img, nil
+}
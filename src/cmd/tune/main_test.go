@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/detector"
+)
+
+func TestLoadCache(t *testing.T) {
+	outputs := []*detector.RawOutput{
+		{ImageSize: detector.Size{Width: 2, Height: 1}, Shape: []int{1, 1}, Data: []float32{1}},
+		{ImageSize: detector.Size{Width: 1, Height: 2}, Shape: []int{1, 1}, Data: []float32{2}},
+	}
+	saved := rawCache{ModelSHA256: "abc", Images: []string{"a.jpg", "b.jpg"}, Outputs: outputs}
+	path := filepath.Join(t.TempDir(), "cache.gob")
+	if err := saveCache(path, saved); err != nil {
+		t.Fatalf("saveCache returned error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		key     rawCache
+		wantErr bool
+	}{
+		{name: "same model and images", key: rawCache{ModelSHA256: "abc", Images: []string{"a.jpg", "b.jpg"}}},
+		{name: "other model", key: rawCache{ModelSHA256: "def", Images: []string{"a.jpg", "b.jpg"}}, wantErr: true},
+		{name: "other images", key: rawCache{ModelSHA256: "abc", Images: []string{"a.jpg", "c.jpg"}}, wantErr: true},
+		{name: "reordered images", key: rawCache{ModelSHA256: "abc", Images: []string{"b.jpg", "a.jpg"}}, wantErr: true},
+		{name: "more images", key: rawCache{ModelSHA256: "abc", Images: []string{"a.jpg", "b.jpg", "c.jpg"}}, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := loadCache(path, tc.key)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %d outputs", len(got))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadCache returned error: %v", err)
+			}
+			if len(got) != 2 || got[1].Data[0] != 2 || got[1].ImageSize != outputs[1].ImageSize {
+				t.Errorf("Unexpected outputs %+v", got)
+			}
+		})
+	}
+}
+
+func TestLoadCache_Missing(t *testing.T) {
+	_, err := loadCache(filepath.Join(t.TempDir(), "missing.gob"), rawCache{})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected an error wrapping os.ErrNotExist, got %v", err)
+	}
+}
+
+func TestLoadCache_Corrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.gob")
+	if err := saveCache(path, rawCache{ModelSHA256: "abc", Images: []string{"a.jpg"}}); err != nil {
+		t.Fatalf("saveCache returned error: %v", err)
+	}
+	if _, err := loadCache(path, rawCache{ModelSHA256: "abc", Images: []string{"a.jpg"}}); err == nil {
+		t.Error("Expected an error for a cache without outputs")
+	}
+
+	truncated := rawCache{ModelSHA256: "abc", Images: []string{"a.jpg"},
+		Outputs: []*detector.RawOutput{{Shape: []int{5, 2}, Data: []float32{1, 2, 3}}}}
+	if err := saveCache(path, truncated); err != nil {
+		t.Fatalf("saveCache returned error: %v", err)
+	}
+	if _, err := loadCache(path, rawCache{ModelSHA256: "abc", Images: []string{"a.jpg"}}); err == nil {
+		t.Error("Expected an error for an output shorter than its shape")
+	}
+
+	if err := os.WriteFile(path, []byte("not gob"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadCache(path, rawCache{}); err == nil || errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a decoding error, got %v", err)
+	}
+}
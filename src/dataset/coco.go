@@ -0,0 +1,291 @@
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+// Category is a COCO category.
+type Category struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Supercategory string `json:"supercategory,omitempty"`
+}
+
+// COCOAnnotation is an object annotation of a COCO dataset.
+type COCOAnnotation struct {
+	ID         int64 `json:"id"`
+	ImageID    int64 `json:"image_id"`
+	CategoryID int   `json:"category_id"`
+	// BBox is the top left corner and the size of the box, [x, y, width, height].
+	BBox    [4]float32 `json:"bbox"`
+	Area    float32    `json:"area"`
+	IsCrowd int        `json:"iscrowd"`
+	// Score is the confidence of predicted annotations, used as pre-annotations.
+	Score float32 `json:"score,omitempty"`
+}
+
+// COCOResult is a detection in the COCO results format read by evaluation tools such as pycocotools.
+type COCOResult struct {
+	ImageID    int64      `json:"image_id"`
+	CategoryID int        `json:"category_id"`
+	BBox       [4]float32 `json:"bbox"`
+	Score      float32    `json:"score"`
+}
+
+// COCODataset is a COCO dataset file, with images, categories and annotations.
+type COCODataset struct {
+	Images      []Image          `json:"images"`
+	Annotations []COCOAnnotation `json:"annotations"`
+	Categories  []Category       `json:"categories"`
+}
+
+// coco80CategoryIDs are the COCO category IDs of the 80 classes of models trained on COCO,
+// which skip the IDs of the 11 categories without annotations.
+var coco80CategoryIDs = []int{
+	1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 13, 14, 15, 16, 17, 18, 19, 20, 21,
+	22, 23, 24, 25, 27, 28, 31, 32, 33, 34, 35, 36, 37, 38, 39, 40, 41, 42, 43, 44,
+	46, 47, 48, 49, 50, 51, 52, 53, 54, 55, 56, 57, 58, 59, 60, 61, 62, 63, 64, 65,
+	67, 70, 72, 73, 74, 75, 76, 77, 78, 79, 80, 81, 82, 84, 85, 86, 87, 88, 89, 90,
+}
+
+// NewCategories returns one category per class label, with IDs starting at 1.
+func NewCategories(classes []string) []Category {
+	categories := make([]Category, len(classes))
+	for i, name := range classes {
+		categories[i] = Category{ID: i + 1, Name: name}
+	}
+	return categories
+}
+
+// NewCOCO80Categories returns the categories of the 80 classes of models trained on COCO,
+// with the official COCO category IDs so results can be evaluated against COCO annotations.
+func NewCOCO80Categories(classes []string) ([]Category, error) {
+	if len(classes) != len(coco80CategoryIDs) {
+		return nil, fmt.Errorf("expected the %d COCO classes, got %d", len(coco80CategoryIDs), len(classes))
+	}
+	categories := NewCategories(classes)
+	for i := range categories {
+		categories[i].ID = coco80CategoryIDs[i]
+	}
+	return categories, nil
+}
+
+// categoryIDs maps category names to IDs.
+func categoryIDs(categories []Category) map[string]int {
+	ids := make(map[string]int, len(categories))
+	for _, c := range categories {
+		ids[c.Name] = c.ID
+	}
+	return ids
+}
+
+// categoryOf returns the ID of the category named like the label of box.
+func categoryOf(ids map[string]int, box *onnx.BoundingBox) (int, error) {
+	id, ok := ids[box.Label]
+	if !ok {
+		return 0, fmt.Errorf("no category for label %q", box.Label)
+	}
+	return id, nil
+}
+
+// xywh returns the COCO bbox of box.
+func xywh(box *onnx.BoundingBox) [4]float32 {
+	x, y, w, h := box.XYWH()
+	return [4]float32{x, y, w, h}
+}
+
+// NewCOCOResults converts detections to COCO results.
+func NewCOCOResults(images []ImageDetections, categories []Category) ([]COCOResult, error) {
+	ids := categoryIDs(categories)
+	results := make([]COCOResult, 0)
+	for _, image := range images {
+		for _, box := range image.Boxes {
+			categoryID, err := categoryOf(ids, &box)
+			if err != nil {
+				return nil, fmt.Errorf("image %d: %w", image.Image.ID, err)
+			}
+			results = append(results, COCOResult{
+				ImageID:    image.Image.ID,
+				CategoryID: categoryID,
+				BBox:       xywh(&box),
+				Score:      box.Confidence,
+			})
+		}
+	}
+	return results, nil
+}
+
+// WriteCOCOResults writes detections to w as a COCO results JSON array.
+func WriteCOCOResults(w io.Writer, images []ImageDetections, categories []Category) error {
+	results, err := NewCOCOResults(images, categories)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(results)
+}
+
+// ReadCOCOResults reads a COCO results JSON array and groups the detections by image ID.
+// Boxes are labelled with the name of their category and ClassID is the position of the
+// category in categories.
+func ReadCOCOResults(r io.Reader, categories []Category) (map[int64][]onnx.BoundingBox, error) {
+	var results []COCOResult
+	if err := json.NewDecoder(r).Decode(&results); err != nil {
+		return nil, fmt.Errorf("failed to decode COCO results: %w", err)
+	}
+	lookup := newCategoryLookup(categories)
+	grouped := make(map[int64][]onnx.BoundingBox)
+	for _, result := range results {
+		box, err := lookup.box(result.CategoryID, result.BBox, result.Score)
+		if err != nil {
+			return nil, fmt.Errorf("image %d: %w", result.ImageID, err)
+		}
+		grouped[result.ImageID] = append(grouped[result.ImageID], box)
+	}
+	return grouped, nil
+}
+
+// NewCOCODataset builds a COCO dataset from detections. The confidence of each box is kept
+// as the annotation score, for use as pre-annotations. Crowd regions are written with iscrowd set.
+func NewCOCODataset(images []ImageDetections, categories []Category) (*COCODataset, error) {
+	ids := categoryIDs(categories)
+	dataset := &COCODataset{
+		Images:      make([]Image, 0, len(images)),
+		Annotations: make([]COCOAnnotation, 0),
+		Categories:  categories,
+	}
+	for _, image := range images {
+		dataset.Images = append(dataset.Images, image.Image)
+		for _, group := range []struct {
+			boxes   []onnx.BoundingBox
+			isCrowd int
+		}{{image.Boxes, 0}, {image.Crowd, 1}} {
+			for _, box := range group.boxes {
+				categoryID, err := categoryOf(ids, &box)
+				if err != nil {
+					return nil, fmt.Errorf("image %d: %w", image.Image.ID, err)
+				}
+				bbox := xywh(&box)
+				dataset.Annotations = append(dataset.Annotations, COCOAnnotation{
+					ID:         int64(len(dataset.Annotations) + 1),
+					ImageID:    image.Image.ID,
+					CategoryID: categoryID,
+					BBox:       bbox,
+					Area:       bbox[2] * bbox[3],
+					IsCrowd:    group.isCrowd,
+					Score:      box.Confidence,
+				})
+			}
+		}
+	}
+	return dataset, nil
+}
+
+// Write writes the dataset to w as JSON.
+func (d *COCODataset) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(d)
+}
+
+// WriteFile writes the dataset to a JSON file.
+func (d *COCODataset) WriteFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create COCO dataset: %w", err)
+	}
+	if err := d.Write(file); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write COCO dataset: %w", err)
+	}
+	return file.Close()
+}
+
+// ReadCOCODataset reads a COCO dataset JSON file from r.
+func ReadCOCODataset(r io.Reader) (*COCODataset, error) {
+	dataset := &COCODataset{}
+	if err := json.NewDecoder(r).Decode(dataset); err != nil {
+		return nil, fmt.Errorf("failed to decode COCO dataset: %w", err)
+	}
+	return dataset, nil
+}
+
+// LoadCOCODataset reads the COCO dataset JSON file at path.
+func LoadCOCODataset(path string) (*COCODataset, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open COCO dataset: %w", err)
+	}
+	defer file.Close()
+	return ReadCOCODataset(file)
+}
+
+// Classes returns the category names, in the order of Categories.
+func (d *COCODataset) Classes() []string {
+	classes := make([]string, len(d.Categories))
+	for i, c := range d.Categories {
+		classes[i] = c.Name
+	}
+	return classes
+}
+
+// Detections groups the annotations by image, in the order of Images. Boxes are labelled with
+// the name of their category, ClassID is the position of the category in Categories, and the
+// confidence is the annotation score, or 1 for ground truth annotations without score.
+// Annotations with iscrowd set go to Crowd instead of Boxes.
+func (d *COCODataset) Detections() ([]ImageDetections, error) {
+	lookup := newCategoryLookup(d.Categories)
+	images := make([]ImageDetections, len(d.Images))
+	byID := make(map[int64]int, len(d.Images))
+	for i, image := range d.Images {
+		images[i] = ImageDetections{Image: image, Boxes: make([]onnx.BoundingBox, 0)}
+		byID[image.ID] = i
+	}
+	for _, annotation := range d.Annotations {
+		i, ok := byID[annotation.ImageID]
+		if !ok {
+			return nil, fmt.Errorf("annotation %d refers to unknown image %d", annotation.ID, annotation.ImageID)
+		}
+		score := annotation.Score
+		if score == 0 {
+			score = 1
+		}
+		box, err := lookup.box(annotation.CategoryID, annotation.BBox, score)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %d: %w", annotation.ID, err)
+		}
+		if annotation.IsCrowd != 0 {
+			images[i].Crowd = append(images[i].Crowd, box)
+			continue
+		}
+		images[i].Boxes = append(images[i].Boxes, box)
+	}
+	return images, nil
+}
+
+// categoryLookup finds categories by ID.
+type categoryLookup struct {
+	categories []Category
+	index      map[int]int
+}
+
+func newCategoryLookup(categories []Category) categoryLookup {
+	index := make(map[int]int, len(categories))
+	for i, c := range categories {
+		index[c.ID] = i
+	}
+	return categoryLookup{categories: categories, index: index}
+}
+
+// box returns the bounding box of a COCO bbox of category categoryID.
+func (l categoryLookup) box(categoryID int, bbox [4]float32, score float32) (onnx.BoundingBox, error) {
+	i, ok := l.index[categoryID]
+	if !ok {
+		return onnx.BoundingBox{}, fmt.Errorf("unknown category %d", categoryID)
+	}
+	box := onnx.BoundingBox{Label: l.categories[i].Name, ClassID: i, Confidence: score}
+	box.SetXYWH(bbox[0], bbox[1], bbox[2], bbox[3])
+	return box, nil
+}
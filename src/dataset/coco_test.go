@@ -0,0 +1,147 @@
+package dataset_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/dataset"
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+func sampleDetections() []dataset.ImageDetections {
+	return []dataset.ImageDetections{
+		{
+			Image: dataset.Image{ID: 1, FileName: "a.jpg", Width: 640, Height: 480},
+			Boxes: []onnx.BoundingBox{
+				{Label: "dog", ClassID: 1, Confidence: 0.75, X1: 10, Y1: 20, X2: 110, Y2: 70},
+				{Label: "cat", ClassID: 0, Confidence: 0.5, X1: 0, Y1: 0, X2: 4, Y2: 8},
+			},
+		},
+		{Image: dataset.Image{ID: 2, FileName: "b.jpg", Width: 100, Height: 100}},
+	}
+}
+
+func TestWriteCOCOResults(t *testing.T) {
+	var buf bytes.Buffer
+	categories := dataset.NewCategories([]string{"cat", "dog"})
+	if err := dataset.WriteCOCOResults(&buf, sampleDetections(), categories); err != nil {
+		t.Fatalf("WriteCOCOResults returned error: %v", err)
+	}
+	want := `[{"image_id":1,"category_id":2,"bbox":[10,20,100,50],"score":0.75},` +
+		`{"image_id":1,"category_id":1,"bbox":[0,0,4,8],"score":0.5}]`
+	if got := strings.TrimSpace(buf.String()); got != want {
+		t.Errorf("WriteCOCOResults wrote\n%s\nwant\n%s", got, want)
+	}
+
+	grouped, err := dataset.ReadCOCOResults(strings.NewReader(want), categories)
+	if err != nil {
+		t.Fatalf("ReadCOCOResults returned error: %v", err)
+	}
+	boxes := grouped[1]
+	if len(boxes) != 2 || boxes[0].Label != "dog" || boxes[0].ClassID != 1 || boxes[0].X2 != 110 || boxes[0].Y2 != 70 {
+		t.Errorf("Unexpected boxes read back %+v", boxes)
+	}
+}
+
+func TestWriteCOCOResults_UnknownLabel(t *testing.T) {
+	var buf bytes.Buffer
+	err := dataset.WriteCOCOResults(&buf, sampleDetections(), dataset.NewCategories([]string{"cat"}))
+	if err == nil || !strings.Contains(err.Error(), "dog") {
+		t.Errorf("Expected an error for the dog label, got %v", err)
+	}
+	if _, err := dataset.ReadCOCOResults(strings.NewReader(`[{"image_id":1,"category_id":9}]`), nil); err == nil {
+		t.Error("Expected an error for an unknown category")
+	}
+}
+
+func TestCOCODataset_RoundTrip(t *testing.T) {
+	d, err := dataset.NewCOCODataset(sampleDetections(), dataset.NewCategories([]string{"cat", "dog"}))
+	if err != nil {
+		t.Fatalf("NewCOCODataset returned error: %v", err)
+	}
+	if len(d.Images) != 2 || len(d.Annotations) != 2 || d.Annotations[0].Area != 5000 || d.Annotations[1].ID != 2 {
+		t.Errorf("Unexpected dataset %+v", d)
+	}
+
+	path := filepath.Join(t.TempDir(), "instances.json")
+	if err := d.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	loaded, err := dataset.LoadCOCODataset(path)
+	if err != nil {
+		t.Fatalf("LoadCOCODataset returned error: %v", err)
+	}
+	if classes := loaded.Classes(); len(classes) != 2 || classes[1] != "dog" {
+		t.Errorf("Unexpected classes %v", classes)
+	}
+
+	images, err := loaded.Detections()
+	if err != nil {
+		t.Fatalf("Detections returned error: %v", err)
+	}
+	if len(images) != 2 || images[0].Image.FileName != "a.jpg" || len(images[0].Boxes) != 2 || len(images[1].Boxes) != 0 {
+		t.Fatalf("Unexpected detections %+v", images)
+	}
+	if box := images[0].Boxes[0]; box.Label != "dog" || box.Confidence != 0.75 || box.X1 != 10 || box.Y2 != 70 {
+		t.Errorf("Unexpected box read back %+v", box)
+	}
+}
+
+func TestCOCODataset_GroundTruth(t *testing.T) {
+	data := `{
+		"images": [{"id": 7, "file_name": "x.jpg", "width": 10, "height": 10}],
+		"annotations": [
+			{"id": 1, "image_id": 7, "category_id": 3, "bbox": [1, 2, 3, 4], "area": 12, "iscrowd": 0},
+			{"id": 2, "image_id": 7, "category_id": 3, "bbox": [0, 0, 5, 5], "area": 25, "iscrowd": 1}
+		],
+		"categories": [{"id": 3, "name": "car"}]
+	}`
+	d, err := dataset.ReadCOCODataset(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadCOCODataset returned error: %v", err)
+	}
+	images, err := d.Detections()
+	if err != nil {
+		t.Fatalf("Detections returned error: %v", err)
+	}
+	if box := images[0].Boxes[0]; box.Label != "car" || box.ClassID != 0 || box.Confidence != 1 || box.X2 != 4 || box.Y2 != 6 {
+		t.Errorf("Unexpected ground truth box %+v", box)
+	}
+	if len(images[0].Boxes) != 1 || len(images[0].Crowd) != 1 || images[0].Crowd[0].X2 != 5 {
+		t.Errorf("Expected the crowd annotation apart from the boxes, got %+v", images[0])
+	}
+
+	back, err := dataset.NewCOCODataset(images, d.Categories)
+	if err != nil {
+		t.Fatalf("NewCOCODataset returned error: %v", err)
+	}
+	if len(back.Annotations) != 2 || back.Annotations[0].IsCrowd != 0 || back.Annotations[1].IsCrowd != 1 {
+		t.Errorf("Expected the crowd annotation written back with iscrowd, got %+v", back.Annotations)
+	}
+
+	d.Annotations[0].ImageID = 8
+	if _, err := d.Detections(); err == nil {
+		t.Error("Expected an error for an annotation of an unknown image")
+	}
+}
+
+func TestNewCOCO80Categories(t *testing.T) {
+	classes := make([]string, 80)
+	categories, err := dataset.NewCOCO80Categories(classes)
+	if err != nil {
+		t.Fatalf("NewCOCO80Categories returned error: %v", err)
+	}
+	if categories[0].ID != 1 || categories[11].ID != 13 || categories[79].ID != 90 {
+		t.Errorf("Unexpected COCO category IDs %v, %v, %v", categories[0].ID, categories[11].ID, categories[79].ID)
+	}
+	if _, err := dataset.NewCOCO80Categories(classes[:3]); err == nil {
+		t.Error("Expected an error for a class list which is not COCO")
+	}
+	data, _ := json.Marshal(categories[0])
+	if string(data) != `{"id":1,"name":""}` {
+		t.Errorf("Unexpected category JSON %s", data)
+	}
+}
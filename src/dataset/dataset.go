@@ -0,0 +1,29 @@
+// Package dataset converts detections to and from the annotation formats read by evaluation
+// tools and labeling platforms: COCO JSON, Pascal VOC XML and YOLO txt.
+//
+// Detections are grouped by image in ImageDetections values. Class labels are matched by name,
+// so boxes remapped with onnx.ClassRemap export under their output label.
+package dataset
+
+import (
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+// Image describes an annotated image.
+type Image struct {
+	// ID identifies the image in COCO files.
+	ID int64 `json:"id"`
+	// FileName is the path of the image, relative to the dataset root.
+	FileName string `json:"file_name"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+// ImageDetections holds the boxes detected or annotated on an image, in pixel coordinates.
+type ImageDetections struct {
+	Image Image
+	Boxes []onnx.BoundingBox
+	// Crowd holds the regions annotated as groups of objects, the COCO iscrowd annotations,
+	// which evaluation ignores instead of counting them as objects to detect.
+	Crowd []onnx.BoundingBox
+}
@@ -0,0 +1,148 @@
+package dataset
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+// VOCAnnotation is the Pascal VOC XML annotation of an image.
+// Box coordinates are in pixels, as exported by CVAT and Label Studio.
+type VOCAnnotation struct {
+	XMLName   xml.Name    `xml:"annotation"`
+	Folder    string      `xml:"folder,omitempty"`
+	Filename  string      `xml:"filename"`
+	Path      string      `xml:"path,omitempty"`
+	Size      VOCSize     `xml:"size"`
+	Segmented int         `xml:"segmented"`
+	Objects   []VOCObject `xml:"object"`
+}
+
+// VOCSize is the size of the annotated image.
+type VOCSize struct {
+	Width  int `xml:"width"`
+	Height int `xml:"height"`
+	Depth  int `xml:"depth"`
+}
+
+// VOCObject is an annotated object.
+type VOCObject struct {
+	Name      string `xml:"name"`
+	Pose      string `xml:"pose"`
+	Truncated int    `xml:"truncated"`
+	Difficult int    `xml:"difficult"`
+	BndBox    VOCBox `xml:"bndbox"`
+}
+
+// VOCBox is the corners of an object box.
+type VOCBox struct {
+	XMin float32 `xml:"xmin"`
+	YMin float32 `xml:"ymin"`
+	XMax float32 `xml:"xmax"`
+	YMax float32 `xml:"ymax"`
+}
+
+// NewVOCAnnotation converts the detections of an image to a VOC annotation.
+func NewVOCAnnotation(image ImageDetections) VOCAnnotation {
+	annotation := VOCAnnotation{
+		Filename: filepath.Base(image.Image.FileName),
+		Path:     image.Image.FileName,
+		Size:     VOCSize{Width: image.Image.Width, Height: image.Image.Height, Depth: 3},
+		Objects:  make([]VOCObject, 0, len(image.Boxes)),
+	}
+	if dir := filepath.Dir(image.Image.FileName); dir != "." {
+		annotation.Folder = filepath.Base(dir)
+	}
+	for _, box := range image.Boxes {
+		annotation.Objects = append(annotation.Objects, VOCObject{
+			Name:   box.Label,
+			Pose:   "Unspecified",
+			BndBox: VOCBox{XMin: box.X1, YMin: box.Y1, XMax: box.X2, YMax: box.Y2},
+		})
+	}
+	return annotation
+}
+
+// Detections converts the annotation back to detections with a confidence of 1.
+// ClassID is the position of the object name in classes, which must hold every name.
+func (a *VOCAnnotation) Detections(classes []string) (ImageDetections, error) {
+	labels := onnx.NewLabelSet(classes)
+	image := ImageDetections{
+		Image: Image{FileName: a.Filename, Width: a.Size.Width, Height: a.Size.Height},
+		Boxes: make([]onnx.BoundingBox, 0, len(a.Objects)),
+	}
+	if a.Path != "" {
+		image.Image.FileName = a.Path
+	}
+	for _, object := range a.Objects {
+		id, ok := labels.ID(object.Name)
+		if !ok {
+			return ImageDetections{}, fmt.Errorf("unknown class %q in %s", object.Name, a.Filename)
+		}
+		image.Boxes = append(image.Boxes, onnx.BoundingBox{
+			Label:      object.Name,
+			ClassID:    id,
+			Confidence: 1,
+			X1:         object.BndBox.XMin,
+			Y1:         object.BndBox.YMin,
+			X2:         object.BndBox.XMax,
+			Y2:         object.BndBox.YMax,
+		})
+	}
+	return image, nil
+}
+
+// WriteVOC writes the detections of an image to w as Pascal VOC XML.
+func WriteVOC(w io.Writer, image ImageDetections) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(NewVOCAnnotation(image)); err != nil {
+		return fmt.Errorf("failed to encode VOC annotation: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ReadVOC reads a Pascal VOC XML annotation. See VOCAnnotation.Detections for classes.
+func ReadVOC(r io.Reader, classes []string) (ImageDetections, error) {
+	var annotation VOCAnnotation
+	if err := xml.NewDecoder(r).Decode(&annotation); err != nil {
+		return ImageDetections{}, fmt.Errorf("failed to decode VOC annotation: %w", err)
+	}
+	return annotation.Detections(classes)
+}
+
+// WriteVOCFile writes the detections of an image to a Pascal VOC XML file.
+func WriteVOCFile(path string, image ImageDetections) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create VOC annotation: %w", err)
+	}
+	if err := WriteVOC(file, image); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// LoadVOC reads the Pascal VOC XML file at path.
+func LoadVOC(path string, classes []string) (ImageDetections, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ImageDetections{}, fmt.Errorf("failed to open VOC annotation: %w", err)
+	}
+	defer file.Close()
+
+	image, err := ReadVOC(file, classes)
+	if err != nil {
+		return ImageDetections{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return image, nil
+}
@@ -0,0 +1,59 @@
+package dataset_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/dataset"
+)
+
+func TestWriteVOC(t *testing.T) {
+	var buf bytes.Buffer
+	image := sampleDetections()[0]
+	image.Image.FileName = "images/a.jpg"
+	if err := dataset.WriteVOC(&buf, image); err != nil {
+		t.Fatalf("WriteVOC returned error: %v", err)
+	}
+	xml := buf.String()
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		"<folder>images</folder>",
+		"<filename>a.jpg</filename>",
+		"<width>640</width>",
+		"<height>480</height>",
+		"<depth>3</depth>",
+		"<name>dog</name>",
+		"<xmin>10</xmin>",
+		"<ymax>70</ymax>",
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("Expected %s in\n%s", want, xml)
+		}
+	}
+
+	read, err := dataset.ReadVOC(&buf, []string{"cat", "dog"})
+	if err != nil {
+		t.Fatalf("ReadVOC returned error: %v", err)
+	}
+	if read.Image.FileName != "images/a.jpg" || read.Image.Width != 640 || len(read.Boxes) != 2 {
+		t.Fatalf("Unexpected annotation read back %+v", read)
+	}
+	if box := read.Boxes[0]; box.Label != "dog" || box.ClassID != 1 || box.X1 != 10 || box.Y2 != 70 || box.Confidence != 1 {
+		t.Errorf("Unexpected box read back %+v", box)
+	}
+}
+
+func TestVOCFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.xml")
+	if err := dataset.WriteVOCFile(path, sampleDetections()[0]); err != nil {
+		t.Fatalf("WriteVOCFile returned error: %v", err)
+	}
+	if _, err := dataset.LoadVOC(path, []string{"cat"}); err == nil || !strings.Contains(err.Error(), "dog") {
+		t.Errorf("Expected an error for the unknown dog class, got %v", err)
+	}
+	if image, err := dataset.LoadVOC(path, []string{"cat", "dog"}); err != nil || image.Image.FileName != "a.jpg" {
+		t.Errorf("LoadVOC returned %+v, %v", image, err)
+	}
+}
@@ -0,0 +1,124 @@
+package dataset
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+// WriteYOLO writes the boxes of an image to w in the YOLO txt format, one box per line:
+// the class ID then the center and size of the box normalized by the image size,
+//
+//	<class> <cx> <cy> <w> <h> [<confidence>]
+//
+// The class ID is the position of the box label in classes. The confidence column, as saved
+// by Ultralytics with save_conf, is written when withConfidence is set.
+func WriteYOLO(w io.Writer, image ImageDetections, classes []string, withConfidence bool) error {
+	if image.Image.Width <= 0 || image.Image.Height <= 0 {
+		return fmt.Errorf("image %q has no size to normalize boxes with", image.Image.FileName)
+	}
+	labels := onnx.NewLabelSet(classes)
+	buf := bufio.NewWriter(w)
+	for _, box := range image.Boxes {
+		id, ok := labels.ID(box.Label)
+		if !ok {
+			return fmt.Errorf("unknown class %q in %s", box.Label, image.Image.FileName)
+		}
+		normalized := box.Normalized(image.Image.Width, image.Image.Height)
+		cx, cy, bw, bh := normalized.CXCYWH()
+		fmt.Fprintf(buf, "%d %.6f %.6f %.6f %.6f", id, cx, cy, bw, bh)
+		if withConfidence {
+			fmt.Fprintf(buf, " %.6f", box.Confidence)
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Flush()
+}
+
+// ReadYOLO reads YOLO txt labels for image, whose size is used to convert the boxes to pixels.
+// Boxes are labelled from classes, and their confidence is the sixth column when present, or 1.
+func ReadYOLO(r io.Reader, image Image, classes []string) (ImageDetections, error) {
+	if image.Width <= 0 || image.Height <= 0 {
+		return ImageDetections{}, fmt.Errorf("image %q has no size to convert boxes with", image.FileName)
+	}
+	result := ImageDetections{Image: image, Boxes: make([]onnx.BoundingBox, 0)}
+	labels := onnx.NewLabelSet(classes)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 5 && len(fields) != 6 {
+			return ImageDetections{}, fmt.Errorf("line %d: expected 5 or 6 fields, got %d", line, len(fields))
+		}
+		id, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return ImageDetections{}, fmt.Errorf("line %d: invalid class ID %q", line, fields[0])
+		}
+		label, err := labels.Name(id)
+		if err != nil {
+			return ImageDetections{}, fmt.Errorf("line %d: %w", line, err)
+		}
+		values := []float32{0, 0, 0, 0, 1}
+		for i, field := range fields[1:] {
+			value, err := strconv.ParseFloat(field, 32)
+			if err != nil {
+				return ImageDetections{}, fmt.Errorf("line %d: invalid value %q", line, field)
+			}
+			values[i] = float32(value)
+		}
+
+		box := onnx.BoundingBox{Label: label, ClassID: id, Confidence: values[4]}
+		box.SetCXCYWH(values[0], values[1], values[2], values[3])
+		result.Boxes = append(result.Boxes, box.Absolute(image.Width, image.Height))
+	}
+	if err := scanner.Err(); err != nil {
+		return ImageDetections{}, fmt.Errorf("failed to read YOLO labels: %w", err)
+	}
+	return result, nil
+}
+
+// WriteYOLOClasses writes the class list of YOLO labels, one name per line as in classes.txt
+// or obj.names files. onnx.ReadLabels reads it back.
+func WriteYOLOClasses(w io.Writer, classes []string) error {
+	buf := bufio.NewWriter(w)
+	for _, name := range classes {
+		buf.WriteString(name)
+		buf.WriteByte('\n')
+	}
+	return buf.Flush()
+}
+
+// WriteYOLOFile writes the boxes of an image to a YOLO txt file.
+func WriteYOLOFile(path string, image ImageDetections, classes []string, withConfidence bool) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create YOLO labels: %w", err)
+	}
+	if err := WriteYOLO(file, image, classes, withConfidence); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// LoadYOLO reads the YOLO txt file at path, with labels for image.
+func LoadYOLO(path string, image Image, classes []string) (ImageDetections, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ImageDetections{}, fmt.Errorf("failed to open YOLO labels: %w", err)
+	}
+	defer file.Close()
+
+	result, err := ReadYOLO(file, image, classes)
+	if err != nil {
+		return ImageDetections{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,107 @@
+package dataset_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/dataset"
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+func TestWriteYOLO(t *testing.T) {
+	var buf bytes.Buffer
+	image := sampleDetections()[0]
+	if err := dataset.WriteYOLO(&buf, image, []string{"cat", "dog"}, false); err != nil {
+		t.Fatalf("WriteYOLO returned error: %v", err)
+	}
+	// The dog is 100x50 pixels centered on (60, 45) of a 640x480 image.
+	want := "1 0.093750 0.093750 0.156250 0.104167\n0 0.003125 0.008333 0.006250 0.016667\n"
+	if buf.String() != want {
+		t.Errorf("WriteYOLO wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	read, err := dataset.ReadYOLO(&buf, image.Image, []string{"cat", "dog"})
+	if err != nil {
+		t.Fatalf("ReadYOLO returned error: %v", err)
+	}
+	if len(read.Boxes) != 2 {
+		t.Fatalf("Expected 2 boxes, got %v", read.Boxes)
+	}
+	box := read.Boxes[0]
+	if box.Label != "dog" || box.ClassID != 1 || box.Confidence != 1 || !approx(box.X1, 10) || !approx(box.Y2, 70) {
+		t.Errorf("Unexpected box read back %+v", box)
+	}
+}
+
+func TestWriteYOLO_Confidence(t *testing.T) {
+	var buf bytes.Buffer
+	image := dataset.ImageDetections{
+		Image: dataset.Image{Width: 100, Height: 100},
+		Boxes: []onnx.BoundingBox{{Label: "cat", Confidence: 0.25, X1: 0, Y1: 0, X2: 50, Y2: 100}},
+	}
+	if err := dataset.WriteYOLO(&buf, image, []string{"cat"}, true); err != nil {
+		t.Fatal(err)
+	}
+	if want := "0 0.250000 0.500000 0.500000 1.000000 0.250000\n"; buf.String() != want {
+		t.Errorf("WriteYOLO wrote %q, want %q", buf.String(), want)
+	}
+	read, err := dataset.ReadYOLO(&buf, image.Image, []string{"cat"})
+	if err != nil || read.Boxes[0].Confidence != 0.25 {
+		t.Errorf("Expected the confidence column read back, got %+v, %v", read.Boxes, err)
+	}
+
+	image.Image.Width = 0
+	if err := dataset.WriteYOLO(&buf, image, []string{"cat"}, false); err == nil {
+		t.Error("Expected an error without image size")
+	}
+	image.Image.Width = 100
+	if err := dataset.WriteYOLO(&buf, image, []string{"dog"}, false); err == nil {
+		t.Error("Expected an error for an unknown class")
+	}
+}
+
+func TestReadYOLO_Errors(t *testing.T) {
+	image := dataset.Image{Width: 10, Height: 10}
+	for _, input := range []string{"0 0.5 0.5 0.1", "x 0.5 0.5 0.1 0.1", "3 0.5 0.5 0.1 0.1", "0 0.5 half 0.1 0.1"} {
+		if _, err := dataset.ReadYOLO(strings.NewReader(input), image, []string{"cat"}); err == nil {
+			t.Errorf("Expected ReadYOLO(%q) to fail", input)
+		}
+	}
+	read, err := dataset.ReadYOLO(strings.NewReader("\n0 0.5 0.5 1 1\n\n"), image, []string{"cat"})
+	if err != nil || len(read.Boxes) != 1 {
+		t.Errorf("Expected blank lines to be skipped, got %+v, %v", read.Boxes, err)
+	}
+	if _, err := dataset.ReadYOLO(strings.NewReader("0 0.5 0.5 1 1"), dataset.Image{FileName: "a.jpg"}, []string{"cat"}); err == nil {
+		t.Error("Expected an error for an image without size")
+	}
+}
+
+func TestYOLOFilesAndClasses(t *testing.T) {
+	dir := t.TempDir()
+	image := sampleDetections()[0]
+	classes := []string{"cat", "dog"}
+	path := filepath.Join(dir, "a.txt")
+	if err := dataset.WriteYOLOFile(path, image, classes, true); err != nil {
+		t.Fatalf("WriteYOLOFile returned error: %v", err)
+	}
+	read, err := dataset.LoadYOLO(path, image.Image, classes)
+	if err != nil || len(read.Boxes) != 2 || read.Boxes[1].Confidence != 0.5 {
+		t.Errorf("LoadYOLO returned %+v, %v", read, err)
+	}
+
+	var buf bytes.Buffer
+	if err := dataset.WriteYOLOClasses(&buf, classes); err != nil {
+		t.Fatal(err)
+	}
+	labels, err := onnx.ReadLabels(&buf)
+	if err != nil || labels.Len() != 2 {
+		t.Errorf("Expected the class list read back, got %v, %v", labels.Names(), err)
+	}
+}
+
+func approx(a, b float32) bool {
+	d := a - b
+	return d < 1e-3 && d > -1e-3
+}
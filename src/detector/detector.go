@@ -0,0 +1,289 @@
+// Package detector provides a configurable object detector built on the onnx package.
+//
+// A Detector owns an ONNX session and the pre and postprocessing settings of a model, so
+// supporting a new YOLO export only requires describing it with Options:
+//
+//	d, err := detector.New(detector.Options{
+//		ModelPath:           "yolo11s.onnx",
+//		LibraryPath:         "onnxruntime.so",
+//		Classes:             classes,
+//		ConfidenceThreshold: 0.5,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer d.Close()
+//	boxes, err := d.AnalyzeImage(img)
+//
+// Analyze returns a Result instead, with the stage timings and the model and settings used,
+// and with the raw model output when KeepRawOutput is set, to decode it again later.
+package detector
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+// Detector runs an object detection model on images. It is safe for concurrent use:
+// runs are serialized on the underlying session while pre and postprocessing happen in parallel.
+type Detector struct {
+	options Options
+	layout  onnx.OutputLayout
+	session *onnx.ONNXSession
+	model   ModelInfo
+}
+
+// New validates the options, applying their defaults, and creates the ONNX session of the model.
+// When no classes are given, they are read from the model metadata.
+func New(options Options) (*Detector, error) {
+	options = options.WithDefaults()
+	if len(options.Classes) == 0 && options.ModelPath != "" && options.LibraryPath != "" {
+		classes, err := readClasses(options)
+		if err != nil {
+			return nil, err
+		}
+		options.Classes = classes
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	layout, err := options.Decoder.layout()
+	if err != nil {
+		return nil, err
+	}
+	if options.ModelSHA256 == "" {
+		options.ModelSHA256, err = fileSHA256(options.ModelPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	onnxRuntime := onnx.NewOnnxRuntime(
+		options.ModelPath,
+		options.LibraryPath,
+		onnx.TensorInputShape{
+			BatchSize: int64(options.BatchSize),
+			Channels:  int64(options.InputChannels),
+			Height:    int64(options.InputHeight),
+			Width:     int64(options.InputWidth),
+		},
+		options.tensorOutputShape(),
+	).WithTensorNames(options.InputName, options.OutputName)
+
+	session, err := onnx.NewONNXSession(onnxRuntime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ONNX session for model at %s: %w", options.ModelPath, err)
+	}
+
+	return &Detector{
+		options: options,
+		layout:  layout,
+		session: session,
+		model: ModelInfo{
+			Path:     options.ModelPath,
+			SHA256:   options.ModelSHA256,
+			Version:  session.Metadata.Version,
+			Producer: session.Metadata.Producer,
+		},
+	}, nil
+}
+
+// readClasses reads the class labels stored in the metadata of the model described by options.
+func readClasses(options Options) ([]string, error) {
+	metadata, err := onnx.ReadModelMetadata(onnx.NewOnnxRuntime(options.ModelPath, options.LibraryPath,
+		onnx.TensorInputShape{}, onnx.TensorOutputShape{}))
+	if err != nil {
+		return nil, err
+	}
+	classes, err := metadata.ClassNames()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read classes of model %s: %w", options.ModelPath, err)
+	}
+	if len(classes) == 0 {
+		return nil, fmt.Errorf("model %s has no %q metadata, classes must be given", options.ModelPath, onnx.ClassNamesKey)
+	}
+	return classes, nil
+}
+
+// Options returns the options of the detector, with defaults applied.
+func (d *Detector) Options() Options {
+	return d.options
+}
+
+// Session returns the ONNX session running the model.
+func (d *Detector) Session() *onnx.ONNXSession {
+	return d.session
+}
+
+// Model returns the identity of the model run by the detector.
+func (d *Detector) Model() ModelInfo {
+	return d.model
+}
+
+// Metadata returns the metadata stored in the model file.
+func (d *Detector) Metadata() onnx.ModelMetadata {
+	return d.session.Metadata
+}
+
+// Close releases the ONNX session of the detector.
+func (d *Detector) Close() {
+	d.session.Close()
+}
+
+// AnalyzeImage runs the model on an image and returns the detected bounding boxes.
+func (d *Detector) AnalyzeImage(img image.Image) ([]onnx.BoundingBox, error) {
+	return d.AnalyzeImageContext(context.Background(), img)
+}
+
+// AnalyzeImageContext is like AnalyzeImage, but stops as soon as ctx ends.
+// Cancellation is checked while preprocessing, waiting for the session and decoding; a session
+// run already started completes in the background and its result is discarded.
+func (d *Detector) AnalyzeImageContext(ctx context.Context, img image.Image) ([]onnx.BoundingBox, error) {
+	result, err := d.AnalyzeContext(ctx, img)
+	if err != nil {
+		return nil, err
+	}
+	return result.Detections, nil
+}
+
+// AnalyzeImages runs the model on several images and returns the bounding boxes of each image, in order.
+// Images are packed into batches of BatchSize and the last partial batch is padded, so the session
+// runs once per batch instead of once per image.
+func (d *Detector) AnalyzeImages(images []image.Image) ([][]onnx.BoundingBox, error) {
+	return d.AnalyzeImagesContext(context.Background(), images)
+}
+
+// AnalyzeImagesContext is like AnalyzeImages, but stops as soon as ctx ends.
+func (d *Detector) AnalyzeImagesContext(ctx context.Context, images []image.Image) ([][]onnx.BoundingBox, error) {
+	results, err := d.AnalyzeBatchContext(ctx, images)
+	if err != nil {
+		return nil, err
+	}
+	boxes := make([][]onnx.BoundingBox, len(results))
+	for i, result := range results {
+		boxes[i] = result.Detections
+	}
+	return boxes, nil
+}
+
+// Analyze runs the model on an image and returns the detections with their provenance.
+func (d *Detector) Analyze(img image.Image) (*Result, error) {
+	return d.AnalyzeContext(context.Background(), img)
+}
+
+// AnalyzeContext is like Analyze, but stops as soon as ctx ends.
+func (d *Detector) AnalyzeContext(ctx context.Context, img image.Image) (*Result, error) {
+	start := time.Now()
+	result := d.newResult(img)
+	processor := d.newProcessor()
+	processor.Image = img
+
+	// Preprocess into a private buffer so the session is only held while it runs.
+	input := make([]float32, d.inputSize())
+	err := processor.InputToDataContext(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process input: %w", err)
+	}
+	result.Timings.Preprocess = time.Since(start)
+
+	inferenceStart := time.Now()
+	output, err := d.session.RunContext(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run session: %w", err)
+	}
+	result.Timings.Inference = time.Since(inferenceStart)
+	if d.options.KeepRawOutput {
+		result.Raw = d.newRawOutput(img, output, 0)
+	}
+
+	postprocessStart := time.Now()
+	result.Detections, err = processor.OutputForBoundsContext(ctx, output, img.Bounds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to process output: %w", err)
+	}
+	result.Timings.Postprocess = time.Since(postprocessStart)
+	result.Timings.Total = time.Since(start)
+
+	return result, nil
+}
+
+// AnalyzeBatch runs the model on several images in batches, like AnalyzeImages, and returns
+// the result of each image, in order.
+func (d *Detector) AnalyzeBatch(images []image.Image) ([]*Result, error) {
+	return d.AnalyzeBatchContext(context.Background(), images)
+}
+
+// AnalyzeBatchContext is like AnalyzeBatch, but stops as soon as ctx ends.
+func (d *Detector) AnalyzeBatchContext(ctx context.Context, images []image.Image) ([]*Result, error) {
+	results := make([]*Result, 0, len(images))
+	input := make([]float32, d.inputSize())
+	for start := 0; start < len(images); start += d.options.BatchSize {
+		batchStart := time.Now()
+		end := min(start+d.options.BatchSize, len(images))
+		processor := d.newProcessor()
+		processor.Images = images[start:end]
+
+		err := processor.InputBatchToDataContext(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process input batch at image %d: %w", start, err)
+		}
+		var timings Timings
+		timings.Preprocess = time.Since(batchStart)
+
+		inferenceStart := time.Now()
+		output, err := d.session.RunContext(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run session: %w", err)
+		}
+		timings.Inference = time.Since(inferenceStart)
+
+		postprocessStart := time.Now()
+		boxes, err := processor.OutputBatchFromDataContext(ctx, output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process output batch at image %d: %w", start, err)
+		}
+		timings.Postprocess = time.Since(postprocessStart)
+		timings.Total = time.Since(batchStart)
+
+		for i, img := range processor.Images {
+			result := d.newResult(img)
+			result.Detections = boxes[i]
+			result.Timings = timings
+			if d.options.KeepRawOutput {
+				result.Raw = d.newRawOutput(img, output, i)
+			}
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
+}
+
+// newResult returns a result for img without detections nor timings.
+func (d *Detector) newResult(img image.Image) *Result {
+	return &Result{
+		Model:     d.model,
+		ImageSize: sizeOf(img),
+		InputSize: Size{Width: d.options.InputWidth, Height: d.options.InputHeight},
+		Thresholds: Thresholds{
+			Confidence:  d.options.ConfidenceThreshold,
+			IoU:         d.options.IoUThreshold,
+			Classes:     d.options.ClassThresholds,
+			ClassFilter: d.options.ClassFilter,
+		},
+	}
+}
+
+// newProcessor returns a processor configured from the detector options, without images.
+func (d *Detector) newProcessor() *onnx.Processor {
+	return d.options.processor(d.layout)
+}
+
+// inputSize returns the number of floats in the input tensor of a batch.
+func (d *Detector) inputSize() int {
+	return d.options.BatchSize * d.options.InputChannels * d.options.InputHeight * d.options.InputWidth
+}
@@ -0,0 +1,352 @@
+package detector
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+// Manifest describes a deployed detection model end to end, so models can be swapped
+// by editing a JSON file instead of recompiling:
+//
+//	{
+//		"model": {"path": "yolo11s.onnx", "sha256": "…"},
+//		"input": {"name": "images", "shape": [1, 3, 640, 640]},
+//		"output": {"name": "output0", "shape": [1, 84, 8400]},
+//		"preprocessing": {"resize": "letterbox"},
+//		"decoder": "yolov8",
+//		"labels_file": "coco.names",
+//		"thresholds": {"confidence": 0.5, "iou": 0.7}
+//	}
+//
+// Relative paths are resolved against the directory of the manifest file.
+type Manifest struct {
+	Model         ModelSpec         `json:"model"`
+	Input         TensorSpec        `json:"input"`
+	Output        TensorSpec        `json:"output"`
+	Preprocessing PreprocessingSpec `json:"preprocessing"`
+	// Decoder is the output layout of the model, "yolov8" or "yolov5". It defaults to "yolov8".
+	Decoder Decoder `json:"decoder,omitempty"`
+	// Labels lists the class labels in the order of the model output.
+	// At most one of Labels and LabelsFile may be set. Without both, the labels are read from
+	// the model metadata when creating the detector.
+	Labels []string `json:"labels,omitempty"`
+	// LabelsFile is the path to a label file in one of the formats read by onnx.ReadLabels.
+	LabelsFile string         `json:"labels_file,omitempty"`
+	Thresholds ThresholdsSpec `json:"thresholds"`
+	// ThresholdsFile is the path to a thresholds file, see LoadThresholds. It replaces
+	// Thresholds when set, so tuned thresholds can be deployed without editing the manifest.
+	ThresholdsFile string `json:"thresholds_file,omitempty"`
+	// ClassFilter selects the classes detected, by label or class ID.
+	ClassFilter onnx.ClassFilter `json:"class_filter"`
+	// Remap renames, merges or drops classes in the detections, see onnx.ClassRemap.
+	Remap onnx.ClassRemap `json:"remap,omitempty"`
+
+	// labels holds the labels read from LabelsFile.
+	labels []string
+}
+
+// ModelSpec locates the model file.
+type ModelSpec struct {
+	// Path is the path to the .onnx model file.
+	Path string `json:"path"`
+	// SHA256 is the hex encoded checksum of the model file. It is checked before creating a
+	// detector when set.
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// TensorSpec describes an input or output tensor of the model.
+type TensorSpec struct {
+	Name  string  `json:"name,omitempty"`
+	Shape []int64 `json:"shape"`
+}
+
+// PreprocessingSpec describes how images are turned into the model input.
+type PreprocessingSpec struct {
+	// Resize is "stretch" or "letterbox". It defaults to "stretch".
+	Resize string `json:"resize,omitempty"`
+}
+
+// ThresholdsSpec holds the decoding thresholds of the model.
+type ThresholdsSpec struct {
+	// Confidence defaults to 0.25 when zero.
+	Confidence float32 `json:"confidence"`
+	// IoU defaults to 0.7 when zero.
+	IoU float32 `json:"iou,omitempty"`
+	// Classes overrides Confidence for the labels it lists.
+	Classes map[string]float32 `json:"classes,omitempty"`
+}
+
+// FieldError reports an invalid manifest field.
+type FieldError struct {
+	// Field is the JSON path of the field, e.g. "input.shape".
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// fieldErrorf returns a FieldError for field with a formatted message.
+func fieldErrorf(field, format string, args ...any) *FieldError {
+	return &FieldError{Field: field, Err: fmt.Errorf(format, args...)}
+}
+
+// LoadManifest reads and validates the manifest at path, loading its labels file if any.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	m, err := ParseManifest(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// ParseManifest decodes and validates a JSON manifest, resolving relative paths against baseDir.
+// Validation errors are joined *FieldError values, one per invalid field.
+func ParseManifest(data []byte, baseDir string) (*Manifest, error) {
+	m := &Manifest{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(m); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return nil, fieldErrorf(typeErr.Field, "expected %v, got JSON %s", typeErr.Type, typeErr.Value)
+		}
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+
+	m.Model.Path = resolvePath(baseDir, m.Model.Path)
+	m.LabelsFile = resolvePath(baseDir, m.LabelsFile)
+	m.ThresholdsFile = resolvePath(baseDir, m.ThresholdsFile)
+	if m.LabelsFile != "" && len(m.Labels) == 0 {
+		if err := m.LoadLabels(); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.ThresholdsFile != "" {
+		thresholds, err := LoadThresholds(m.ThresholdsFile)
+		if err != nil {
+			return nil, &FieldError{Field: "thresholds_file", Err: err}
+		}
+		m.Thresholds = thresholds
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// resolvePath joins a relative path to baseDir, leaving empty and absolute paths untouched.
+func resolvePath(baseDir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
+
+// LoadLabels reads the class labels from LabelsFile.
+func (m *Manifest) LoadLabels() error {
+	labels, err := onnx.LoadLabels(m.LabelsFile)
+	if err != nil {
+		return &FieldError{Field: "labels_file", Err: err}
+	}
+	m.labels = labels.Names()
+	return nil
+}
+
+// Classes returns the class labels of the model, from Labels or LabelsFile.
+func (m *Manifest) Classes() []string {
+	if len(m.Labels) > 0 {
+		return m.Labels
+	}
+	return m.labels
+}
+
+// Validate checks every field of the manifest and returns one *FieldError per invalid field.
+func (m *Manifest) Validate() error {
+	var errs []error
+	if m.Model.Path == "" {
+		errs = append(errs, fieldErrorf("model.path", "is required"))
+	}
+	if m.Model.SHA256 != "" {
+		if sum, err := hex.DecodeString(m.Model.SHA256); err != nil || len(sum) != sha256.Size {
+			errs = append(errs, fieldErrorf("model.sha256", "%q is not a hex encoded SHA-256 checksum", m.Model.SHA256))
+		}
+	}
+
+	if len(m.Input.Shape) != 4 {
+		errs = append(errs, fieldErrorf("input.shape", "expected [batch, channels, height, width], got %v", m.Input.Shape))
+	} else if !positive(m.Input.Shape) {
+		errs = append(errs, fieldErrorf("input.shape", "dimensions must be positive, got %v", m.Input.Shape))
+	} else if m.Input.Shape[1] != 3 {
+		errs = append(errs, fieldErrorf("input.shape", "%d channels are not supported, images are fed as RGB", m.Input.Shape[1]))
+	}
+
+	decoder := m.Decoder
+	if decoder == "" {
+		decoder = DecoderYOLOv8
+	}
+	if _, err := decoder.layout(); err != nil {
+		errs = append(errs, &FieldError{Field: "decoder", Err: err})
+	}
+
+	switch {
+	case len(m.Labels) > 0 && m.LabelsFile != "":
+		errs = append(errs, fieldErrorf("labels", "labels and labels_file are mutually exclusive"))
+	case len(m.Classes()) == 0 && m.LabelsFile != "":
+		errs = append(errs, fieldErrorf("labels_file", "%s holds no labels", m.LabelsFile))
+	}
+
+	if len(m.Output.Shape) != 3 {
+		errs = append(errs, fieldErrorf("output.shape", "expected [batch, rows, columns], got %v", m.Output.Shape))
+	} else if !positive(m.Output.Shape) {
+		errs = append(errs, fieldErrorf("output.shape", "dimensions must be positive, got %v", m.Output.Shape))
+	} else {
+		if len(m.Input.Shape) == 4 && m.Output.Shape[0] != m.Input.Shape[0] {
+			errs = append(errs, fieldErrorf("output.shape", "batch size %d differs from input batch size %d",
+				m.Output.Shape[0], m.Input.Shape[0]))
+		}
+		if classes := len(m.Classes()); classes > 0 {
+			// YOLOv8 outputs 4 box coordinates then the class scores on the rows, YOLOv5 adds
+			// an objectness score and puts them on the columns.
+			got, want := m.Output.Shape[1], int64(classes+4)
+			if decoder == DecoderYOLOv5 {
+				got, want = m.Output.Shape[2], int64(classes+5)
+			}
+			if got != want {
+				errs = append(errs, fieldErrorf("output.shape", "%d values per detection do not match %d labels with decoder %s (expected %d)",
+					got, classes, decoder, want))
+			}
+		}
+	}
+
+	if _, err := onnx.ParseResizeMode(m.Preprocessing.Resize); err != nil {
+		errs = append(errs, &FieldError{Field: "preprocessing.resize", Err: err})
+	}
+	if m.Thresholds.Confidence < 0 || m.Thresholds.Confidence > 1 {
+		errs = append(errs, fieldErrorf("thresholds.confidence", "%v is outside [0, 1]", m.Thresholds.Confidence))
+	}
+	for label, threshold := range m.Thresholds.Classes {
+		if threshold < 0 || threshold > 1 {
+			errs = append(errs, fieldErrorf("thresholds.classes."+label, "%v is outside [0, 1]", threshold))
+		}
+	}
+	if classes := m.Classes(); len(classes) > 0 {
+		if err := errors.Join(checkClasses(classes, m.Thresholds.Classes, onnx.ClassFilter{})...); err != nil {
+			errs = append(errs, &FieldError{Field: "thresholds.classes", Err: err})
+		}
+		if err := errors.Join(checkClasses(classes, nil, m.ClassFilter)...); err != nil {
+			errs = append(errs, &FieldError{Field: "class_filter", Err: err})
+		}
+	}
+	if m.Thresholds.IoU < 0 || m.Thresholds.IoU > 1 {
+		errs = append(errs, fieldErrorf("thresholds.iou", "%v is outside [0, 1]", m.Thresholds.IoU))
+	}
+	return errors.Join(errs...)
+}
+
+// positive reports whether every dimension of shape is positive.
+func positive(shape []int64) bool {
+	for _, dim := range shape {
+		if dim <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// VerifyModel checks the model file against Model.SHA256. It does nothing when no checksum is set.
+func (m *Manifest) VerifyModel() error {
+	if m.Model.SHA256 == "" {
+		return nil
+	}
+	sum, err := fileSHA256(m.Model.Path)
+	if err != nil {
+		return &FieldError{Field: "model.path", Err: err}
+	}
+	if !strings.EqualFold(sum, m.Model.SHA256) {
+		return fieldErrorf("model.sha256", "model %s has checksum %s, expected %s", m.Model.Path, sum, m.Model.SHA256)
+	}
+	return nil
+}
+
+// fileSHA256 returns the hex encoded checksum of the file at path.
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open model: %w", err)
+	}
+	defer file.Close()
+	return readSHA256(file)
+}
+
+// readSHA256 returns the hex encoded checksum of the content of r.
+func readSHA256(r io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", fmt.Errorf("failed to hash model: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Options converts the manifest to detector options running with the onnxruntime library at libraryPath.
+// The manifest must be valid.
+func (m *Manifest) Options(libraryPath string) Options {
+	resize, _ := onnx.ParseResizeMode(m.Preprocessing.Resize)
+	options := Options{
+		ModelPath:           m.Model.Path,
+		ModelSHA256:         strings.ToLower(m.Model.SHA256),
+		LibraryPath:         libraryPath,
+		InputName:           m.Input.Name,
+		OutputName:          m.Output.Name,
+		Classes:             m.Classes(),
+		ConfidenceThreshold: m.Thresholds.Confidence,
+		ClassThresholds:     m.Thresholds.Classes,
+		ClassFilter:         m.ClassFilter,
+		IoUThreshold:        m.Thresholds.IoU,
+		Decoder:             m.Decoder,
+		Resize:              resize,
+		Remap:               m.Remap,
+	}
+	if len(m.Input.Shape) == 4 {
+		options.BatchSize = int(m.Input.Shape[0])
+		options.InputChannels = int(m.Input.Shape[1])
+		options.InputHeight = int(m.Input.Shape[2])
+		options.InputWidth = int(m.Input.Shape[3])
+	}
+	if len(m.Output.Shape) == 3 {
+		options.Detections = int(m.Output.Shape[2])
+		if m.Decoder == DecoderYOLOv5 {
+			options.Detections = int(m.Output.Shape[1])
+		}
+	}
+	return options.WithDefaults()
+}
+
+// NewFromManifest verifies the model checksum of the manifest and creates its detector.
+func NewFromManifest(m *Manifest, libraryPath string) (*Detector, error) {
+	if err := m.VerifyModel(); err != nil {
+		return nil, err
+	}
+	return New(m.Options(libraryPath))
+}
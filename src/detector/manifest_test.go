@@ -0,0 +1,186 @@
+package detector_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/detector"
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+const validManifest = `{
+	"model": {"path": "model.onnx"},
+	"input": {"name": "in", "shape": [2, 3, 320, 320]},
+	"output": {"name": "out", "shape": [2, 6, 2100]},
+	"preprocessing": {"resize": "letterbox"},
+	"decoder": "yolov8",
+	"labels": ["cat", "dog"],
+	"thresholds": {"confidence": 0.4, "iou": 0.5}
+}`
+
+func TestParseManifest_Options(t *testing.T) {
+	m, err := detector.ParseManifest([]byte(validManifest), "/models")
+	if err != nil {
+		t.Fatalf("ParseManifest returned error: %v", err)
+	}
+	o := m.Options("lib.so")
+
+	if o.ModelPath != filepath.Join("/models", "model.onnx") || o.LibraryPath != "lib.so" {
+		t.Errorf("Unexpected paths %q, %q", o.ModelPath, o.LibraryPath)
+	}
+	if o.InputName != "in" || o.OutputName != "out" {
+		t.Errorf("Unexpected tensor names %q, %q", o.InputName, o.OutputName)
+	}
+	if o.BatchSize != 2 || o.InputChannels != 3 || o.InputHeight != 320 || o.InputWidth != 320 || o.Detections != 2100 {
+		t.Errorf("Unexpected shapes %+v", o)
+	}
+	if o.Resize != onnx.ResizeLetterbox || o.ConfidenceThreshold != 0.4 || o.IoUThreshold != 0.5 {
+		t.Errorf("Unexpected preprocessing or thresholds %+v", o)
+	}
+	if err := o.Validate(); err != nil {
+		t.Errorf("Expected manifest options to be valid, got %v", err)
+	}
+
+	p, err := o.Processor()
+	if err != nil {
+		t.Fatalf("Processor returned error: %v", err)
+	}
+	if p.ModelOutputClasses != 2 || p.ModelDetections != 2100 || p.Resize != onnx.ResizeLetterbox {
+		t.Errorf("Unexpected processor %+v", p)
+	}
+}
+
+func TestParseManifest_DefaultThresholds(t *testing.T) {
+	data := `{
+		"model": {"path": "model.onnx"},
+		"input": {"shape": [1, 3, 320, 320]},
+		"output": {"shape": [1, 6, 2100]},
+		"labels": ["cat", "dog"]
+	}`
+	m, err := detector.ParseManifest([]byte(data), "/models")
+	if err != nil {
+		t.Fatalf("ParseManifest returned error: %v", err)
+	}
+	if o := m.Options("lib.so"); o.ConfidenceThreshold != 0.25 || o.IoUThreshold != 0.7 {
+		t.Errorf("Expected default thresholds 0.25 and 0.7 without a thresholds field, got %v and %v",
+			o.ConfidenceThreshold, o.IoUThreshold)
+	}
+}
+
+func TestParseManifest_FieldErrors(t *testing.T) {
+	data := `{
+		"model": {"sha256": "abc"},
+		"input": {"shape": [1, 3, 640]},
+		"output": {"shape": [1, 84, 8400]},
+		"preprocessing": {"resize": "crop"},
+		"decoder": "yolov5",
+		"thresholds": {"confidence": 2}
+	}`
+	_, err := detector.ParseManifest([]byte(data), ".")
+	if err == nil {
+		t.Fatal("Expected an invalid manifest to fail")
+	}
+
+	fields := map[string]bool{}
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var fieldErr *detector.FieldError
+		if !errors.As(e, &fieldErr) {
+			t.Errorf("Expected a FieldError, got %v", e)
+			continue
+		}
+		fields[fieldErr.Field] = true
+	}
+	for _, want := range []string{"model.path", "model.sha256", "input.shape", "preprocessing.resize", "thresholds.confidence"} {
+		if !fields[want] {
+			t.Errorf("Expected an error for field %q, got %v", want, err)
+		}
+	}
+}
+
+func TestParseManifest_OutputShapeMismatch(t *testing.T) {
+	data := strings.Replace(validManifest, `[2, 6, 2100]`, `[2, 84, 2100]`, 1)
+	_, err := detector.ParseManifest([]byte(data), ".")
+	var fieldErr *detector.FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "output.shape" {
+		t.Errorf("Expected an output.shape error, got %v", err)
+	}
+}
+
+func TestParseManifest_UnsupportedChannels(t *testing.T) {
+	data := strings.Replace(validManifest, `[2, 3, 320, 320]`, `[2, 1, 320, 320]`, 1)
+	_, err := detector.ParseManifest([]byte(data), ".")
+	var fieldErr *detector.FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "input.shape" {
+		t.Errorf("Expected an input.shape error for a grayscale input, got %v", err)
+	}
+}
+
+func TestParseManifest_TypeError(t *testing.T) {
+	data := strings.Replace(validManifest, `"confidence": 0.4`, `"confidence": "high"`, 1)
+	_, err := detector.ParseManifest([]byte(data), ".")
+	var fieldErr *detector.FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "thresholds.confidence" {
+		t.Errorf("Expected a thresholds.confidence error, got %v", err)
+	}
+}
+
+func TestLoadManifest_LabelsFileAndChecksum(t *testing.T) {
+	dir := t.TempDir()
+	model := []byte("not really a model")
+	sum := sha256.Sum256(model)
+	writeFile(t, filepath.Join(dir, "model.onnx"), string(model))
+	writeFile(t, filepath.Join(dir, "labels.names"), "cat\n\ndog\n")
+	data := strings.Replace(validManifest, `"labels": ["cat", "dog"]`, `"labels_file": "labels.names"`, 1)
+	data = strings.Replace(data, `"path": "model.onnx"`, `"path": "model.onnx", "sha256": "`+hex.EncodeToString(sum[:])+`"`, 1)
+	path := filepath.Join(dir, "manifest.json")
+	writeFile(t, path, data)
+
+	m, err := detector.LoadManifest(path)
+	if err != nil {
+		t.Fatalf("LoadManifest returned error: %v", err)
+	}
+	if classes := m.Classes(); len(classes) != 2 || classes[0] != "cat" || classes[1] != "dog" {
+		t.Errorf("Expected labels from the labels file, got %v", classes)
+	}
+	if err := m.VerifyModel(); err != nil {
+		t.Errorf("VerifyModel returned error: %v", err)
+	}
+
+	writeFile(t, filepath.Join(dir, "model.onnx"), "tampered")
+	var fieldErr *detector.FieldError
+	if err := m.VerifyModel(); !errors.As(err, &fieldErr) || fieldErr.Field != "model.sha256" {
+		t.Errorf("Expected a checksum mismatch, got %v", err)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseManifest_ClassSelection(t *testing.T) {
+	data := strings.Replace(validManifest, `"iou": 0.5`, `"iou": 0.5, "classes": {"cat": 0.3, "horse": 0.5}`, 1)
+	data = strings.Replace(data, `"decoder": "yolov8",`, `"decoder": "yolov8", "class_filter": {"exclude_ids": [1]},`, 1)
+	_, err := detector.ParseManifest([]byte(data), ".")
+	var fieldErr *detector.FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "thresholds.classes" || !strings.Contains(err.Error(), "horse") {
+		t.Fatalf("Expected a thresholds.classes error for horse, got %v", err)
+	}
+
+	data = strings.Replace(data, `, "horse": 0.5`, ``, 1)
+	m, err := detector.ParseManifest([]byte(data), ".")
+	if err != nil {
+		t.Fatalf("ParseManifest returned error: %v", err)
+	}
+	o := m.Options("lib.so")
+	if o.ClassThresholds["cat"] != 0.3 || len(o.ClassFilter.ExcludeIDs) != 1 {
+		t.Errorf("Expected class selection in options, got %+v", o)
+	}
+}
@@ -0,0 +1,251 @@
+package detector
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+// Decoder names the output layout of a detection model.
+type Decoder string
+
+const (
+	// DecoderYOLOv8 decodes the (4 + classes, detections) output of YOLOv8 and YOLO11 models.
+	DecoderYOLOv8 Decoder = "yolov8"
+	// DecoderYOLOv5 decodes the (detections, 5 + classes) output of YOLOv5 and YOLOv7 models.
+	DecoderYOLOv5 Decoder = "yolov5"
+)
+
+// layout returns the onnx.OutputLayout decoded by d.
+func (d Decoder) layout() (onnx.OutputLayout, error) {
+	switch d {
+	case DecoderYOLOv8:
+		return onnx.LayoutYOLOv8, nil
+	case DecoderYOLOv5:
+		return onnx.LayoutYOLOv5, nil
+	default:
+		return 0, fmt.Errorf("unknown decoder %q, expected %q or %q", d, DecoderYOLOv8, DecoderYOLOv5)
+	}
+}
+
+// anchors returns the number of detections output by models of decoder d for a width x height
+// input: one per cell at strides 8, 16 and 32, and three per cell for YOLOv5.
+func (d Decoder) anchors(width, height int) int {
+	var cells int
+	for _, stride := range []int{8, 16, 32} {
+		cells += (width / stride) * (height / stride)
+	}
+	if d == DecoderYOLOv5 {
+		return 3 * cells
+	}
+	return cells
+}
+
+// Options describes a detection model and how to run it.
+// Zero fields are replaced by the defaults documented on each field.
+type Options struct {
+	// ModelPath is the path to the .onnx model file.
+	ModelPath string
+	// ModelSHA256 is the hex encoded checksum of the model file, reported in results.
+	// New computes it when empty.
+	ModelSHA256 string
+	// LibraryPath is the path to the onnxruntime shared library.
+	LibraryPath string
+	// InputName and OutputName are the names of the model tensors.
+	// They default to onnx.DefaultInputName and onnx.DefaultOutputName.
+	InputName  string
+	OutputName string
+	// InputWidth and InputHeight are the dimensions to which input images are resized.
+	// They default to 640.
+	InputWidth  int
+	InputHeight int
+	// InputChannels is the number of channels in the input image. It defaults to 3 (RGB), the
+	// only count supported.
+	InputChannels int
+	// BatchSize is the number of images processed by a single run of the model. It defaults to 1.
+	BatchSize int
+	// Detections is the number of candidate detections output by the model. It defaults to the
+	// anchor count of YOLOv8 and YOLO11 models at strides 8, 16 and 32, e.g. 8400 for 640x640,
+	// and to 3 times that count with DecoderYOLOv5, which has 3 anchors per cell, e.g. 25200.
+	Detections int
+	// Classes is the list of class labels, in the order of the model output.
+	// When empty, New reads them from the names metadata of Ultralytics exports.
+	Classes []string
+	// ConfidenceThreshold is the minimum confidence of a detection. Zero stands for the default
+	// of 0.25, so keeping every detection takes a small positive threshold instead.
+	ConfidenceThreshold float32
+	// ClassThresholds overrides ConfidenceThreshold for the labels it lists.
+	ClassThresholds map[string]float32
+	// ClassFilter selects the classes detected, by label or class ID.
+	ClassFilter onnx.ClassFilter
+	// IoUThreshold is the IoU above which non-maximum suppression merges detections. Zero stands
+	// for the default of 0.7.
+	IoUThreshold float32
+	// Decoder is the output layout of the model. It defaults to DecoderYOLOv8.
+	Decoder Decoder
+	// Resize is how images are fitted to the model input. It defaults to onnx.ResizeStretch.
+	Resize onnx.ResizeMode
+	// Remap renames, merges or drops classes in the detections.
+	Remap onnx.ClassRemap
+	// KeepScores attaches the confidence of every class to each detection.
+	KeepScores bool
+	// KeepRawOutput attaches the raw output of the model to each Result, so it can be decoded
+	// again with other settings, see Options.DecodeContext.
+	KeepRawOutput bool
+}
+
+// WithDefaults returns a copy of the options with zero fields replaced by their default.
+func (o Options) WithDefaults() Options {
+	if o.InputName == "" {
+		o.InputName = onnx.DefaultInputName
+	}
+	if o.OutputName == "" {
+		o.OutputName = onnx.DefaultOutputName
+	}
+	if o.InputWidth == 0 {
+		o.InputWidth = 640
+	}
+	if o.InputHeight == 0 {
+		o.InputHeight = 640
+	}
+	if o.InputChannels == 0 {
+		o.InputChannels = 3
+	}
+	if o.BatchSize == 0 {
+		o.BatchSize = 1
+	}
+	if o.Decoder == "" {
+		o.Decoder = DecoderYOLOv8
+	}
+	if o.Detections == 0 {
+		o.Detections = o.Decoder.anchors(o.InputWidth, o.InputHeight)
+	}
+	if o.ConfidenceThreshold == 0 {
+		o.ConfidenceThreshold = 0.25
+	}
+	if o.IoUThreshold == 0 {
+		o.IoUThreshold = 0.7
+	}
+	return o
+}
+
+// Validate checks that the options describe a runnable model.
+// It should be called on options with defaults applied.
+func (o Options) Validate() error {
+	var errs []error
+	if o.ModelPath == "" {
+		errs = append(errs, errors.New("model path is required"))
+	}
+	if o.LibraryPath == "" {
+		errs = append(errs, errors.New("library path is required"))
+	}
+	if o.InputWidth <= 0 || o.InputHeight <= 0 || o.InputChannels <= 0 {
+		errs = append(errs, fmt.Errorf("invalid input size %dx%dx%d", o.InputWidth, o.InputHeight, o.InputChannels))
+	} else if o.InputChannels != 3 {
+		errs = append(errs, fmt.Errorf("unsupported input channel count %d, images are fed as RGB", o.InputChannels))
+	}
+	if o.BatchSize <= 0 {
+		errs = append(errs, fmt.Errorf("invalid batch size %d, must be at least 1", o.BatchSize))
+	}
+	if o.Detections <= 0 {
+		errs = append(errs, fmt.Errorf("invalid detection count %d", o.Detections))
+	}
+	if len(o.Classes) == 0 {
+		errs = append(errs, errors.New("at least one class is required"))
+	}
+	if o.ConfidenceThreshold < 0 || o.ConfidenceThreshold > 1 {
+		errs = append(errs, fmt.Errorf("confidence threshold %v is outside [0, 1]", o.ConfidenceThreshold))
+	}
+	for label, threshold := range o.ClassThresholds {
+		if threshold < 0 || threshold > 1 {
+			errs = append(errs, fmt.Errorf("confidence threshold %v of class %q is outside [0, 1]", threshold, label))
+		}
+	}
+	if len(o.Classes) > 0 {
+		errs = append(errs, checkClasses(o.Classes, o.ClassThresholds, o.ClassFilter)...)
+	}
+	if o.IoUThreshold < 0 || o.IoUThreshold > 1 {
+		errs = append(errs, fmt.Errorf("IoU threshold %v is outside [0, 1]", o.IoUThreshold))
+	}
+	if _, err := o.Decoder.layout(); err != nil {
+		errs = append(errs, err)
+	}
+	if o.Resize != onnx.ResizeStretch && o.Resize != onnx.ResizeLetterbox {
+		errs = append(errs, fmt.Errorf("unknown resize mode %v", o.Resize))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid detector options: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// checkClasses returns an error for each class named by thresholds or filter which is not one of classes.
+func checkClasses(classes []string, thresholds map[string]float32, filter onnx.ClassFilter) []error {
+	labels := onnx.NewLabelSet(classes)
+	var errs []error
+	for label := range thresholds {
+		if _, ok := labels.ID(label); !ok {
+			errs = append(errs, fmt.Errorf("class threshold for unknown class %q", label))
+		}
+	}
+	for _, label := range filter.Labels() {
+		if _, ok := labels.ID(label); !ok {
+			errs = append(errs, fmt.Errorf("class filter names unknown class %q", label))
+		}
+	}
+	for _, id := range filter.IDs() {
+		if _, err := labels.Name(id); err != nil {
+			errs = append(errs, fmt.Errorf("class filter: %w", err))
+		}
+	}
+	return errs
+}
+
+// Processor returns a processor decoding the outputs of the model described by the options,
+// without images. It lets callers running the model themselves reuse the detector settings.
+func (o Options) Processor() (*onnx.Processor, error) {
+	o = o.WithDefaults()
+	layout, err := o.Decoder.layout()
+	if err != nil {
+		return nil, err
+	}
+	return o.processor(layout), nil
+}
+
+// processor returns a processor configured from the options, decoding layout.
+func (o Options) processor(layout onnx.OutputLayout) *onnx.Processor {
+	return &onnx.Processor{
+		ModelClasses:        onnx.NewLabelSet(o.Classes),
+		ModelHeight:         uint(o.InputHeight),
+		ModelWidth:          uint(o.InputWidth),
+		ModelInputChannels:  uint(o.InputChannels),
+		ModelOutputClasses:  uint(len(o.Classes)),
+		ModelDetections:     uint(o.Detections),
+		ThresholdConfidence: o.ConfidenceThreshold,
+		ClassThresholds:     o.ClassThresholds,
+		ClassFilter:         o.ClassFilter,
+		ThresholdIoU:        o.IoUThreshold,
+		Layout:              layout,
+		Resize:              o.Resize,
+		Remap:               o.Remap,
+		KeepScores:          o.KeepScores,
+	}
+}
+
+// tensorOutputShape returns the output shape of the model described by the options.
+func (o Options) tensorOutputShape() onnx.TensorOutputShape {
+	if o.Decoder == DecoderYOLOv5 {
+		// YOLOv5 outputs one row per detection, so the axes are swapped.
+		return onnx.TensorOutputShape{
+			BatchSize:  int64(o.BatchSize),
+			Classes:    int64(o.Detections),
+			Detections: int64(len(o.Classes) + 5),
+		}
+	}
+	return onnx.TensorOutputShape{
+		BatchSize:  int64(o.BatchSize),
+		Classes:    int64(len(o.Classes) + 4), // 4 for bounding box coordinates
+		Detections: int64(o.Detections),
+	}
+}
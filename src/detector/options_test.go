@@ -0,0 +1,83 @@
+package detector_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/detector"
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+func TestOptions_WithDefaults(t *testing.T) {
+	o := detector.Options{ModelPath: "model.onnx", LibraryPath: "lib.so", Classes: []string{"a"}}.WithDefaults()
+
+	if o.InputWidth != 640 || o.InputHeight != 640 || o.InputChannels != 3 || o.BatchSize != 1 {
+		t.Errorf("Unexpected default input %dx%dx%d batch %d", o.InputWidth, o.InputHeight, o.InputChannels, o.BatchSize)
+	}
+	if o.Detections != 8400 {
+		t.Errorf("Expected 8400 default detections for 640x640, got %d", o.Detections)
+	}
+	if o.InputName != onnx.DefaultInputName || o.OutputName != onnx.DefaultOutputName {
+		t.Errorf("Unexpected default tensor names %q, %q", o.InputName, o.OutputName)
+	}
+	if o.ConfidenceThreshold != 0.25 || o.IoUThreshold != 0.7 || o.Decoder != detector.DecoderYOLOv8 || o.Resize != onnx.ResizeStretch {
+		t.Errorf("Unexpected default decoding %v, %v, %v, %v", o.ConfidenceThreshold, o.IoUThreshold, o.Decoder, o.Resize)
+	}
+	if err := o.Validate(); err != nil {
+		t.Errorf("Expected defaulted options to be valid, got %v", err)
+	}
+
+	small := detector.Options{InputWidth: 320, InputHeight: 320}.WithDefaults()
+	if small.Detections != 2100 {
+		t.Errorf("Expected 2100 default detections for 320x320, got %d", small.Detections)
+	}
+	v5 := detector.Options{Decoder: detector.DecoderYOLOv5}.WithDefaults()
+	if v5.Detections != 25200 {
+		t.Errorf("Expected 25200 default YOLOv5 detections for 640x640, got %d", v5.Detections)
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	o := detector.Options{
+		BatchSize:           -1,
+		InputChannels:       1,
+		ConfidenceThreshold: 1.5,
+		Decoder:             "yolov9000",
+	}.WithDefaults()
+
+	err := o.Validate()
+	if err == nil {
+		t.Fatal("Expected invalid options to fail validation")
+	}
+	for _, want := range []string{"model path", "library path", "channel", "batch size", "class", "confidence threshold", "yolov9000"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected validation error to mention %q, got %v", want, err)
+		}
+	}
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	if _, err := detector.New(detector.Options{}); err == nil {
+		t.Error("Expected New to reject options without a model")
+	}
+}
+
+func TestOptions_ValidateClassSelection(t *testing.T) {
+	o := detector.Options{
+		ModelPath:       "model.onnx",
+		LibraryPath:     "lib.so",
+		Classes:         []string{"person", "car"},
+		ClassThresholds: map[string]float32{"person": 0.35, "boat": 0.5, "car": 1.5},
+		ClassFilter:     onnx.ClassFilter{Include: []string{"bus"}, ExcludeIDs: []int{7}},
+	}.WithDefaults()
+
+	err := o.Validate()
+	if err == nil {
+		t.Fatal("Expected unknown classes to fail validation")
+	}
+	for _, want := range []string{`"boat"`, `"car" is outside`, `"bus"`, "class ID 7"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected validation error to mention %s, got %v", want, err)
+		}
+	}
+}
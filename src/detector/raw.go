@@ -0,0 +1,170 @@
+package detector
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+// RawOutput is the output tensor of the model for one image, with how the image was turned
+// into the model input. It can be decoded again with other thresholds, filters or NMS settings
+// without running the model, e.g. to sweep thresholds over a dataset.
+type RawOutput struct {
+	// ImageSize is the size of the analyzed image, and ImageOrigin the top left corner of its
+	// bounds, so boxes decode to the same coordinates as live detections on sub-images.
+	ImageSize   Size
+	ImageOrigin image.Point
+	// InputSize is the model input the image was resized to, with Resize.
+	// Decoding checks it and Resize against the options when it is set, as by Infer.
+	InputSize Size
+	Resize    onnx.ResizeMode
+	// Decoder is the output layout of Data. Decoding checks it against the options when set.
+	Decoder Decoder
+	// Shape is the shape of Data, the output of a single image without the batch axis.
+	// Decoding checks it against the options when set.
+	Shape []int
+	Data  []float32
+}
+
+// Infer runs the model on an image and returns its raw output, without decoding it.
+func (d *Detector) Infer(img image.Image) (*RawOutput, error) {
+	return d.InferContext(context.Background(), img)
+}
+
+// InferContext is like Infer, but stops as soon as ctx ends.
+func (d *Detector) InferContext(ctx context.Context, img image.Image) (*RawOutput, error) {
+	processor := d.newProcessor()
+	processor.Image = img
+	input := make([]float32, d.inputSize())
+	if err := processor.InputToDataContext(ctx, input); err != nil {
+		return nil, fmt.Errorf("failed to process input: %w", err)
+	}
+	output, err := d.session.RunContext(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run session: %w", err)
+	}
+	return d.newRawOutput(img, output, 0), nil
+}
+
+// newRawOutput returns the raw output of img, the image at index i of the batch whose output is
+// output. The output of the image is copied out of the batch.
+func (d *Detector) newRawOutput(img image.Image, output []float32, i int) *RawOutput {
+	shape := d.options.tensorOutputShape()
+	rows, columns := int(shape.Classes), int(shape.Detections)
+	return &RawOutput{
+		ImageSize:   sizeOf(img),
+		ImageOrigin: img.Bounds().Min,
+		InputSize:   Size{Width: d.options.InputWidth, Height: d.options.InputHeight},
+		Resize:      d.options.Resize,
+		Decoder:     d.options.Decoder,
+		Shape:       []int{rows, columns},
+		Data:        slices.Clone(output[i*rows*columns : (i+1)*rows*columns]),
+	}
+}
+
+// Decode decodes a raw output with the settings of the detector.
+func (d *Detector) Decode(raw *RawOutput) ([]onnx.BoundingBox, error) {
+	return d.options.DecodeContext(context.Background(), raw)
+}
+
+// DecodeContext decodes a raw output of the model described by the options with their
+// decoding settings: thresholds, class filter, NMS IoU and remapping.
+func (o Options) DecodeContext(ctx context.Context, raw *RawOutput) ([]onnx.BoundingBox, error) {
+	o = o.WithDefaults()
+	if raw.InputSize != (Size{}) {
+		if raw.InputSize.Width != o.InputWidth || raw.InputSize.Height != o.InputHeight || raw.Resize != o.Resize {
+			return nil, fmt.Errorf("raw output of a %dx%d %v input, options expect %dx%d %v",
+				raw.InputSize.Width, raw.InputSize.Height, raw.Resize, o.InputWidth, o.InputHeight, o.Resize)
+		}
+	}
+	if raw.Decoder != "" && raw.Decoder != o.Decoder {
+		return nil, fmt.Errorf("raw output of decoder %q, options expect %q", raw.Decoder, o.Decoder)
+	}
+	if len(raw.Shape) > 0 {
+		shape := o.tensorOutputShape()
+		if want := []int{int(shape.Classes), int(shape.Detections)}; !slices.Equal(raw.Shape, want) {
+			return nil, fmt.Errorf("raw output of shape %v, options expect %v", raw.Shape, want)
+		}
+	}
+
+	processor, err := o.Processor()
+	if err != nil {
+		return nil, err
+	}
+	bounds := image.Rectangle{
+		Min: raw.ImageOrigin,
+		Max: raw.ImageOrigin.Add(image.Pt(raw.ImageSize.Width, raw.ImageSize.Height)),
+	}
+	boxes, err := processor.OutputForBoundsContext(ctx, raw.Data, bounds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process output: %w", err)
+	}
+	return boxes, nil
+}
+
+// WriteRawOutputs writes raw outputs to w, to be read back by ReadRawOutputs.
+func WriteRawOutputs(w io.Writer, outputs []*RawOutput) error {
+	return gob.NewEncoder(w).Encode(outputs)
+}
+
+// ReadRawOutputs reads raw outputs written by WriteRawOutputs.
+func ReadRawOutputs(r io.Reader) ([]*RawOutput, error) {
+	var outputs []*RawOutput
+	if err := gob.NewDecoder(r).Decode(&outputs); err != nil {
+		return nil, fmt.Errorf("failed to decode raw outputs: %w", err)
+	}
+	for i, raw := range outputs {
+		if err := raw.Validate(); err != nil {
+			return nil, fmt.Errorf("corrupted raw output %d: %w", i, err)
+		}
+	}
+	return outputs, nil
+}
+
+// Validate checks that Data holds as many values as Shape describes.
+func (raw *RawOutput) Validate() error {
+	if raw == nil {
+		return errors.New("missing raw output")
+	}
+	size := 1
+	for _, dim := range raw.Shape {
+		if dim < 0 {
+			return fmt.Errorf("negative dimension in shape %v", raw.Shape)
+		}
+		size *= dim
+	}
+	if len(raw.Shape) == 0 || size != len(raw.Data) {
+		return fmt.Errorf("%d values for shape %v", len(raw.Data), raw.Shape)
+	}
+	return nil
+}
+
+// SaveRawOutputs writes raw outputs to the file at path, replacing it if it exists.
+func SaveRawOutputs(path string, outputs []*RawOutput) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create raw outputs file: %w", err)
+	}
+	if err := WriteRawOutputs(file, outputs); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to save raw outputs: %w", err)
+	}
+	return file.Close()
+}
+
+// LoadRawOutputs reads raw outputs from the file at path.
+func LoadRawOutputs(path string) ([]*RawOutput, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open raw outputs file: %w", err)
+	}
+	defer file.Close()
+	return ReadRawOutputs(file)
+}
@@ -0,0 +1,130 @@
+package detector_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/detector"
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+func TestOptions_DecodeContext(t *testing.T) {
+	options := detector.Options{
+		InputWidth:          100,
+		InputHeight:         100,
+		Detections:          2,
+		Classes:             []string{"cat", "dog"},
+		ConfidenceThreshold: 0.5,
+	}
+	// Two detections in the YOLOv8 layout: a dog at 0.9 and a cat at 0.6.
+	raw := &detector.RawOutput{
+		ImageSize: detector.Size{Width: 200, Height: 100},
+		Data: []float32{
+			50, 20, // xc
+			50, 20, // yc
+			20, 10, // w
+			20, 10, // h
+			0.1, 0.6, // cat
+			0.9, 0.2, // dog
+		},
+	}
+
+	boxes, err := options.DecodeContext(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("DecodeContext returned error: %v", err)
+	}
+	if len(boxes) != 2 || boxes[0].Label != "dog" || boxes[0].X1 != 80 || boxes[1].Label != "cat" {
+		t.Errorf("Unexpected boxes %+v", boxes)
+	}
+
+	// The same output decoded again with a higher threshold.
+	boxes, err = options.WithThresholds(detector.ThresholdsSpec{Confidence: 0.7}).DecodeContext(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("DecodeContext returned error: %v", err)
+	}
+	if len(boxes) != 1 || boxes[0].Label != "dog" {
+		t.Errorf("Expected only the dog, got %+v", boxes)
+	}
+
+	// The output of a sub-image decodes to its coordinates, offset by the bounds origin.
+	raw.ImageOrigin = image.Pt(100, 50)
+	boxes, err = options.DecodeContext(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("DecodeContext returned error: %v", err)
+	}
+	if len(boxes) != 2 || boxes[0].X1 != 180 || boxes[0].Y1 != 90 {
+		t.Errorf("Expected the dog at (180, 90), got %+v", boxes)
+	}
+}
+
+func TestOptions_DecodeContext_Mismatch(t *testing.T) {
+	options := detector.Options{InputWidth: 100, InputHeight: 100, Detections: 1, Classes: []string{"cat"}}
+	raw := &detector.RawOutput{
+		ImageSize: detector.Size{Width: 100, Height: 100},
+		InputSize: detector.Size{Width: 100, Height: 100},
+		Resize:    onnx.ResizeLetterbox,
+		Data:      []float32{50, 50, 20, 20, 0.9},
+	}
+	if _, err := options.DecodeContext(context.Background(), raw); err == nil {
+		t.Error("Expected an error for a letterboxed output decoded as stretched")
+	}
+
+	raw.Resize = onnx.ResizeStretch
+	raw.Decoder = detector.DecoderYOLOv5
+	if _, err := options.DecodeContext(context.Background(), raw); err == nil {
+		t.Error("Expected an error for a YOLOv5 output decoded as YOLOv8")
+	}
+
+	raw.Decoder = detector.DecoderYOLOv8
+	raw.Shape = []int{6, 1}
+	if _, err := options.DecodeContext(context.Background(), raw); err == nil {
+		t.Error("Expected an error for an output of 2 classes decoded with 1")
+	}
+
+	raw.Shape = []int{5, 1}
+	if boxes, err := options.DecodeContext(context.Background(), raw); err != nil || len(boxes) != 1 {
+		t.Errorf("Expected the cat, got %+v, %v", boxes, err)
+	}
+}
+
+func TestSaveRawOutputs_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outputs.gob")
+	want := []*detector.RawOutput{{
+		ImageSize:   detector.Size{Width: 200, Height: 100},
+		ImageOrigin: image.Pt(10, 20),
+		InputSize:   detector.Size{Width: 100, Height: 100},
+		Resize:      onnx.ResizeLetterbox,
+		Decoder:     detector.DecoderYOLOv8,
+		Shape:       []int{5, 1},
+		Data:        []float32{50, 50, 20, 20, 0.9},
+	}}
+	if err := detector.SaveRawOutputs(path, want); err != nil {
+		t.Fatalf("SaveRawOutputs returned error: %v", err)
+	}
+	got, err := detector.LoadRawOutputs(path)
+	if err != nil {
+		t.Fatalf("LoadRawOutputs returned error: %v", err)
+	}
+	if len(got) != 1 || got[0].ImageSize != want[0].ImageSize || got[0].ImageOrigin != want[0].ImageOrigin || got[0].Resize != onnx.ResizeLetterbox ||
+		got[0].Decoder != detector.DecoderYOLOv8 || !slices.Equal(got[0].Data, want[0].Data) {
+		t.Errorf("Expected %+v, got %+v", want[0], got[0])
+	}
+}
+
+func TestReadRawOutputs_Corrupted(t *testing.T) {
+	var buf bytes.Buffer
+	raw := []*detector.RawOutput{{Shape: []int{5, 2}, Data: []float32{1, 2, 3}}}
+	if err := detector.WriteRawOutputs(&buf, raw); err != nil {
+		t.Fatalf("WriteRawOutputs returned error: %v", err)
+	}
+	if _, err := detector.ReadRawOutputs(&buf); err == nil {
+		t.Error("Expected an error for data not matching its shape")
+	}
+	if _, err := detector.ReadRawOutputs(bytes.NewReader([]byte("garbage"))); err == nil {
+		t.Error("Expected an error for an invalid file")
+	}
+}
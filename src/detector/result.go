@@ -0,0 +1,61 @@
+package detector
+
+import (
+	"image"
+	"time"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+// Result is the outcome of running the detector on one image, with what is needed to trace it
+// back to the model and settings which produced it. It is meant to be logged as JSON.
+type Result struct {
+	Detections []onnx.BoundingBox `json:"detections"`
+	Timings    Timings            `json:"timings"`
+	Model      ModelInfo          `json:"model"`
+	// ImageSize is the size of the analyzed image, and InputSize the model input it was resized to.
+	ImageSize  Size       `json:"image_size"`
+	InputSize  Size       `json:"input_size"`
+	Thresholds Thresholds `json:"thresholds"`
+	// Raw is the raw output of the model for the image when Options.KeepRawOutput is set.
+	// It is left out of the JSON, see WriteRawOutputs to save it.
+	Raw *RawOutput `json:"-"`
+}
+
+// Timings holds the duration of each stage of an analysis, in nanoseconds once encoded to JSON.
+// For batches, Inference and Postprocess are those of the whole batch.
+type Timings struct {
+	Preprocess time.Duration `json:"preprocess_ns"`
+	// Inference includes the wait for the session when other analyses hold it.
+	Inference   time.Duration `json:"inference_ns"`
+	Postprocess time.Duration `json:"postprocess_ns"`
+	Total       time.Duration `json:"total_ns"`
+}
+
+// ModelInfo identifies the model which produced a result.
+type ModelInfo struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	// Version and Producer come from the model metadata.
+	Version  int64  `json:"version"`
+	Producer string `json:"producer,omitempty"`
+}
+
+// Size is the size of an image in pixels.
+type Size struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// sizeOf returns the size of img.
+func sizeOf(img image.Image) Size {
+	return Size{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+}
+
+// Thresholds holds the decoding settings in effect for a result.
+type Thresholds struct {
+	Confidence  float32            `json:"confidence"`
+	IoU         float32            `json:"iou"`
+	Classes     map[string]float32 `json:"classes,omitempty"`
+	ClassFilter onnx.ClassFilter   `json:"class_filter"`
+}
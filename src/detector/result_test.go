@@ -0,0 +1,53 @@
+package detector_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/detector"
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+func TestResult_JSON(t *testing.T) {
+	result := detector.Result{
+		Detections: []onnx.BoundingBox{{Label: "dog", ClassID: 16, Confidence: 0.9, X2: 10, Y2: 10}},
+		Timings:    detector.Timings{Preprocess: time.Millisecond, Total: 3 * time.Millisecond},
+		Model:      detector.ModelInfo{Path: "yolo11s.onnx", SHA256: "abc", Version: 2},
+		ImageSize:  detector.Size{Width: 810, Height: 1080},
+		InputSize:  detector.Size{Width: 640, Height: 640},
+		Thresholds: detector.Thresholds{Confidence: 0.5, IoU: 0.7},
+		Raw:        &detector.RawOutput{Shape: []int{5, 1}, Data: []float32{1, 2, 3, 4, 5}},
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %v", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("json.Unmarshal returned error: %v", err)
+	}
+	for _, key := range []string{"detections", "timings", "model", "image_size", "input_size", "thresholds"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("Expected key %q in %s", key, data)
+		}
+	}
+	if len(fields) != 6 {
+		t.Errorf("Expected the raw output to be left out of %s", data)
+	}
+	if string(fields["timings"]) != `{"preprocess_ns":1000000,"inference_ns":0,"postprocess_ns":0,"total_ns":3000000}` {
+		t.Errorf("Unexpected timings %s", fields["timings"])
+	}
+
+	var back detector.Result
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatalf("json.Unmarshal returned error: %v", err)
+	}
+	if back.Timings.Preprocess != time.Millisecond || back.Model.SHA256 != "abc" || back.ImageSize.Height != 1080 {
+		t.Errorf("Round trip lost fields: %+v", back)
+	}
+	if len(back.Detections) != 1 || back.Detections[0].ClassID != 16 {
+		t.Errorf("Round trip lost detections: %+v", back.Detections)
+	}
+}
@@ -0,0 +1,157 @@
+package detector
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+// imageSizeKey is the custom metadata key under which Ultralytics exports store the input size.
+const imageSizeKey = "imgsz"
+
+// labelsFilePlaceholder is the labels file of suggested manifests for models without class names.
+const labelsFilePlaceholder = "labels.txt"
+
+// SuggestManifest returns a manifest for the detection model at modelPath, from its first input
+// and output tensors and its metadata, as a starting point to review before deploying the model.
+//
+// Dynamic dimensions are fixed: the batch to 1, the image size to the imgsz metadata of
+// Ultralytics exports or 640, the detections to the anchor count of that size and decoder, and
+// the class axis to the number of names in the metadata. The decoder is guessed from the output
+// layout. An error is returned instead of a manifest with a dimension that cannot be known.
+// Labels are left to the names metadata of the model; without it, LabelsFile is set to
+// labels.txt, a file of class names to write next to the manifest.
+func SuggestManifest(modelPath string, inputs, outputs []onnx.TensorInfo, metadata onnx.ModelMetadata) (*Manifest, error) {
+	if len(inputs) == 0 || len(outputs) == 0 {
+		return nil, errors.New("model has no input or no output")
+	}
+	input, output := inputs[0], outputs[0]
+	if input.ElementType != "float32" {
+		return nil, fmt.Errorf("input %s is %s, detectors feed float32 images", input.Name, input.ElementType)
+	}
+	if len(input.Shape) != 4 {
+		return nil, fmt.Errorf("input %s has shape %v, expected [batch, channels, height, width]", input.Name, input.Shape)
+	}
+	if len(output.Shape) != 3 {
+		return nil, fmt.Errorf("output %s has shape %v, expected [batch, rows, columns]", output.Name, output.Shape)
+	}
+
+	if channels := input.Shape[1]; channels > 0 && channels != 3 {
+		return nil, fmt.Errorf("input %s has %d channels, detectors feed RGB images", input.Name, channels)
+	}
+
+	width, height, err := metadataImageSize(metadata)
+	if err != nil {
+		return nil, err
+	}
+	inputShape := []int64{1, 3, int64(height), int64(width)}
+	for i, dim := range input.Shape {
+		if dim > 0 {
+			inputShape[i] = dim
+		}
+	}
+
+	classes, err := metadata.ClassNames()
+	if err != nil {
+		return nil, err
+	}
+	decoder, err := guessDecoder(output.Shape, len(classes))
+	if err != nil {
+		return nil, fmt.Errorf("output %s: %w", output.Name, err)
+	}
+	anchors := int64(decoder.anchors(int(inputShape[3]), int(inputShape[2])))
+	// The class axis is only sized from the names metadata when it is dynamic.
+	outputShape := []int64{inputShape[0], -1, anchors}
+	classAxis, values := 1, int64(len(classes)+4)
+	if decoder == DecoderYOLOv5 {
+		outputShape = []int64{inputShape[0], anchors, -1}
+		classAxis, values = 2, int64(len(classes)+5)
+	}
+	if len(classes) > 0 {
+		outputShape[classAxis] = values
+	}
+	for i, dim := range output.Shape {
+		if dim > 0 {
+			outputShape[i] = dim
+		}
+	}
+	if outputShape[classAxis] <= 0 {
+		return nil, fmt.Errorf("output %s has a dynamic class axis and the model has no names metadata to size it", output.Name)
+	}
+	if len(classes) > 0 && outputShape[classAxis] != values {
+		return nil, fmt.Errorf("output %s has %d values per detection, which do not match the %d names of the metadata with decoder %s",
+			output.Name, outputShape[classAxis], len(classes), decoder)
+	}
+	if !positive(outputShape) {
+		return nil, fmt.Errorf("output %s has shape %v, whose unknown dimensions cannot be suggested", output.Name, output.Shape)
+	}
+
+	m := &Manifest{
+		Model:      ModelSpec{Path: modelPath},
+		Input:      TensorSpec{Name: input.Name, Shape: inputShape},
+		Output:     TensorSpec{Name: output.Name, Shape: outputShape},
+		Decoder:    decoder,
+		Thresholds: ThresholdsSpec{Confidence: 0.25, IoU: 0.7},
+	}
+	if len(classes) == 0 {
+		m.LabelsFile = labelsFilePlaceholder
+	}
+	return m, nil
+}
+
+// guessDecoder returns the decoder of a detection output of the given shape, YOLOv8 outputs
+// being (4 + classes, detections) and YOLOv5 ones (detections, 5 + classes). With class names,
+// it is the one whose class axis matches them, a dynamic axis being the class axis when the
+// fixed one does not. Otherwise, it is guessed from the axis holding the detections, the
+// dynamic or longest one. With both axes dynamic, the decoder is unknown.
+func guessDecoder(shape []int64, classes int) (Decoder, error) {
+	rows, columns := shape[1], shape[2]
+	switch {
+	case rows < 0 && columns < 0:
+		return "", errors.New("cannot guess the decoder of an output with dynamic rows and columns")
+	case classes > 0 && rows == int64(classes+4):
+		return DecoderYOLOv8, nil
+	case classes > 0 && columns == int64(classes+5):
+		return DecoderYOLOv5, nil
+	case classes > 0 && rows < 0:
+		return DecoderYOLOv8, nil
+	case classes > 0 && columns < 0:
+		return DecoderYOLOv5, nil
+	case rows < 0:
+		return DecoderYOLOv5, nil
+	case columns < 0:
+		return DecoderYOLOv8, nil
+	case rows < columns:
+		return DecoderYOLOv8, nil
+	default:
+		return DecoderYOLOv5, nil
+	}
+}
+
+// metadataImageSize returns the input size stored under imageSizeKey, written as [height, width]
+// or as a single side, and 640x640 when the model has no such key.
+func metadataImageSize(metadata onnx.ModelMetadata) (width, height int, err error) {
+	value, ok := metadata.Custom[imageSizeKey]
+	if !ok {
+		return 640, 640, nil
+	}
+	var sides []int
+	for _, field := range strings.Split(strings.Trim(value, "[]() "), ",") {
+		side, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || side <= 0 {
+			return 0, 0, fmt.Errorf("invalid %q metadata %q", imageSizeKey, value)
+		}
+		sides = append(sides, side)
+	}
+	switch len(sides) {
+	case 1:
+		return sides[0], sides[0], nil
+	case 2:
+		return sides[1], sides[0], nil
+	default:
+		return 0, 0, fmt.Errorf("invalid %q metadata %q", imageSizeKey, value)
+	}
+}
@@ -0,0 +1,136 @@
+package detector_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/detector"
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+// ultralyticsMetadata returns the metadata of an Ultralytics export with the given classes and imgsz.
+func ultralyticsMetadata(classes int, imgsz string) onnx.ModelMetadata {
+	names := make([]string, classes)
+	for i := range names {
+		names[i] = fmt.Sprintf("%d: 'class%d'", i, i)
+	}
+	return onnx.ModelMetadata{
+		Producer: "pytorch",
+		Custom: map[string]string{
+			"names":  "{" + strings.Join(names, ", ") + "}",
+			"imgsz":  imgsz,
+			"stride": "32",
+		},
+	}
+}
+
+func tensor(name string, shape ...int64) onnx.TensorInfo {
+	return onnx.TensorInfo{Name: name, ElementType: "float32", Shape: shape}
+}
+
+func TestSuggestManifest_FixedShapes(t *testing.T) {
+	m, err := detector.SuggestManifest("yolo11s.onnx",
+		[]onnx.TensorInfo{tensor("images", 1, 3, 640, 640)},
+		[]onnx.TensorInfo{tensor("output0", 1, 84, 8400)},
+		ultralyticsMetadata(80, "[640, 640]"))
+	if err != nil {
+		t.Fatalf("SuggestManifest returned error: %v", err)
+	}
+	if m.Decoder != detector.DecoderYOLOv8 || m.Input.Name != "images" || m.Output.Name != "output0" {
+		t.Errorf("Unexpected manifest %+v", m)
+	}
+	if !slices.Equal(m.Output.Shape, []int64{1, 84, 8400}) {
+		t.Errorf("Expected the output shape of the model, got %v", m.Output.Shape)
+	}
+	if err := m.Validate(); err != nil {
+		t.Errorf("Expected a valid manifest, got %v", err)
+	}
+	if m.LabelsFile != "" {
+		t.Errorf("Expected the labels of the metadata, got labels file %q", m.LabelsFile)
+	}
+}
+
+func TestSuggestManifest_NoClassNames(t *testing.T) {
+	metadata := ultralyticsMetadata(2, "640")
+	delete(metadata.Custom, "names")
+	m, err := detector.SuggestManifest("model.onnx",
+		[]onnx.TensorInfo{tensor("images", 1, 3, 640, 640)},
+		[]onnx.TensorInfo{tensor("output0", 1, 6, 8400)}, metadata)
+	if err != nil {
+		t.Fatalf("SuggestManifest returned error: %v", err)
+	}
+	if m.LabelsFile != "labels.txt" || len(m.Labels) != 0 {
+		t.Errorf("Expected a labels file placeholder, got %q and %q", m.LabelsFile, m.Labels)
+	}
+}
+
+func TestSuggestManifest_DynamicShapes(t *testing.T) {
+	tests := map[string]struct {
+		input, output   []int64
+		imgsz           string
+		wantIn, wantOut []int64
+		wantDecoder     detector.Decoder
+	}{
+		"yolov8 from imgsz": {
+			input: []int64{-1, 3, -1, -1}, output: []int64{-1, 6, -1}, imgsz: "[320, 480]",
+			wantIn: []int64{1, 3, 320, 480}, wantOut: []int64{1, 6, 3150}, wantDecoder: detector.DecoderYOLOv8,
+		},
+		"yolov5 without imgsz": {
+			input: []int64{-1, 3, -1, -1}, output: []int64{-1, -1, 7},
+			wantIn: []int64{1, 3, 640, 640}, wantOut: []int64{1, 25200, 7}, wantDecoder: detector.DecoderYOLOv5,
+		},
+		"yolov5 class axis from names": {
+			input: []int64{1, 3, 640, 640}, output: []int64{1, 25200, -1}, imgsz: "640",
+			wantIn: []int64{1, 3, 640, 640}, wantOut: []int64{1, 25200, 7}, wantDecoder: detector.DecoderYOLOv5,
+		},
+		"yolov8 class axis from names": {
+			input: []int64{1, 3, 640, 640}, output: []int64{1, -1, 8400}, imgsz: "640",
+			wantIn: []int64{1, 3, 640, 640}, wantOut: []int64{1, 6, 8400}, wantDecoder: detector.DecoderYOLOv8,
+		},
+		"fixed yolov5": {
+			input: []int64{1, 3, 640, 640}, output: []int64{1, 25200, 7}, imgsz: "640",
+			wantIn: []int64{1, 3, 640, 640}, wantOut: []int64{1, 25200, 7}, wantDecoder: detector.DecoderYOLOv5,
+		},
+	}
+	for name, test := range tests {
+		metadata := ultralyticsMetadata(2, test.imgsz)
+		if test.imgsz == "" {
+			delete(metadata.Custom, "imgsz")
+		}
+		m, err := detector.SuggestManifest("model.onnx",
+			[]onnx.TensorInfo{tensor("images", test.input...)},
+			[]onnx.TensorInfo{tensor("output0", test.output...)}, metadata)
+		if err != nil {
+			t.Errorf("%s: SuggestManifest returned error: %v", name, err)
+			continue
+		}
+		if !slices.Equal(m.Input.Shape, test.wantIn) || !slices.Equal(m.Output.Shape, test.wantOut) || m.Decoder != test.wantDecoder {
+			t.Errorf("%s: expected %v %v %s, got %v %v %s", name, test.wantIn, test.wantOut, test.wantDecoder,
+				m.Input.Shape, m.Output.Shape, m.Decoder)
+		}
+	}
+}
+
+func TestSuggestManifest_Errors(t *testing.T) {
+	metadata := ultralyticsMetadata(2, "640")
+	tests := map[string]struct {
+		inputs, outputs []onnx.TensorInfo
+		metadata        onnx.ModelMetadata
+	}{
+		"no output":          {[]onnx.TensorInfo{tensor("images", 1, 3, 640, 640)}, nil, metadata},
+		"uint8 input":        {[]onnx.TensorInfo{{Name: "images", ElementType: "uint8", Shape: []int64{1, 3, 640, 640}}}, []onnx.TensorInfo{tensor("output0", 1, 6, 8400)}, metadata},
+		"classification":     {[]onnx.TensorInfo{tensor("images", 1, 3, 224, 224)}, []onnx.TensorInfo{tensor("output0", 1, 1000)}, metadata},
+		"dynamic everywhere": {[]onnx.TensorInfo{tensor("images", 1, 3, 640, 640)}, []onnx.TensorInfo{tensor("output0", 1, -1, -1)}, metadata},
+		"grayscale input":    {[]onnx.TensorInfo{tensor("images", 1, 1, 640, 640)}, []onnx.TensorInfo{tensor("output0", 1, 6, 8400)}, metadata},
+		"empty class axis":   {[]onnx.TensorInfo{tensor("images", 1, 3, 640, 640)}, []onnx.TensorInfo{tensor("output0", 1, 0, 8400)}, onnx.ModelMetadata{}},
+		"names mismatch":     {[]onnx.TensorInfo{tensor("images", 1, 3, 640, 640)}, []onnx.TensorInfo{tensor("output0", 1, 84, 8400)}, metadata},
+		"invalid imgsz":      {[]onnx.TensorInfo{tensor("images", -1, 3, -1, -1)}, []onnx.TensorInfo{tensor("output0", 1, 6, -1)}, ultralyticsMetadata(2, "[640, x]")},
+	}
+	for name, test := range tests {
+		if _, err := detector.SuggestManifest("model.onnx", test.inputs, test.outputs, test.metadata); err == nil {
+			t.Errorf("Expected an error for %s", name)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package detector
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// LoadThresholds reads a thresholds file, the JSON of a ThresholdsSpec as written by
+// WriteThresholds:
+//
+//	{"confidence": 0.35, "iou": 0.6, "classes": {"person": 0.42}}
+func LoadThresholds(path string) (ThresholdsSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ThresholdsSpec{}, fmt.Errorf("failed to read thresholds: %w", err)
+	}
+	thresholds, err := ParseThresholds(data)
+	if err != nil {
+		return ThresholdsSpec{}, fmt.Errorf("invalid thresholds %s: %w", path, err)
+	}
+	return thresholds, nil
+}
+
+// ParseThresholds decodes and validates the JSON of a ThresholdsSpec.
+func ParseThresholds(data []byte) (ThresholdsSpec, error) {
+	var thresholds ThresholdsSpec
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&thresholds); err != nil {
+		return ThresholdsSpec{}, fmt.Errorf("failed to decode thresholds: %w", err)
+	}
+	if err := thresholds.Validate(); err != nil {
+		return ThresholdsSpec{}, err
+	}
+	return thresholds, nil
+}
+
+// Validate checks that every threshold is in [0, 1].
+func (t ThresholdsSpec) Validate() error {
+	var errs []error
+	if t.Confidence < 0 || t.Confidence > 1 {
+		errs = append(errs, fmt.Errorf("confidence threshold %v is outside [0, 1]", t.Confidence))
+	}
+	if t.IoU < 0 || t.IoU > 1 {
+		errs = append(errs, fmt.Errorf("IoU threshold %v is outside [0, 1]", t.IoU))
+	}
+	for label, threshold := range t.Classes {
+		if threshold < 0 || threshold > 1 {
+			errs = append(errs, fmt.Errorf("confidence threshold %v of class %q is outside [0, 1]", threshold, label))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WriteThresholds writes thresholds to w as indented JSON.
+func WriteThresholds(w io.Writer, thresholds ThresholdsSpec) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(thresholds)
+}
+
+// SaveThresholds writes thresholds to a JSON file read back by LoadThresholds.
+func SaveThresholds(path string, thresholds ThresholdsSpec) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create thresholds file: %w", err)
+	}
+	if err := WriteThresholds(file, thresholds); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// WithThresholds returns a copy of the options decoding with thresholds. A zero confidence
+// or IoU keeps the threshold of the options.
+func (o Options) WithThresholds(thresholds ThresholdsSpec) Options {
+	if thresholds.Confidence != 0 {
+		o.ConfidenceThreshold = thresholds.Confidence
+	}
+	o.ClassThresholds = thresholds.Classes
+	if thresholds.IoU != 0 {
+		o.IoUThreshold = thresholds.IoU
+	}
+	return o
+}
@@ -0,0 +1,73 @@
+package detector_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/detector"
+)
+
+func TestSaveThresholds_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "thresholds.json")
+	want := detector.ThresholdsSpec{Confidence: 0.35, IoU: 0.6, Classes: map[string]float32{"cat": 0.42}}
+	if err := detector.SaveThresholds(path, want); err != nil {
+		t.Fatalf("SaveThresholds returned error: %v", err)
+	}
+	got, err := detector.LoadThresholds(path)
+	if err != nil {
+		t.Fatalf("LoadThresholds returned error: %v", err)
+	}
+	if got.Confidence != want.Confidence || got.IoU != want.IoU || got.Classes["cat"] != 0.42 {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestParseThresholds_Invalid(t *testing.T) {
+	for _, data := range []string{
+		`{"confidence": 1.5}`,
+		`{"confidence": 0.5, "classes": {"cat": -1}}`,
+		`{"confidence": 0.5, "nms": 0.5}`,
+		`not json`,
+	} {
+		if _, err := detector.ParseThresholds([]byte(data)); err == nil {
+			t.Errorf("Expected an error for %s", data)
+		}
+	}
+}
+
+func TestOptions_WithThresholds(t *testing.T) {
+	o := detector.Options{ConfidenceThreshold: 0.5, IoUThreshold: 0.7}
+	o = o.WithThresholds(detector.ThresholdsSpec{Confidence: 0.3, Classes: map[string]float32{"cat": 0.4}})
+	if o.ConfidenceThreshold != 0.3 || o.IoUThreshold != 0.7 || o.ClassThresholds["cat"] != 0.4 {
+		t.Errorf("Unexpected options %+v", o)
+	}
+	o = o.WithThresholds(detector.ThresholdsSpec{IoU: 0.55})
+	if o.ConfidenceThreshold != 0.3 || o.IoUThreshold != 0.55 || o.ClassThresholds != nil {
+		t.Errorf("Unexpected options %+v", o)
+	}
+}
+
+func TestParseManifest_ThresholdsFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "thresholds.json"), `{"confidence": 0.25, "iou": 0.6, "classes": {"dog": 0.3}}`)
+	data := `{
+		"model": {"path": "model.onnx"},
+		"input": {"shape": [1, 3, 320, 320]},
+		"output": {"shape": [1, 6, 2100]},
+		"labels": ["cat", "dog"],
+		"thresholds_file": "thresholds.json"
+	}`
+	m, err := detector.ParseManifest([]byte(data), dir)
+	if err != nil {
+		t.Fatalf("ParseManifest returned error: %v", err)
+	}
+	options := m.Options("lib.so")
+	if options.ConfidenceThreshold != 0.25 || options.IoUThreshold != 0.6 || options.ClassThresholds["dog"] != 0.3 {
+		t.Errorf("Expected the thresholds of the file, got %+v", options)
+	}
+
+	writeFile(t, filepath.Join(dir, "thresholds.json"), `{"confidence": 0.25, "classes": {"horse": 0.3}}`)
+	if _, err := detector.ParseManifest([]byte(data), dir); err == nil {
+		t.Error("Expected an error for a threshold of an unknown class")
+	}
+}
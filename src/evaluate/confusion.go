@@ -0,0 +1,163 @@
+package evaluate
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// Background is the name of the background row and column of a ConfusionMatrix.
+const Background = "background"
+
+// ConfusionMatrix counts how predictions match the ground truth, class against class.
+// Predictions are matched to ground truth boxes of any label, pairs of highest IoU first.
+type ConfusionMatrix struct {
+	// Classes are the labels of the rows and columns, before the background.
+	Classes             []string `json:"classes"`
+	IoUThreshold        float64  `json:"iou_threshold"`
+	ConfidenceThreshold float32  `json:"confidence_threshold"`
+	// Matrix[p][g] counts the predictions of class p matched to a ground truth box of class g.
+	// The last row counts the ground truth boxes no prediction matched, the false negatives,
+	// and the last column the predictions matching no ground truth box, the false positives.
+	Matrix [][]int `json:"matrix"`
+}
+
+// Confusion computes the confusion matrix of the predictions from options.ConfidenceThreshold,
+// at options.IoUThreshold, keeping the options.MaxDetections most confident predictions of
+// each image as in Evaluate. Classes default to the labels of the predictions and the ground
+// truth, sorted. Boxes of other labels, and unmatched predictions lying on a crowd region of
+// their label, are ignored. A class named Background is rejected, as its row and column would
+// be mistaken for the background ones.
+func Confusion(images []Image, options Options) (*ConfusionMatrix, error) {
+	options = options.withDefaults()
+	classes := options.Classes
+	if len(classes) == 0 {
+		classes = sortedLabels(images, true)
+	}
+	images = keepMostConfident(images, options.MaxDetections)
+	if slices.Contains(classes, Background) {
+		return nil, fmt.Errorf("class %q is reserved for the background row and column of the confusion matrix", Background)
+	}
+	index := make(map[string]int, len(classes))
+	for i, label := range classes {
+		index[label] = i
+	}
+
+	background := len(classes)
+	m := &ConfusionMatrix{
+		Classes:             classes,
+		IoUThreshold:        options.IoUThreshold,
+		ConfidenceThreshold: options.ConfidenceThreshold,
+		Matrix:              make([][]int, background+1),
+	}
+	for i := range m.Matrix {
+		m.Matrix[i] = make([]int, background+1)
+	}
+
+	for _, image := range images {
+		var predicted, actual []int
+		var predictions, groundTruth []int
+		for i, box := range image.Predictions {
+			if c, ok := index[box.Label]; ok && box.Confidence >= options.ConfidenceThreshold {
+				predictions, predicted = append(predictions, i), append(predicted, c)
+			}
+		}
+		for g, box := range image.GroundTruth {
+			if c, ok := index[box.Label]; ok {
+				groundTruth, actual = append(groundTruth, g), append(actual, c)
+			}
+		}
+
+		type pair struct {
+			p, g int
+			iou  float64
+		}
+		var pairs []pair
+		for p, i := range predictions {
+			for g, j := range groundTruth {
+				if iou := float64(image.Predictions[i].IoU(&image.GroundTruth[j])); iou >= options.IoUThreshold {
+					pairs = append(pairs, pair{p: p, g: g, iou: iou})
+				}
+			}
+		}
+		sort.SliceStable(pairs, func(i, j int) bool {
+			return pairs[i].iou > pairs[j].iou
+		})
+
+		matchedP := make([]bool, len(predictions))
+		matchedG := make([]bool, len(groundTruth))
+		for _, pair := range pairs {
+			if matchedP[pair.p] || matchedG[pair.g] {
+				continue
+			}
+			matchedP[pair.p], matchedG[pair.g] = true, true
+			m.Matrix[predicted[pair.p]][actual[pair.g]]++
+		}
+		for p, matched := range matchedP {
+			prediction := &image.Predictions[predictions[p]]
+			if !matched && !onCrowd(prediction, ofLabel(image.Crowd, prediction.Label), options.IoUThreshold) {
+				m.Matrix[predicted[p]][background]++
+			}
+		}
+		for g, matched := range matchedG {
+			if !matched {
+				m.Matrix[background][actual[g]]++
+			}
+		}
+	}
+	return m, nil
+}
+
+// Count returns the number of predictions of class predicted matched to a ground truth box of
+// class actual. Either may be Background.
+func (m *ConfusionMatrix) Count(predicted, actual string) int {
+	p, g := m.index(predicted), m.index(actual)
+	if p < 0 || g < 0 {
+		return 0
+	}
+	return m.Matrix[p][g]
+}
+
+// index returns the row or column of label, or -1.
+func (m *ConfusionMatrix) index(label string) int {
+	if label == Background {
+		return len(m.Classes)
+	}
+	return slices.Index(m.Classes, label)
+}
+
+// Labels returns the labels of the rows and columns, the classes then Background.
+func (m *ConfusionMatrix) Labels() []string {
+	return append(slices.Clone(m.Classes), Background)
+}
+
+// WriteCSV writes the matrix to w as CSV, with a header row of the ground truth labels and a
+// first column of the predicted labels.
+func (m *ConfusionMatrix) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	labels := m.Labels()
+	if err := writer.Write(append([]string{"predicted/actual"}, labels...)); err != nil {
+		return err
+	}
+	for p, row := range m.Matrix {
+		record := make([]string, 0, len(row)+1)
+		record = append(record, labels[p])
+		for _, count := range row {
+			record = append(record, strconv.Itoa(count))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes the matrix to w as JSON.
+func (m *ConfusionMatrix) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(m)
+}
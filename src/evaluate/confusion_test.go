@@ -0,0 +1,128 @@
+package evaluate_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/evaluate"
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+// confusedImages has a car found, a truck predicted as a car, a missed truck and a false car.
+func confusedImages() []evaluate.Image {
+	return []evaluate.Image{
+		{
+			Predictions: []onnx.BoundingBox{box("car", 0.9, 0, 0, 10, 10), box("car", 0.8, 20, 20, 40, 40)},
+			GroundTruth: []onnx.BoundingBox{box("car", 1, 0, 0, 10, 10), box("truck", 1, 20, 20, 40, 42)},
+		},
+		{
+			Predictions: []onnx.BoundingBox{box("car", 0.6, 100, 100, 110, 110), box("truck", 0.2, 0, 0, 30, 30)},
+			GroundTruth: []onnx.BoundingBox{box("truck", 1, 0, 0, 30, 30)},
+		},
+	}
+}
+
+func TestConfusion(t *testing.T) {
+	m, err := evaluate.Confusion(confusedImages(), evaluate.Options{ConfidenceThreshold: 0.5})
+	if err != nil {
+		t.Fatalf("Confusion returned error: %v", err)
+	}
+
+	if len(m.Classes) != 2 || m.Classes[0] != "car" || m.Classes[1] != "truck" || m.IoUThreshold != 0.5 {
+		t.Fatalf("Unexpected matrix %+v", m)
+	}
+	want := [][]int{
+		{1, 1, 1},
+		{0, 0, 0},
+		{0, 1, 0},
+	}
+	for p := range want {
+		for g := range want[p] {
+			if m.Matrix[p][g] != want[p][g] {
+				t.Fatalf("Expected matrix %v, got %v", want, m.Matrix)
+			}
+		}
+	}
+	if m.Count("car", "truck") != 1 || m.Count(evaluate.Background, "truck") != 1 || m.Count("car", evaluate.Background) != 1 {
+		t.Errorf("Unexpected counts in %v", m.Matrix)
+	}
+	if m.Count("bus", "car") != 0 {
+		t.Error("Expected no count for an unknown class")
+	}
+
+	// Without the confidence threshold the low confidence truck is found.
+	m, err = evaluate.Confusion(confusedImages(), evaluate.Options{})
+	if err != nil {
+		t.Fatalf("Confusion returned error: %v", err)
+	}
+	if m.Count("truck", "truck") != 1 || m.Count(evaluate.Background, "truck") != 0 {
+		t.Errorf("Expected the truck to be found, got %v", m.Matrix)
+	}
+
+	// Keeping the most confident prediction of each image drops the second car and the truck.
+	m, err = evaluate.Confusion(confusedImages(), evaluate.Options{MaxDetections: 1})
+	if err != nil {
+		t.Fatalf("Confusion returned error: %v", err)
+	}
+	if m.Count("car", "truck") != 0 || m.Count("truck", "truck") != 0 || m.Count(evaluate.Background, "truck") != 2 {
+		t.Errorf("Expected both trucks to be missed, got %v", m.Matrix)
+	}
+}
+
+func TestConfusion_BackgroundClass(t *testing.T) {
+	images := []evaluate.Image{{
+		Predictions: []onnx.BoundingBox{box("background", 0.9, 0, 0, 10, 10)},
+		GroundTruth: []onnx.BoundingBox{box("car", 1, 0, 0, 10, 10)},
+	}}
+	if _, err := evaluate.Confusion(images, evaluate.Options{}); err == nil {
+		t.Error("Expected a class named background to be rejected")
+	}
+	if _, err := evaluate.Confusion(confusedImages(), evaluate.Options{Classes: []string{"car", evaluate.Background}}); err == nil {
+		t.Error("Expected the background class to be rejected from options")
+	}
+}
+
+func TestConfusionMatrix_WriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	m, err := evaluate.Confusion(confusedImages(), evaluate.Options{ConfidenceThreshold: 0.5})
+	if err != nil {
+		t.Fatalf("Confusion returned error: %v", err)
+	}
+	if err := m.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV returned error: %v", err)
+	}
+	want := "predicted/actual,car,truck,background\ncar,1,1,1\ntruck,0,0,0\nbackground,0,1,0\n"
+	if buf.String() != want {
+		t.Errorf("WriteCSV wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+	if err := m.WriteCSV(failingWriter{}); err == nil {
+		t.Error("Expected WriteCSV to report the write error")
+	}
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestConfusionMatrix_WriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	m, err := evaluate.Confusion(confusedImages(), evaluate.Options{})
+	if err != nil {
+		t.Fatalf("Confusion returned error: %v", err)
+	}
+	if err := m.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON returned error: %v", err)
+	}
+	var decoded evaluate.ConfusionMatrix
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to decode %s: %v", buf.String(), err)
+	}
+	if len(decoded.Matrix) != 3 || decoded.Matrix[1][1] != 1 || decoded.Classes[1] != "truck" {
+		t.Errorf("Unexpected decoded matrix %+v", decoded)
+	}
+}
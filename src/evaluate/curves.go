@@ -0,0 +1,127 @@
+package evaluate
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// ConfidencePoints is the number of confidence thresholds of a Curve, 0 to 1 by 0.01.
+const ConfidencePoints = 101
+
+// Curve is the precision, recall and F1 score of a class across confidence thresholds.
+type Curve struct {
+	Label       string       `json:"label"`
+	GroundTruth int          `json:"ground_truth"`
+	Points      []CurvePoint `json:"points"`
+}
+
+// CurvePoint is the evaluation of a class keeping the predictions from Confidence.
+type CurvePoint struct {
+	Confidence float32 `json:"confidence"`
+	Precision  float64 `json:"precision"`
+	Recall     float64 `json:"recall"`
+	F1         float64 `json:"f1"`
+	TP         int     `json:"tp"`
+	FP         int     `json:"fp"`
+	FN         int     `json:"fn"`
+}
+
+// Curves computes the curve of each class at options.IoUThreshold, with predictions matched
+// as in Evaluate. Classes default to the labels of the predictions and the ground truth,
+// sorted, so predictions of classes missing from the ground truth count as false positives
+// in the Total of the curves.
+func Curves(images []Image, options Options) []Curve {
+	options = options.withDefaults()
+	classes := options.Classes
+	if len(classes) == 0 {
+		classes = sortedLabels(images, true)
+	}
+	images = keepMostConfident(images, options.MaxDetections)
+
+	curves := make([]Curve, 0, len(classes))
+	for _, label := range classes {
+		records, groundTruth, _ := matchClass(images, label, []float64{options.IoUThreshold})
+		curve := Curve{Label: label, GroundTruth: groundTruth, Points: make([]CurvePoint, ConfidencePoints)}
+		for k := range curve.Points {
+			confidence := float32(k) / float32(ConfidencePoints-1)
+			tp, fp := countAbove(records[0], confidence)
+			curve.Points[k] = newCurvePoint(confidence, tp, fp, groundTruth-tp)
+		}
+		curves = append(curves, curve)
+	}
+	return curves
+}
+
+// Total returns the curve of all classes together, summing their counts at each confidence.
+func Total(curves []Curve) Curve {
+	total := Curve{Label: "all", Points: make([]CurvePoint, ConfidencePoints)}
+	for k := range total.Points {
+		var tp, fp, fn int
+		for _, curve := range curves {
+			tp += curve.Points[k].TP
+			fp += curve.Points[k].FP
+			fn += curve.Points[k].FN
+		}
+		total.Points[k] = newCurvePoint(float32(k)/float32(ConfidencePoints-1), tp, fp, fn)
+	}
+	for _, curve := range curves {
+		total.GroundTruth += curve.GroundTruth
+	}
+	return total
+}
+
+// newCurvePoint returns the point of the counts at confidence.
+func newCurvePoint(confidence float32, tp, fp, fn int) CurvePoint {
+	point := CurvePoint{
+		Confidence: confidence,
+		Precision:  ratio(tp, tp+fp),
+		Recall:     ratio(tp, tp+fn),
+		TP:         tp,
+		FP:         fp,
+		FN:         fn,
+	}
+	if point.Precision+point.Recall > 0 {
+		point.F1 = 2 * point.Precision * point.Recall / (point.Precision + point.Recall)
+	}
+	return point
+}
+
+// Best returns the point of highest F1 score, the lowest confidence among ties.
+func (c *Curve) Best() CurvePoint {
+	var best CurvePoint
+	for i, point := range c.Points {
+		if i == 0 || point.F1 > best.F1 {
+			best = point
+		}
+	}
+	return best
+}
+
+// WriteCurvesCSV writes curves to w as CSV, one row per class and confidence threshold.
+func WriteCurvesCSV(w io.Writer, curves []Curve) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"label", "confidence", "precision", "recall", "f1", "tp", "fp", "fn"})
+	for _, curve := range curves {
+		for _, point := range curve.Points {
+			writer.Write([]string{
+				curve.Label,
+				strconv.FormatFloat(float64(point.Confidence), 'f', 2, 32),
+				strconv.FormatFloat(point.Precision, 'f', 6, 64),
+				strconv.FormatFloat(point.Recall, 'f', 6, 64),
+				strconv.FormatFloat(point.F1, 'f', 6, 64),
+				strconv.Itoa(point.TP),
+				strconv.Itoa(point.FP),
+				strconv.Itoa(point.FN),
+			})
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteCurvesJSON writes curves to w as a JSON array.
+func WriteCurvesJSON(w io.Writer, curves []Curve) error {
+	return json.NewEncoder(w).Encode(curves)
+}
@@ -0,0 +1,116 @@
+package evaluate_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/evaluate"
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+func curveImages() []evaluate.Image {
+	// Three cats, found at 0.9 and 0.4, with a false positive at 0.7.
+	return []evaluate.Image{{
+		Predictions: []onnx.BoundingBox{
+			box("cat", 0.9, 0, 0, 10, 10),
+			box("cat", 0.7, 50, 50, 60, 60),
+			box("cat", 0.4, 20, 20, 30, 30),
+		},
+		GroundTruth: []onnx.BoundingBox{
+			box("cat", 1, 0, 0, 10, 10),
+			box("cat", 1, 20, 20, 30, 30),
+			box("cat", 1, 80, 80, 90, 90),
+		},
+	}}
+}
+
+func TestCurves(t *testing.T) {
+	curves := evaluate.Curves(curveImages(), evaluate.Options{})
+	if len(curves) != 1 || curves[0].Label != "cat" || curves[0].GroundTruth != 3 {
+		t.Fatalf("Unexpected curves %+v", curves)
+	}
+	points := curves[0].Points
+	if len(points) != evaluate.ConfidencePoints {
+		t.Fatalf("Expected %d points, got %d", evaluate.ConfidencePoints, len(points))
+	}
+
+	// From 0.4: 2 TP, 1 FP, 1 FN.
+	p := points[40]
+	if p.Confidence != 0.4 || p.TP != 2 || p.FP != 1 || p.FN != 1 || !near(p.F1, 2.0/3) {
+		t.Errorf("Unexpected point at 0.4 %+v", p)
+	}
+	// From 0.8: 1 TP, 0 FP, 2 FN.
+	p = points[80]
+	if p.TP != 1 || p.FP != 0 || p.FN != 2 || p.Precision != 1 || !near(p.Recall, 1.0/3) || !near(p.F1, 0.5) {
+		t.Errorf("Unexpected point at 0.8 %+v", p)
+	}
+	// Above every prediction.
+	p = points[100]
+	if p.TP != 0 || p.FP != 0 || p.FN != 3 || p.Precision != 0 || p.F1 != 0 {
+		t.Errorf("Unexpected point at 1 %+v", p)
+	}
+
+	best := curves[0].Best()
+	if best.Confidence != 0 || !near(best.F1, 2.0/3) {
+		t.Errorf("Expected the lowest confidence of best F1, got %+v", best)
+	}
+}
+
+func TestWriteCurvesCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := evaluate.WriteCurvesCSV(&buf, evaluate.Curves(curveImages(), evaluate.Options{})); err != nil {
+		t.Fatalf("WriteCurvesCSV returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1+evaluate.ConfidencePoints {
+		t.Fatalf("Expected %d lines, got %d", 1+evaluate.ConfidencePoints, len(lines))
+	}
+	if lines[0] != "label,confidence,precision,recall,f1,tp,fp,fn" {
+		t.Errorf("Unexpected header %q", lines[0])
+	}
+	if want := "cat,0.80,1.000000,0.333333,0.500000,1,0,2"; lines[81] != want {
+		t.Errorf("Expected row %q, got %q", want, lines[81])
+	}
+}
+
+func TestWriteCurvesJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := evaluate.WriteCurvesJSON(&buf, evaluate.Curves(curveImages(), evaluate.Options{})); err != nil {
+		t.Fatalf("WriteCurvesJSON returned error: %v", err)
+	}
+	var decoded []evaluate.Curve
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to decode curves: %v", err)
+	}
+	if len(decoded) != 1 || decoded[0].Points[40].TP != 2 {
+		t.Errorf("Unexpected decoded curves %+v", decoded)
+	}
+}
+
+func TestTotal(t *testing.T) {
+	images := curveImages()
+	images[0].Predictions = append(images[0].Predictions, box("dog", 0.6, 100, 100, 110, 110))
+	images[0].GroundTruth = append(images[0].GroundTruth, box("dog", 1, 100, 100, 110, 110))
+
+	total := evaluate.Total(evaluate.Curves(images, evaluate.Options{}))
+	if total.Label != "all" || total.GroundTruth != 4 || len(total.Points) != evaluate.ConfidencePoints {
+		t.Fatalf("Unexpected total curve %+v", total)
+	}
+	// From 0.5: the cat at 0.9, the false cat at 0.7 and the dog at 0.6.
+	p := total.Points[50]
+	if p.TP != 2 || p.FP != 1 || p.FN != 2 || !near(p.Precision, 2.0/3) || p.Recall != 0.5 {
+		t.Errorf("Unexpected point at 0.5 %+v", p)
+	}
+
+	// A bird only predicted is a false positive of the total.
+	images[0].Predictions = append(images[0].Predictions, box("bird", 0.8, 200, 200, 210, 210))
+	curves := evaluate.Curves(images, evaluate.Options{})
+	if len(curves) != 3 || curves[0].Label != "bird" || curves[0].GroundTruth != 0 {
+		t.Fatalf("Expected a bird curve without ground truth, got %+v", curves)
+	}
+	if p := evaluate.Total(curves).Points[50]; p.TP != 2 || p.FP != 2 || !near(p.Precision, 0.5) {
+		t.Errorf("Expected the bird as a false positive at 0.5, got %+v", p)
+	}
+}
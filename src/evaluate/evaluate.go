@@ -0,0 +1,371 @@
+// Package evaluate measures detection quality against ground truth, following the COCO
+// evaluation protocol: the most confident detections of each image are kept, they are matched
+// greedily by decreasing confidence to the unmatched ground truth box of the same label with the
+// highest IoU, detections left on crowd regions are ignored, and average precision is the mean
+// of the interpolated precision at 101 recall points. Confusion and Curves show which classes
+// are confused and how precision and recall trade off across confidence thresholds.
+//
+//	report := evaluate.Evaluate([]evaluate.Image{{Predictions: boxes, GroundTruth: annotations}}, evaluate.Options{})
+//	fmt.Printf("mAP %.3f, mAP@.5 %.3f\n", report.MAP, report.MAP50)
+package evaluate
+
+import (
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+// RecallPoints is the number of recall values at which precision is interpolated, 0 to 1 by 0.01.
+const RecallPoints = 101
+
+// IoUThresholds are the IoU thresholds averaged by mAP@[.5:.95], 0.5 to 0.95 by 0.05.
+var IoUThresholds = func() []float64 {
+	thresholds := make([]float64, 10)
+	for i := range thresholds {
+		thresholds[i] = float64(50+5*i) / 100
+	}
+	return thresholds
+}()
+
+// Image holds the predicted and ground truth boxes of an image, in the same coordinates.
+// Boxes are matched by Label.
+type Image struct {
+	Predictions []onnx.BoundingBox
+	GroundTruth []onnx.BoundingBox
+	// Crowd holds the ground truth regions of groups of objects, the COCO iscrowd annotations.
+	// They need not be detected, and predictions matching no ground truth box but lying on a
+	// crowd region of their label are ignored instead of counted as false positives.
+	Crowd []onnx.BoundingBox
+}
+
+// Options configures an evaluation.
+type Options struct {
+	// Classes lists the labels to evaluate, in the order of the report. It defaults to the
+	// labels of the ground truth, sorted. Predictions of other labels are ignored.
+	Classes []string
+	// MaxDetections is the number of most confident predictions kept per image, over all
+	// classes. It defaults to 100, as in COCO.
+	MaxDetections int
+	// ConfidenceThreshold is the confidence from which predictions are counted in the TP, FP
+	// and FN counts, in precision and recall, and in the confusion matrix. Average precision
+	// and the curves use every prediction.
+	ConfidenceThreshold float32
+	// IoUThreshold is the IoU from which a prediction matches a ground truth box in the
+	// confusion matrix and the curves. It defaults to 0.5.
+	IoUThreshold float64
+}
+
+// withDefaults returns the options with defaults applied.
+func (o Options) withDefaults() Options {
+	if o.MaxDetections <= 0 {
+		o.MaxDetections = 100
+	}
+	if o.IoUThreshold <= 0 {
+		o.IoUThreshold = 0.5
+	}
+	return o
+}
+
+// Report holds the result of an evaluation.
+type Report struct {
+	// MAP is the mean over classes of the AP averaged over IoUThresholds, mAP@[.5:.95].
+	MAP float64 `json:"map"`
+	// MAP50 and MAP75 are the mean AP at IoU 0.5 and 0.75.
+	MAP50 float64 `json:"map50"`
+	MAP75 float64 `json:"map75"`
+	// Precision, Recall and the counts are summed over classes, at IoU 0.5 and ConfidenceThreshold.
+	Precision float64       `json:"precision"`
+	Recall    float64       `json:"recall"`
+	TP        int           `json:"tp"`
+	FP        int           `json:"fp"`
+	FN        int           `json:"fn"`
+	Classes   []ClassReport `json:"classes"`
+}
+
+// ClassReport holds the evaluation of a class.
+// Classes without ground truth have zero AP and are left out of the means of the Report.
+type ClassReport struct {
+	Label string `json:"label"`
+	// GroundTruth and Predictions are the number of boxes of the class.
+	GroundTruth int     `json:"ground_truth"`
+	Predictions int     `json:"predictions"`
+	AP          float64 `json:"ap"`
+	AP50        float64 `json:"ap50"`
+	AP75        float64 `json:"ap75"`
+	// Precision, Recall and the counts are at IoU 0.5 and ConfidenceThreshold.
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	TP        int     `json:"tp"`
+	FP        int     `json:"fp"`
+	FN        int     `json:"fn"`
+	// PrecisionCurve is the interpolated precision at each of the RecallPoints, at IoU 0.5.
+	PrecisionCurve []float64 `json:"precision_curve"`
+}
+
+// scored is a prediction with its match result at one IoU threshold.
+type scored struct {
+	confidence float32
+	tp         bool
+}
+
+// Evaluate compares the predictions of each image to its ground truth.
+func Evaluate(images []Image, options Options) Report {
+	options = options.withDefaults()
+	classes := options.Classes
+	if len(classes) == 0 {
+		classes = sortedLabels(images, false)
+	}
+	images = keepMostConfident(images, options.MaxDetections)
+
+	report := Report{Classes: make([]ClassReport, 0, len(classes))}
+	evaluated := 0
+	for _, label := range classes {
+		class := evaluateClass(images, label, options)
+		report.Classes = append(report.Classes, class)
+		report.TP += class.TP
+		report.FP += class.FP
+		report.FN += class.FN
+		if class.GroundTruth > 0 {
+			evaluated++
+			report.MAP += class.AP
+			report.MAP50 += class.AP50
+			report.MAP75 += class.AP75
+		}
+	}
+	if evaluated > 0 {
+		report.MAP /= float64(evaluated)
+		report.MAP50 /= float64(evaluated)
+		report.MAP75 /= float64(evaluated)
+	}
+	report.Precision = ratio(report.TP, report.TP+report.FP)
+	report.Recall = ratio(report.TP, report.TP+report.FN)
+	return report
+}
+
+// sortedLabels returns the distinct labels of the ground truth, and of the predictions when
+// withPredictions is set, sorted.
+func sortedLabels(images []Image, withPredictions bool) []string {
+	seen := map[string]bool{}
+	var labels []string
+	add := func(boxes []onnx.BoundingBox) {
+		for _, box := range boxes {
+			if !seen[box.Label] {
+				seen[box.Label] = true
+				labels = append(labels, box.Label)
+			}
+		}
+	}
+	for _, image := range images {
+		add(image.GroundTruth)
+		if withPredictions {
+			add(image.Predictions)
+		}
+	}
+	slices.Sort(labels)
+	return labels
+}
+
+// keepMostConfident returns the images with their maxDetections most confident predictions.
+func keepMostConfident(images []Image, maxDetections int) []Image {
+	kept := make([]Image, len(images))
+	for i, image := range images {
+		predictions := slices.Clone(image.Predictions)
+		sortByConfidence(predictions)
+		if len(predictions) > maxDetections {
+			predictions = predictions[:maxDetections]
+		}
+		image.Predictions = predictions
+		kept[i] = image
+	}
+	return kept
+}
+
+// evaluateClass computes the report of the boxes labelled label.
+func evaluateClass(images []Image, label string, options Options) ClassReport {
+	class := ClassReport{Label: label}
+	var records [][]scored
+	records, class.GroundTruth, class.Predictions = matchClass(images, label, IoUThresholds)
+
+	class.TP, class.FP = countAbove(records[0], options.ConfidenceThreshold)
+	class.FN = class.GroundTruth - class.TP
+	class.Precision = ratio(class.TP, class.TP+class.FP)
+	class.Recall = ratio(class.TP, class.GroundTruth)
+
+	class.PrecisionCurve = make([]float64, RecallPoints)
+	if class.GroundTruth == 0 {
+		return class
+	}
+	for t := range IoUThresholds {
+		ap, curve := averagePrecision(records[t], class.GroundTruth)
+		class.AP += ap
+		switch IoUThresholds[t] {
+		case 0.5:
+			class.AP50 = ap
+			class.PrecisionCurve = curve
+		case 0.75:
+			class.AP75 = ap
+		}
+	}
+	class.AP /= float64(len(IoUThresholds))
+	return class
+}
+
+// matchClass matches the predictions of the boxes labelled label to the ground truth of each
+// image at each of the IoU thresholds. It returns the matched predictions per threshold, without
+// the ignored ones, and the number of ground truth and predicted boxes.
+func matchClass(images []Image, label string, thresholds []float64) ([][]scored, int, int) {
+	records := make([][]scored, len(thresholds))
+	groundTruthCount, predictionCount := 0, 0
+	for _, image := range images {
+		predictions := ofLabel(image.Predictions, label)
+		sortByConfidence(predictions)
+		groundTruth := ofLabel(image.GroundTruth, label)
+		crowd := ofLabel(image.Crowd, label)
+		groundTruthCount += len(groundTruth)
+		predictionCount += len(predictions)
+
+		for t, threshold := range thresholds {
+			for i, result := range match(predictions, groundTruth, crowd, threshold) {
+				if result != ignored {
+					records[t] = append(records[t], scored{confidence: predictions[i].Confidence, tp: result == truePositive})
+				}
+			}
+		}
+	}
+	return records, groundTruthCount, predictionCount
+}
+
+// countAbove returns the number of true and false positives from the confidence threshold.
+// Predictions are matched by decreasing confidence, so the less confident ones do not change
+// the matches of those above the threshold.
+func countAbove(records []scored, threshold float32) (tp, fp int) {
+	for _, r := range records {
+		if r.confidence < threshold {
+			continue
+		}
+		if r.tp {
+			tp++
+		} else {
+			fp++
+		}
+	}
+	return tp, fp
+}
+
+// ofLabel returns the boxes labelled label.
+func ofLabel(boxes []onnx.BoundingBox, label string) []onnx.BoundingBox {
+	var selected []onnx.BoundingBox
+	for _, box := range boxes {
+		if box.Label == label {
+			selected = append(selected, box)
+		}
+	}
+	return selected
+}
+
+// sortByConfidence sorts boxes from the most to the least confident, keeping the order of ties.
+func sortByConfidence(boxes []onnx.BoundingBox) {
+	sort.SliceStable(boxes, func(i, j int) bool {
+		return boxes[i].Confidence > boxes[j].Confidence
+	})
+}
+
+// outcome is the result of matching a prediction to the ground truth.
+type outcome int
+
+const (
+	falsePositive outcome = iota
+	truePositive
+	// ignored predictions lie on a crowd region and count neither way.
+	ignored
+)
+
+// match returns the outcome of each prediction, sorted by decreasing confidence, at the IoU
+// threshold. Each prediction takes the unmatched ground truth box it overlaps most, if any
+// reaches the threshold, and is otherwise ignored when it lies on a crowd region.
+func match(predictions, groundTruth, crowd []onnx.BoundingBox, threshold float64) []outcome {
+	outcomes := make([]outcome, len(predictions))
+	matched := make([]bool, len(groundTruth))
+	for i := range predictions {
+		best, bestIoU := -1, math.Min(threshold, 1-1e-10)
+		for g := range groundTruth {
+			if matched[g] {
+				continue
+			}
+			if iou := float64(predictions[i].IoU(&groundTruth[g])); iou >= bestIoU {
+				best, bestIoU = g, iou
+			}
+		}
+		switch {
+		case best >= 0:
+			matched[best] = true
+			outcomes[i] = truePositive
+		case onCrowd(&predictions[i], crowd, threshold):
+			outcomes[i] = ignored
+		}
+	}
+	return outcomes
+}
+
+// onCrowd reports whether the prediction lies on one of the crowd regions, as measured by COCO:
+// the share of the prediction inside the region reaches the IoU threshold.
+func onCrowd(prediction *onnx.BoundingBox, crowd []onnx.BoundingBox, threshold float64) bool {
+	_, _, w, h := prediction.XYWH()
+	area := math.Abs(float64(w * h))
+	if area == 0 {
+		return false
+	}
+	for g := range crowd {
+		if float64(prediction.Intersection(&crowd[g]))/area >= math.Min(threshold, 1-1e-10) {
+			return true
+		}
+	}
+	return false
+}
+
+// averagePrecision returns the 101-point interpolated average precision of the matched
+// predictions of a class with groundTruth boxes, and the interpolated precision curve.
+func averagePrecision(records []scored, groundTruth int) (float64, []float64) {
+	sorted := slices.Clone(records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].confidence > sorted[j].confidence
+	})
+
+	precision := make([]float64, len(sorted))
+	recall := make([]float64, len(sorted))
+	tp, fp := 0, 0
+	for i, r := range sorted {
+		if r.tp {
+			tp++
+		} else {
+			fp++
+		}
+		precision[i] = float64(tp) / float64(tp+fp)
+		recall[i] = float64(tp) / float64(groundTruth)
+	}
+	// Interpolate: the precision at a recall is the best precision at any higher recall.
+	for i := len(precision) - 1; i > 0; i-- {
+		precision[i-1] = math.Max(precision[i-1], precision[i])
+	}
+
+	curve := make([]float64, RecallPoints)
+	sum := 0.0
+	for k := range curve {
+		r := float64(k) / float64(RecallPoints-1)
+		if i := sort.SearchFloat64s(recall, r); i < len(recall) {
+			curve[k] = precision[i]
+		}
+		sum += curve[k]
+	}
+	return sum / RecallPoints, curve
+}
+
+// ratio returns a / b, or 0 when b is 0.
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
@@ -0,0 +1,194 @@
+package evaluate_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/evaluate"
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+func box(label string, confidence, x1, y1, x2, y2 float32) onnx.BoundingBox {
+	return onnx.BoundingBox{Label: label, Confidence: confidence, X1: x1, Y1: y1, X2: x2, Y2: y2}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestEvaluate_Perfect(t *testing.T) {
+	report := evaluate.Evaluate([]evaluate.Image{{
+		Predictions: []onnx.BoundingBox{box("cat", 0.9, 0, 0, 10, 10)},
+		GroundTruth: []onnx.BoundingBox{box("cat", 1, 0, 0, 10, 10)},
+	}}, evaluate.Options{})
+
+	if report.MAP != 1 || report.MAP50 != 1 || report.MAP75 != 1 {
+		t.Errorf("Expected perfect mAP, got %+v", report)
+	}
+	if report.TP != 1 || report.FP != 0 || report.FN != 0 || report.Precision != 1 || report.Recall != 1 {
+		t.Errorf("Unexpected counts %+v", report)
+	}
+	if len(report.Classes) != 1 || report.Classes[0].Label != "cat" {
+		t.Fatalf("Unexpected classes %+v", report.Classes)
+	}
+	for k, p := range report.Classes[0].PrecisionCurve {
+		if p != 1 {
+			t.Fatalf("Expected precision 1 at recall point %d, got %v", k, p)
+		}
+	}
+}
+
+func TestEvaluate_InterpolatedPrecision(t *testing.T) {
+	// Sorted by confidence the predictions are TP, FP, TP: recall 0.5, 0.5, 1 and
+	// precision 1, 0.5, 2/3, interpolated to 1, 2/3, 2/3. The 51 recall points up to 0.5
+	// have precision 1 and the 50 above have 2/3.
+	images := []evaluate.Image{
+		{
+			Predictions: []onnx.BoundingBox{box("cat", 0.9, 0, 0, 10, 10), box("cat", 0.8, 50, 50, 60, 60)},
+			GroundTruth: []onnx.BoundingBox{box("cat", 1, 0, 0, 10, 10)},
+		},
+		{
+			Predictions: []onnx.BoundingBox{box("cat", 0.7, 20, 20, 40, 40)},
+			GroundTruth: []onnx.BoundingBox{box("cat", 1, 20, 20, 40, 40)},
+		},
+	}
+	report := evaluate.Evaluate(images, evaluate.Options{})
+
+	want := (51 + 50*2.0/3) / 101
+	if !near(report.MAP50, want) || !near(report.MAP, want) {
+		t.Errorf("Expected mAP %v, got %v and mAP@.5 %v", want, report.MAP, report.MAP50)
+	}
+	class := report.Classes[0]
+	if class.TP != 2 || class.FP != 1 || class.FN != 0 || class.GroundTruth != 2 || class.Predictions != 3 {
+		t.Errorf("Unexpected counts %+v", class)
+	}
+	if !near(class.Precision, 2.0/3) || class.Recall != 1 {
+		t.Errorf("Unexpected precision %v and recall %v", class.Precision, class.Recall)
+	}
+	if class.PrecisionCurve[50] != 1 || !near(class.PrecisionCurve[51], 2.0/3) {
+		t.Errorf("Unexpected precision curve %v", class.PrecisionCurve)
+	}
+}
+
+func TestEvaluate_IoUThresholds(t *testing.T) {
+	// The prediction covers 78% of the ground truth box: a true positive at the 6 IoU
+	// thresholds from 0.5 to 0.75 and a false positive at the 4 above.
+	report := evaluate.Evaluate([]evaluate.Image{{
+		Predictions: []onnx.BoundingBox{box("cat", 0.9, 0, 0, 10, 7.8)},
+		GroundTruth: []onnx.BoundingBox{box("cat", 1, 0, 0, 10, 10)},
+	}}, evaluate.Options{})
+
+	if !near(report.MAP, 0.6) || report.MAP50 != 1 || report.MAP75 != 1 {
+		t.Errorf("Expected mAP 0.6, mAP@.5 1 and mAP@.75 1, got %+v", report)
+	}
+}
+
+func TestEvaluate_Classes(t *testing.T) {
+	images := []evaluate.Image{{
+		Predictions: []onnx.BoundingBox{
+			box("cat", 0.9, 0, 0, 10, 10),
+			// A dog is predicted on the cat: a false positive of dog.
+			box("dog", 0.8, 0, 0, 10, 10),
+			// No bird is annotated, so birds are not evaluated.
+			box("bird", 0.7, 30, 30, 40, 40),
+		},
+		GroundTruth: []onnx.BoundingBox{box("cat", 1, 0, 0, 10, 10), box("dog", 1, 50, 50, 70, 70)},
+	}}
+
+	report := evaluate.Evaluate(images, evaluate.Options{})
+	if len(report.Classes) != 2 || report.Classes[0].Label != "cat" || report.Classes[1].Label != "dog" {
+		t.Fatalf("Expected cat and dog classes, got %+v", report.Classes)
+	}
+	if report.Classes[0].AP != 1 || report.Classes[1].AP != 0 || report.MAP != 0.5 {
+		t.Errorf("Expected AP 1 and 0 averaging to 0.5, got %+v", report)
+	}
+	if report.TP != 1 || report.FP != 1 || report.FN != 1 || report.Precision != 0.5 || report.Recall != 0.5 {
+		t.Errorf("Unexpected counts %+v", report)
+	}
+
+	// Listed classes without ground truth are reported but left out of the mean.
+	report = evaluate.Evaluate(images, evaluate.Options{Classes: []string{"bird", "cat", "dog"}})
+	bird := report.Classes[0]
+	if bird.Label != "bird" || bird.GroundTruth != 0 || bird.FP != 1 || bird.AP != 0 || report.MAP != 0.5 {
+		t.Errorf("Unexpected bird report %+v in %+v", bird, report)
+	}
+}
+
+func TestEvaluate_ConfidenceThreshold(t *testing.T) {
+	images := []evaluate.Image{{
+		Predictions: []onnx.BoundingBox{box("cat", 0.9, 0, 0, 10, 10), box("cat", 0.3, 20, 20, 30, 30)},
+		GroundTruth: []onnx.BoundingBox{box("cat", 1, 0, 0, 10, 10), box("cat", 1, 20, 20, 30, 30)},
+	}}
+	report := evaluate.Evaluate(images, evaluate.Options{ConfidenceThreshold: 0.5})
+	if report.TP != 1 || report.FP != 0 || report.FN != 1 || report.Recall != 0.5 {
+		t.Errorf("Expected the low confidence match to be left out of the counts, got %+v", report)
+	}
+	if report.MAP != 1 {
+		t.Errorf("Expected AP to use every prediction, got %v", report.MAP)
+	}
+}
+
+func TestEvaluate_Matching(t *testing.T) {
+	// The most confident prediction takes the ground truth box it overlaps most, leaving
+	// the other one to the second prediction. A duplicate is a false positive.
+	images := []evaluate.Image{{
+		Predictions: []onnx.BoundingBox{
+			box("cat", 0.9, 0, 0, 10, 10),
+			box("cat", 0.8, 2, 0, 12, 10),
+			box("cat", 0.7, 0, 0, 10, 10),
+		},
+		GroundTruth: []onnx.BoundingBox{box("cat", 1, 2, 0, 12, 10), box("cat", 1, 0, 0, 10, 10)},
+	}}
+	report := evaluate.Evaluate(images, evaluate.Options{})
+	if report.TP != 2 || report.FP != 1 || report.MAP != 1 {
+		t.Errorf("Expected 2 exact matches and a duplicate, got %+v", report)
+	}
+
+	report = evaluate.Evaluate(images, evaluate.Options{MaxDetections: 1})
+	if report.TP != 1 || report.FP != 0 || report.FN != 1 {
+		t.Errorf("Expected one prediction kept, got %+v", report)
+	}
+}
+
+func TestEvaluate_MaxDetectionsPerImage(t *testing.T) {
+	// The cap applies to the predictions of an image over all classes: the cat is dropped
+	// as the least confident of the three.
+	images := []evaluate.Image{{
+		Predictions: []onnx.BoundingBox{
+			box("cat", 0.5, 0, 0, 10, 10),
+			box("dog", 0.9, 20, 0, 30, 10),
+			box("dog", 0.8, 40, 0, 50, 10),
+		},
+		GroundTruth: []onnx.BoundingBox{box("cat", 1, 0, 0, 10, 10), box("dog", 1, 20, 0, 30, 10), box("dog", 1, 40, 0, 50, 10)},
+	}}
+	report := evaluate.Evaluate(images, evaluate.Options{MaxDetections: 2})
+	if report.TP != 2 || report.FN != 1 || report.Classes[0].Predictions != 0 || report.Classes[1].Predictions != 2 {
+		t.Errorf("Expected the two dogs kept, got %+v", report)
+	}
+}
+
+func TestEvaluate_Crowd(t *testing.T) {
+	// The second prediction matches no ground truth box but lies within the crowd region,
+	// so it is ignored instead of being a false positive. The crowd need not be detected.
+	images := []evaluate.Image{{
+		Predictions: []onnx.BoundingBox{
+			box("person", 0.9, 0, 0, 10, 10),
+			box("person", 0.8, 50, 50, 60, 60),
+			box("person", 0.7, 200, 200, 210, 210),
+		},
+		GroundTruth: []onnx.BoundingBox{box("person", 1, 0, 0, 10, 10)},
+		Crowd:       []onnx.BoundingBox{box("person", 1, 40, 40, 100, 100)},
+	}}
+	report := evaluate.Evaluate(images, evaluate.Options{})
+	if report.TP != 1 || report.FP != 1 || report.FN != 0 {
+		t.Errorf("Expected the prediction on the crowd to be ignored, got %+v", report)
+	}
+
+	m, err := evaluate.Confusion(images, evaluate.Options{})
+	if err != nil {
+		t.Fatalf("Confusion returned error: %v", err)
+	}
+	if got := m.Count("person", evaluate.Background); got != 1 {
+		t.Errorf("Expected one background prediction, got %d", got)
+	}
+}
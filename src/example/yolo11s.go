@@ -1,67 +1,30 @@
 // Package example implements the YOLOv11s neural network for object detection.
+// It is a preset of detector.Detector for the YOLOv11s model trained on COCO, described by yolo11s.json.
+// The class labels are read from the metadata of the Ultralytics export.
 package example
 
 import (
 	"fmt"
-	"image"
 	"os"
 	"path/filepath"
 	"runtime"
 
-	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+	"github.com/deadelus/go-clean-onnxruntime/src/detector"
 )
 
 var (
-	// relPathToModel is the relative path to the YOLOv11s ONNX model file.
-	relPathToModel = "src/example/yolo11s.onnx"
-	// modelHeight and modelWidth are the dimensions to which input images are resized.
-	// The YOLOv11s model expects input images to be 640x640 pixels.
-	// https://docs.ultralytics.com/fr/tasks/detect/
-	modelHeight = 640
-	modelWidth  = 640
-	// The model processes one image at a time
+	// relPathToManifest is the relative path to the manifest describing the YOLOv11s model,
+	// its input and output tensors, class labels and thresholds.
+	relPathToManifest = "src/example/yolo11s.json"
+	// The model processes one image at a time by default
+	// Use NewBatchNeuralNetwork with a model exported with a dynamic or larger batch to go faster
 	batchSize = 1
-	// modelInputChannels is the number of channels in the input image (3 for RGB).
-	modelInputChannels = 3
-	// modelClasses is the number of classes the model can detect.
-	modelClasses = 80
-	// modelDetections is the number of detections the model can output.
-	modelDetections = 8400
-	// thresholdConfidence is the minimum confidence threshold for detections.
-	thresholdConfidence = 0.5
 )
 
-// Array of YOLOv8 class labels
-// This is the list of classes that the YOLOv8 model can detect.
-var yoloClasses = []string{
-	"person", "bicycle", "car", "motorcycle", "airplane", "bus", "train", "truck",
-	"boat", "traffic light", "fire hydrant", "stop sign", "parking meter",
-	"bench", "bird", "cat", "dog", "horse", "sheep", "cow", "elephant",
-	"bear", "zebra", "giraffe", "backpack", "umbrella", "handbag",
-	"tie", "suitcase", "frisbee", "skis", "snowboard",
-	"sports ball", "kite", "baseball bat", "baseball glove",
-	"skateboard", "surfboard", "tennis racket",
-	"bottle", "wine glass", "cup",
-	"fork", "knife", "spoon",
-	"bowl",
-	"banana", "apple",
-	"sandwich",
-	"orange", "broccoli", "carrot",
-	"hot dog", "pizza", "donut", "cake",
-	"chair", "couch", "potted plant",
-	"bed", "dining table", "toilet",
-	"tv", "laptop", "mouse",
-	"remote", "keyboard", "cell phone",
-	"microwave", "oven", "toaster",
-	"sink", "refrigerator",
-	"book", "clock", "vase",
-	"scissors", "teddy bear", "hair drier",
-	"toothbrush",
-}
-
 // Yolo11sExample represents the YOLOv11s neural implementation.
+// AnalyzeImage, AnalyzeImages and their context variants come from the embedded Detector.
 type Yolo11sExample struct {
-	Session *onnx.ONNXSession
+	*detector.Detector
 }
 
 // getOnnxLibrary returns the path to the shared library based on the current OS and architecture.
@@ -103,75 +66,36 @@ func getOnnxLibrary() string {
 
 // NewNeuralNetwork initializes the ONNX runtime for YOLOv11s model.
 func NewNeuralNetwork() (*Yolo11sExample, error) {
+	return NewBatchNeuralNetwork(batchSize)
+}
+
+// NewBatchNeuralNetwork initializes the ONNX runtime for YOLOv11s model with the given batch size.
+// The model file must accept that batch size, e.g. when exported with dynamic axes.
+func NewBatchNeuralNetwork(batchSize int) (*Yolo11sExample, error) {
+	if batchSize < 1 {
+		return nil, fmt.Errorf("invalid batch size %d, must be at least 1", batchSize)
+	}
+
 	// Use current working directory as base
 	cwd, err := os.Getwd()
 	if err != nil {
 		panic("Unable to determine working directory: " + err.Error())
 	}
-	modelPath := filepath.Join(cwd, relPathToModel)
+	manifestPath := filepath.Join(cwd, relPathToManifest)
 
+	manifest, err := detector.LoadManifest(manifestPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get model file path: %w", err)
+		return nil, fmt.Errorf("failed to load YOLOv11s manifest: %w", err)
 	}
-
-	onnxRuntime := onnx.NewOnnxRuntime(
-		modelPath,
-		getOnnxLibrary(),
-		onnx.TensorInputShape{
-			BatchSize: int64(batchSize),
-			Channels:  int64(modelInputChannels),
-			Height:    int64(modelHeight),
-			Width:     int64(modelWidth),
-		},
-		onnx.TensorOutputShape{
-			BatchSize:  int64(batchSize),
-			Classes:    int64(modelClasses + 4), // 4 for bounding box coordinates
-			Detections: int64(modelDetections),
-		},
-	)
-	if onnxRuntime == nil {
-		return nil, fmt.Errorf("failed to initialize ONNX runtime for YOLOv11s model at %s", modelPath)
+	if err := manifest.VerifyModel(); err != nil {
+		return nil, fmt.Errorf("failed to verify YOLOv11s model: %w", err)
 	}
 
-	// Create a new ONNX session
-	session, err := onnx.NewONNXSession(onnxRuntime)
+	options := manifest.Options(getOnnxLibrary())
+	options.BatchSize = batchSize
+	d, err := detector.New(options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create ONNX session for YOLOv11s model at %s: %w", modelPath, err)
+		return nil, fmt.Errorf("failed to create detector for YOLOv11s model at %s: %w", options.ModelPath, err)
 	}
-	return &Yolo11sExample{
-		Session: session,
-	}, nil
-}
-
-// AnalyzeImage implements the AI interface for Yolo11sExample.
-func (m *Yolo11sExample) AnalyzeImage(image image.Image) ([]onnx.BoundingBox, error) {
-	processor := &onnx.Processor{
-		Image:               image,
-		ModelClasses:        yoloClasses,
-		ModelHeight:         uint(modelHeight),
-		ModelWidth:          uint(modelWidth),
-		ModelInputChannels:  uint(modelInputChannels),
-		ModelOutputClasses:  uint(modelClasses),
-		ModelDetections:     uint(modelDetections),
-		ThresholdConfidence: float32(thresholdConfidence),
-	}
-
-	err := processor.Input(m.Session.TensorInput)
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to process input: %w", err)
-	}
-
-	err = m.Session.Session.Run()
-	if err != nil {
-		return nil, fmt.Errorf("failed to run session: %w", err)
-	}
-
-	boxes := processor.Output(m.Session.TensorOutput)
-
-	if boxes == nil {
-		return nil, fmt.Errorf("no bounding boxes detected")
-	}
-
-	return boxes, nil
+	return &Yolo11sExample{Detector: d}, nil
 }
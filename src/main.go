@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"image"
 	"image/jpeg"
@@ -28,13 +29,18 @@ func main() {
 		return
 	}
 
-	result, err := onnxExample.AnalyzeImage(img)
+	result, err := onnxExample.Analyze(img)
 	if err != nil {
 		fmt.Printf("Error analyzing image: %v\n", err)
 		return
 	}
 
-	fmt.Printf("Analysis result: %v\n", result)
+	report, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fmt.Printf("Error encoding result: %v\n", err)
+		return
+	}
+	fmt.Printf("Analysis result: %s\n", report)
 }
 
 func loadImageFromPath(path string) (image.Image, error) {
@@ -0,0 +1,73 @@
+package npy
+
+import "math"
+
+// Float16 is an IEEE 754 half precision float, stored as its bits, as in the float16 tensors of
+// models exported with half precision.
+type Float16 uint16
+
+// NewFloat16 returns the half precision float nearest to f, rounding ties to even.
+// Values too large become infinities and values too small become zeros or subnormals.
+func NewFloat16(f float32) Float16 {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exponent := int(bits>>23) & 0xff
+	mantissa := bits & 0x7fffff
+
+	switch {
+	case exponent == 0xff:
+		// Infinity, or NaN keeping a mantissa bit set.
+		if mantissa != 0 {
+			return Float16(sign | 0x7e00)
+		}
+		return Float16(sign | 0x7c00)
+	case exponent-127 > 15:
+		return Float16(sign | 0x7c00)
+	case exponent-127 >= -14:
+		// Normal: round the 23 bit mantissa to 10 bits. A carry into the exponent is correct,
+		// up to the infinity.
+		half := uint32(exponent-127+15)<<10 | mantissa>>13
+		return Float16(uint32(sign) | roundToEven(half, mantissa, 13))
+	case exponent-127 >= -25:
+		// Subnormal: shift the mantissa with its implicit bit.
+		mantissa |= 0x800000
+		shift := uint(-14 - (exponent - 127) + 13)
+		return Float16(uint32(sign) | roundToEven(mantissa>>shift, mantissa, shift))
+	default:
+		return Float16(sign)
+	}
+}
+
+// roundToEven rounds truncated, the value of mantissa shifted right by shift, to nearest even.
+func roundToEven(truncated, mantissa uint32, shift uint) uint32 {
+	remainder := mantissa & (1<<shift - 1)
+	halfway := uint32(1) << (shift - 1)
+	if remainder > halfway || (remainder == halfway && truncated&1 == 1) {
+		truncated++
+	}
+	return truncated
+}
+
+// Float32 returns the value of h as a float32, which represents every half precision float exactly.
+func (h Float16) Float32() float32 {
+	sign := uint32(h&0x8000) << 16
+	exponent := uint32(h>>10) & 0x1f
+	mantissa := uint32(h & 0x3ff)
+
+	switch {
+	case exponent == 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mantissa<<13)
+	case exponent != 0:
+		return math.Float32frombits(sign | (exponent+127-15)<<23 | mantissa<<13)
+	case mantissa == 0:
+		return math.Float32frombits(sign)
+	default:
+		// Subnormal: normalize the mantissa.
+		exponent = 127 - 14
+		for mantissa&0x400 == 0 {
+			mantissa <<= 1
+			exponent--
+		}
+		return math.Float32frombits(sign | exponent<<23 | (mantissa&0x3ff)<<13)
+	}
+}
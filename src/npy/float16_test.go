@@ -0,0 +1,48 @@
+package npy_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/npy"
+)
+
+func TestNewFloat16(t *testing.T) {
+	for _, c := range []struct {
+		f    float32
+		want npy.Float16
+	}{
+		{1, 0x3c00},
+		{-2, 0xc000},
+		{0.1, 0x2e66},
+		{65504, 0x7bff},
+		{65520, 0x7c00}, // rounds up past the largest half
+		{float32(math.Inf(-1)), 0xfc00},
+		{float32(math.Ldexp(1, -24)), 0x0001}, // smallest subnormal
+		{float32(math.Ldexp(1, -25)), 0x0000}, // halfway to it, rounded to even
+		{float32(math.Ldexp(3, -26)), 0x0001},
+		{1e-10, 0},
+		{1 + 1.0/2048, 0x3c00}, // halfway between 1 and the next half, rounded to even
+		{1 + 3.0/2048, 0x3c02},
+	} {
+		if got := npy.NewFloat16(c.f); got != c.want {
+			t.Errorf("NewFloat16(%v) = %#04x, want %#04x", c.f, uint16(got), uint16(c.want))
+		}
+	}
+	if h := npy.NewFloat16(float32(math.NaN())); !math.IsNaN(float64(h.Float32())) {
+		t.Errorf("Expected NaN, got %#04x", uint16(h))
+	}
+}
+
+func TestFloat16_RoundTrip(t *testing.T) {
+	for bits := 0; bits <= math.MaxUint16; bits++ {
+		h := npy.Float16(bits)
+		f := h.Float32()
+		if math.IsNaN(float64(f)) {
+			continue
+		}
+		if back := npy.NewFloat16(f); back != h {
+			t.Fatalf("Float16 %#04x converts to %v and back to %#04x", bits, f, uint16(back))
+		}
+	}
+}
@@ -0,0 +1,463 @@
+// Package npy reads and writes NumPy .npy and .npz files, so tensors can be exchanged with Python:
+// preprocessed inputs and raw outputs dumped from Go can be compared to those of a reference
+// implementation, and the other way around.
+//
+//	a, err := npy.NewArray(output, 1, 84, 8400)
+//	if err != nil {
+//		return err
+//	}
+//	err = npy.SaveFile("output.npy", a)
+//
+// Arrays hold float32, uint8, int64 or float16 values in C order. Fortran ordered files are
+// transposed to C order when read.
+package npy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Element is a type of value an Array can hold.
+type Element interface {
+	float32 | uint8 | int64 | Float16
+}
+
+// DType is the NumPy type of the values of an array.
+type DType string
+
+// The supported DTypes, named as in NumPy.
+const (
+	Float32DType DType = "float32"
+	Uint8DType   DType = "uint8"
+	Int64DType   DType = "int64"
+	Float16DType DType = "float16"
+)
+
+// Array is an n-dimensional array of values in C order, the last axis varying fastest.
+type Array struct {
+	DType DType
+	Shape []int
+	// Data is a []float32, []uint8, []int64 or []Float16 according to DType, with as many
+	// values as the product of Shape.
+	Data any
+}
+
+// NewArray returns an array holding data with the given shape. Without shape, the array is
+// one-dimensional.
+func NewArray[T Element](data []T, shape ...int) (*Array, error) {
+	if len(shape) == 0 {
+		shape = []int{len(data)}
+	}
+	if size := elements(shape); size != len(data) {
+		return nil, fmt.Errorf("shape %v holds %d values, got %d", shape, size, len(data))
+	}
+	return &Array{DType: dtypeOf[T](), Shape: append([]int(nil), shape...), Data: data}, nil
+}
+
+// Values returns the data of a as a []T, or an error when a does not hold T values.
+func Values[T Element](a *Array) ([]T, error) {
+	data, ok := a.Data.([]T)
+	if !ok {
+		return nil, fmt.Errorf("array holds %s values, not %s", a.DType, dtypeOf[T]())
+	}
+	return data, nil
+}
+
+// Len returns the number of values of the array.
+func (a *Array) Len() int {
+	return elements(a.Shape)
+}
+
+// Float32s returns the values of a converted to float32, whatever their type.
+func (a *Array) Float32s() []float32 {
+	switch data := a.Data.(type) {
+	case []float32:
+		return data
+	case []uint8:
+		return convert(data, func(v uint8) float32 { return float32(v) })
+	case []int64:
+		return convert(data, func(v int64) float32 { return float32(v) })
+	case []Float16:
+		return convert(data, Float16.Float32)
+	default:
+		return nil
+	}
+}
+
+// MaxAbsDiff returns the largest absolute difference between the values of two arrays of the
+// same shape, compared as float32. It is meant to check Go tensors against Python references.
+func MaxAbsDiff(a, b *Array) (float64, error) {
+	for _, array := range []*Array{a, b} {
+		if err := array.check(); err != nil {
+			return 0, err
+		}
+	}
+	if !slices.Equal(a.Shape, b.Shape) {
+		return 0, fmt.Errorf("shapes %v and %v differ", a.Shape, b.Shape)
+	}
+	av, bv := a.Float32s(), b.Float32s()
+	diff := 0.0
+	for i := range av {
+		diff = math.Max(diff, math.Abs(float64(av[i])-float64(bv[i])))
+	}
+	return diff, nil
+}
+
+// check returns an error when the data of a is not a slice of its DType values, or does not hold
+// as many values as its shape.
+func (a *Array) check() error {
+	if _, ok := descriptors[a.DType]; !ok {
+		return fmt.Errorf("unsupported dtype %q", a.DType)
+	}
+	if dtype, ok := dtypeOfData(a.Data); !ok || dtype != a.DType {
+		return fmt.Errorf("array of dtype %s holds %T data", a.DType, a.Data)
+	}
+	for _, dim := range a.Shape {
+		if dim < 0 {
+			return fmt.Errorf("invalid shape %v", a.Shape)
+		}
+	}
+	if a.Len() != lenOf(a.Data) {
+		return fmt.Errorf("shape %v holds %d values, got %d", a.Shape, a.Len(), lenOf(a.Data))
+	}
+	return nil
+}
+
+// convert applies f to every value of data.
+func convert[T, U any](data []T, f func(T) U) []U {
+	converted := make([]U, len(data))
+	for i, v := range data {
+		converted[i] = f(v)
+	}
+	return converted
+}
+
+// dtypeOf returns the DType of T.
+func dtypeOf[T Element]() DType {
+	var v T
+	switch any(v).(type) {
+	case float32:
+		return Float32DType
+	case uint8:
+		return Uint8DType
+	case int64:
+		return Int64DType
+	default:
+		return Float16DType
+	}
+}
+
+// descriptors are the NumPy type descriptors of each DType, with their size in bytes.
+var descriptors = map[DType]struct {
+	descr string
+	size  int
+}{
+	Float32DType: {"f4", 4},
+	Uint8DType:   {"u1", 1},
+	Int64DType:   {"i8", 8},
+	Float16DType: {"f2", 2},
+}
+
+// magic starts every .npy file.
+const magic = "\x93NUMPY"
+
+// headerAlignment is the alignment of the data, after the header.
+const headerAlignment = 64
+
+// Write writes a to w in the .npy format, version 1.0 unless its header needs version 2.0.
+// Values are written little-endian.
+func Write(w io.Writer, a *Array) error {
+	if err := a.check(); err != nil {
+		return err
+	}
+	d := descriptors[a.DType]
+
+	order := "<"
+	if d.size == 1 {
+		order = "|"
+	}
+	header := fmt.Sprintf("{'descr': '%s%s', 'fortran_order': False, 'shape': %s, }", order, d.descr, shapeTuple(a.Shape))
+
+	// Pad the header with spaces and a newline so the data starts aligned.
+	version, prefix := byte(1), len(magic)+2+2
+	if len(header)+1+prefix > math.MaxUint16 {
+		version, prefix = 2, len(magic)+2+4
+	}
+	padding := headerAlignment - (prefix+len(header)+1)%headerAlignment
+	if padding == headerAlignment {
+		padding = 0
+	}
+	header += strings.Repeat(" ", padding) + "\n"
+
+	buf := bufio.NewWriter(w)
+	buf.WriteString(magic)
+	buf.Write([]byte{version, 0})
+	if version == 1 {
+		binary.Write(buf, binary.LittleEndian, uint16(len(header)))
+	} else {
+		binary.Write(buf, binary.LittleEndian, uint32(len(header)))
+	}
+	buf.WriteString(header)
+	if err := binary.Write(buf, binary.LittleEndian, a.Data); err != nil {
+		return fmt.Errorf("failed to write array data: %w", err)
+	}
+	return buf.Flush()
+}
+
+// shapeTuple formats shape as a Python tuple.
+func shapeTuple(shape []int) string {
+	dims := make([]string, len(shape))
+	for i, dim := range shape {
+		dims[i] = strconv.Itoa(dim)
+	}
+	if len(shape) == 1 {
+		return "(" + dims[0] + ",)"
+	}
+	return "(" + strings.Join(dims, ", ") + ")"
+}
+
+// maxHeaderLen and maxDataLen bound the header and the data read from a file, so a corrupted
+// or hostile header cannot make Read allocate unbounded memory. NumPy itself refuses headers
+// longer than 10000 bytes by default.
+const (
+	maxHeaderLen = 1 << 16
+	maxDataLen   = 1 << 30
+)
+
+var (
+	descrPattern = regexp.MustCompile(`['"]descr['"]\s*:\s*['"]([<>|=])?([a-z]\d+)['"]`)
+	orderPattern = regexp.MustCompile(`['"]fortran_order['"]\s*:\s*(True|False)`)
+	shapePattern = regexp.MustCompile(`['"]shape['"]\s*:\s*\(([^)]*)\)`)
+)
+
+// Read reads an array in the .npy format, of version 1.0, 2.0 or 3.0.
+func Read(r io.Reader) (*Array, error) {
+	prefix := make([]byte, len(magic)+2)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, fmt.Errorf("failed to read npy header: %w", err)
+	}
+	if string(prefix[:len(magic)]) != magic {
+		return nil, errors.New("not a npy file")
+	}
+
+	var headerLen int
+	switch version := prefix[len(magic)]; version {
+	case 1:
+		var n uint16
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("failed to read npy header: %w", err)
+		}
+		headerLen = int(n)
+	case 2, 3:
+		var n uint32
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("failed to read npy header: %w", err)
+		}
+		headerLen = int(n)
+	default:
+		return nil, fmt.Errorf("unsupported npy version %d", version)
+	}
+	if headerLen > maxHeaderLen {
+		return nil, fmt.Errorf("npy header of %d bytes exceeds the limit of %d", headerLen, maxHeaderLen)
+	}
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("failed to read npy header: %w", err)
+	}
+
+	dtype, order, fortran, shape, err := parseHeader(string(header))
+	if err != nil {
+		return nil, err
+	}
+	size, err := dataLen(shape, descriptors[dtype].size)
+	if err != nil {
+		return nil, err
+	}
+	a := &Array{DType: dtype, Shape: shape}
+	raw := make([]byte, size)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return nil, fmt.Errorf("failed to read array data: %w", err)
+	}
+
+	switch dtype {
+	case Float32DType:
+		a.Data, err = decode[float32](raw, order, shape, fortran)
+	case Uint8DType:
+		a.Data, err = decode[uint8](raw, order, shape, fortran)
+	case Int64DType:
+		a.Data, err = decode[int64](raw, order, shape, fortran)
+	case Float16DType:
+		a.Data, err = decode[Float16](raw, order, shape, fortran)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// parseHeader parses the dictionary of a .npy header.
+func parseHeader(header string) (DType, binary.ByteOrder, bool, []int, error) {
+	descr := descrPattern.FindStringSubmatch(header)
+	fortran := orderPattern.FindStringSubmatch(header)
+	shapeMatch := shapePattern.FindStringSubmatch(header)
+	if descr == nil || fortran == nil || shapeMatch == nil {
+		return "", nil, false, nil, fmt.Errorf("invalid npy header %q", strings.TrimSpace(header))
+	}
+
+	var dtype DType
+	for t, d := range descriptors {
+		if d.descr == descr[2] {
+			dtype = t
+		}
+	}
+	if dtype == "" {
+		return "", nil, false, nil, fmt.Errorf("unsupported dtype %q, expected float32, uint8, int64 or float16", descr[1]+descr[2])
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if descr[1] == ">" {
+		order = binary.BigEndian
+	}
+
+	shape := []int{}
+	for _, field := range strings.Split(shapeMatch[1], ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		dim, err := strconv.Atoi(strings.TrimSuffix(field, "L"))
+		if err != nil || dim < 0 {
+			return "", nil, false, nil, fmt.Errorf("invalid npy shape (%s)", shapeMatch[1])
+		}
+		shape = append(shape, dim)
+	}
+	return dtype, order, fortran[1] == "True", shape, nil
+}
+
+// dataLen returns the number of bytes of an array of the given shape and item size, or an error
+// when it exceeds maxDataLen. Each dimension is checked before multiplying, so huge shapes
+// cannot overflow to a small size.
+func dataLen(shape []int, itemSize int) (int, error) {
+	size := itemSize
+	for _, dim := range shape {
+		if dim > 0 && size > maxDataLen/dim {
+			return 0, fmt.Errorf("npy shape %v exceeds the limit of %d bytes", shape, maxDataLen)
+		}
+		size *= dim
+	}
+	return size, nil
+}
+
+// decode decodes the values of raw in the given byte order, transposing Fortran ordered values
+// to C order.
+func decode[T Element](raw []byte, order binary.ByteOrder, shape []int, fortran bool) ([]T, error) {
+	data := make([]T, elements(shape))
+	if err := binary.Read(bytes.NewReader(raw), order, data); err != nil {
+		return nil, fmt.Errorf("failed to decode array data: %w", err)
+	}
+	if fortran && len(shape) > 1 {
+		data = fortranToC(data, shape)
+	}
+	return data, nil
+}
+
+// fortranToC reorders values from Fortran order, the first axis varying fastest, to C order.
+func fortranToC[T any](data []T, shape []int) []T {
+	reordered := make([]T, len(data))
+	index := make([]int, len(shape))
+	for i := range reordered {
+		// index is the position of value i in C order; find its offset in Fortran order.
+		offset, stride := 0, 1
+		for axis := range shape {
+			offset += index[axis] * stride
+			stride *= shape[axis]
+		}
+		reordered[i] = data[offset]
+		for axis := len(shape) - 1; axis >= 0; axis-- {
+			index[axis]++
+			if index[axis] < shape[axis] {
+				break
+			}
+			index[axis] = 0
+		}
+	}
+	return reordered
+}
+
+// elements returns the number of values of an array of the given shape.
+func elements(shape []int) int {
+	n := 1
+	for _, dim := range shape {
+		n *= dim
+	}
+	return n
+}
+
+// dtypeOfData returns the DType of a slice of values, or false for unsupported data.
+func dtypeOfData(data any) (DType, bool) {
+	switch data.(type) {
+	case []float32:
+		return Float32DType, true
+	case []uint8:
+		return Uint8DType, true
+	case []int64:
+		return Int64DType, true
+	case []Float16:
+		return Float16DType, true
+	default:
+		return "", false
+	}
+}
+
+// lenOf returns the length of a slice of values, or -1 for unsupported data.
+func lenOf(data any) int {
+	switch data := data.(type) {
+	case []float32:
+		return len(data)
+	case []uint8:
+		return len(data)
+	case []int64:
+		return len(data)
+	case []Float16:
+		return len(data)
+	default:
+		return -1
+	}
+}
+
+// SaveFile writes a to the .npy file at path.
+func SaveFile(path string, a *Array) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create npy file: %w", err)
+	}
+	if err := Write(file, a); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// LoadFile reads the .npy file at path.
+func LoadFile(path string) (*Array, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open npy file: %w", err)
+	}
+	defer file.Close()
+
+	a, err := Read(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return a, nil
+}
@@ -0,0 +1,184 @@
+package npy_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/npy"
+)
+
+// npyFile returns a .npy version 1.0 file with the given header dictionary, padded as NumPy does.
+func npyFile(header string, data any, order binary.ByteOrder) []byte {
+	header += strings.Repeat(" ", 64-(10+len(header)+1)%64) + "\n"
+	var buf bytes.Buffer
+	buf.WriteString("\x93NUMPY\x01\x00")
+	binary.Write(&buf, binary.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+	binary.Write(&buf, order, data)
+	return buf.Bytes()
+}
+
+func TestWrite_MatchesNumPy(t *testing.T) {
+	a, err := npy.NewArray([]float32{1, 2, 3, 4, 5, 6}, 2, 3)
+	if err != nil {
+		t.Fatalf("NewArray returned error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := npy.Write(&buf, a); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	// The file numpy.save writes for np.arange(1, 7, dtype=np.float32).reshape(2, 3).
+	want := npyFile("{'descr': '<f4', 'fortran_order': False, 'shape': (2, 3), }", []float32{1, 2, 3, 4, 5, 6}, binary.LittleEndian)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("Write wrote\n%q\nwant\n%q", buf.Bytes(), want)
+	}
+	if (buf.Len()-6*4)%64 != 0 {
+		t.Errorf("Expected the data to start on a 64 bytes boundary, header is %d bytes", buf.Len()-6*4)
+	}
+}
+
+func TestWrite_RoundTrip(t *testing.T) {
+	for _, a := range []*npy.Array{
+		mustArray(t, []float32{0.5, -1, 3.25}),
+		mustArray(t, []uint8{0, 128, 255, 7}, 2, 2),
+		mustArray(t, []int64{-1 << 40, 0, 1 << 40, 5, 6, 7}, 1, 2, 3),
+		mustArray(t, []npy.Float16{npy.NewFloat16(1), npy.NewFloat16(-0.5)}, 2, 1),
+		mustArray(t, []float32{42}, []int{}...),
+	} {
+		var buf bytes.Buffer
+		if err := npy.Write(&buf, a); err != nil {
+			t.Fatalf("Write returned error: %v", err)
+		}
+		b, err := npy.Read(&buf)
+		if err != nil {
+			t.Fatalf("Read returned error for %s: %v", a.DType, err)
+		}
+		if b.DType != a.DType || !slices.Equal(b.Shape, a.Shape) {
+			t.Errorf("Expected %s %v, got %s %v", a.DType, a.Shape, b.DType, b.Shape)
+		}
+		if diff, err := npy.MaxAbsDiff(a, b); err != nil || diff != 0 {
+			t.Errorf("Expected the same %s values, got a difference of %v, %v", a.DType, diff, err)
+		}
+	}
+}
+
+func mustArray[T npy.Element](t *testing.T, data []T, shape ...int) *npy.Array {
+	t.Helper()
+	a, err := npy.NewArray(data, shape...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestRead_FortranOrder(t *testing.T) {
+	// np.asfortranarray of [[1, 2, 3], [4, 5, 6]] stores the columns one after the other.
+	data := npyFile("{'descr': '<f4', 'fortran_order': True, 'shape': (2, 3), }", []float32{1, 4, 2, 5, 3, 6}, binary.LittleEndian)
+	a, err := npy.Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	values, err := npy.Values[float32](a)
+	if err != nil {
+		t.Fatalf("Values returned error: %v", err)
+	}
+	if !slices.Equal(values, []float32{1, 2, 3, 4, 5, 6}) || !slices.Equal(a.Shape, []int{2, 3}) {
+		t.Errorf("Expected the values in C order, got %v %v", values, a.Shape)
+	}
+
+	// A 2x2x2 array: the first axis varies fastest in Fortran order.
+	data = npyFile("{'descr': '|u1', 'fortran_order': True, 'shape': (2, 2, 2), }", []uint8{0, 4, 2, 6, 1, 5, 3, 7}, binary.LittleEndian)
+	a, err = npy.Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	if values, _ := npy.Values[uint8](a); !slices.Equal(values, []uint8{0, 1, 2, 3, 4, 5, 6, 7}) {
+		t.Errorf("Expected 0 to 7 in C order, got %v", values)
+	}
+}
+
+func TestRead_BigEndian(t *testing.T) {
+	data := npyFile("{'descr': '>i8', 'fortran_order': False, 'shape': (3,), }", []int64{1, -2, 1 << 50}, binary.BigEndian)
+	a, err := npy.Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	if values, _ := npy.Values[int64](a); !slices.Equal(values, []int64{1, -2, 1 << 50}) {
+		t.Errorf("Unexpected values %v", values)
+	}
+	if _, err := npy.Values[float32](a); err == nil {
+		t.Error("Expected an error reading int64 values as float32")
+	}
+}
+
+func TestRead_Invalid(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"magic":     []byte("PK\x03\x04 not npy"),
+		"dtype":     npyFile("{'descr': '<c16', 'fortran_order': False, 'shape': (1,), }", []float64{0, 0}, binary.LittleEndian),
+		"header":    npyFile("{'descr': '<f4'}", []float32{1}, binary.LittleEndian),
+		"truncated": npyFile("{'descr': '<f4', 'fortran_order': False, 'shape': (4,), }", []float32{1}, binary.LittleEndian),
+		// Shapes whose byte count overflows, to a negative or a zero size.
+		"huge shape":        npyFile("{'descr': '<i8', 'fortran_order': False, 'shape': (1729382256910270464,), }", []int64{1}, binary.LittleEndian),
+		"overflowing shape": npyFile("{'descr': '<f4', 'fortran_order': False, 'shape': (4611686018427387904, 4), }", []float32{1}, binary.LittleEndian),
+		"long header":       []byte("\x93NUMPY\x02\x00\x00\x00\x00\x80"),
+		"large shape":       npyFile("{'descr': '<f4', 'fortran_order': False, 'shape': (65536, 65536), }", []float32{1}, binary.LittleEndian),
+	} {
+		if _, err := npy.Read(bytes.NewReader(data)); err == nil {
+			t.Errorf("Expected an error for an invalid %s", name)
+		}
+	}
+}
+
+func TestNewArray_ShapeMismatch(t *testing.T) {
+	if _, err := npy.NewArray([]float32{1, 2, 3}, 2, 2); err == nil {
+		t.Error("Expected an error for 3 values in a 2x2 array")
+	}
+}
+
+func TestMaxAbsDiff(t *testing.T) {
+	a := mustArray(t, []float32{1, 2, 3})
+	b := mustArray(t, []npy.Float16{npy.NewFloat16(1), npy.NewFloat16(2.5), npy.NewFloat16(3)})
+	if diff, err := npy.MaxAbsDiff(a, b); err != nil || diff != 0.5 {
+		t.Errorf("Expected a difference of 0.5, got %v, %v", diff, err)
+	}
+	if _, err := npy.MaxAbsDiff(a, mustArray(t, []float32{1, 2, 3}, 3, 1)); err == nil {
+		t.Error("Expected an error for different shapes")
+	}
+	short := &npy.Array{DType: npy.Float32DType, Shape: []int{3}, Data: []float32{1, 2}}
+	if _, err := npy.MaxAbsDiff(a, short); err == nil {
+		t.Error("Expected an error for data shorter than the shape")
+	}
+}
+
+func TestWrite_Inconsistent(t *testing.T) {
+	for name, a := range map[string]*npy.Array{
+		"data of another dtype": {DType: npy.Float32DType, Shape: []int{2}, Data: []int64{1, 2}},
+		"unsupported data":      {DType: npy.Float32DType, Shape: []int{2}, Data: []float64{1, 2}},
+		"short data":            {DType: npy.Uint8DType, Shape: []int{2, 2}, Data: []uint8{1, 2}},
+		"unsupported dtype":     {DType: "float64", Shape: []int{1}, Data: []float32{1}},
+	} {
+		if err := npy.Write(io.Discard, a); err == nil {
+			t.Errorf("Expected an error for %s", name)
+		}
+	}
+}
+
+func TestSaveFile_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output.npy")
+	a := mustArray(t, []float32{1, 2, 3, 4}, 1, 4)
+	if err := npy.SaveFile(path, a); err != nil {
+		t.Fatalf("SaveFile returned error: %v", err)
+	}
+	b, err := npy.LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile returned error: %v", err)
+	}
+	if values, _ := npy.Values[float32](b); !slices.Equal(values, []float32{1, 2, 3, 4}) {
+		t.Errorf("Unexpected values %v", values)
+	}
+}
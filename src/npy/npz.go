@@ -0,0 +1,89 @@
+package npy
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// WriteNPZ writes arrays to w as an uncompressed .npz archive, like numpy.savez. Each array is
+// stored as <name>.npy, in the order of the names.
+func WriteNPZ(w io.Writer, arrays map[string]*Array) error {
+	archive := zip.NewWriter(w)
+	names := make([]string, 0, len(arrays))
+	for name := range arrays {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		entry, err := archive.CreateHeader(&zip.FileHeader{Name: name + ".npy", Method: zip.Store})
+		if err != nil {
+			return fmt.Errorf("failed to add %s to npz archive: %w", name, err)
+		}
+		if err := Write(entry, arrays[name]); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return archive.Close()
+}
+
+// ReadNPZ reads the arrays of a .npz archive of the given size, stored or compressed as by
+// numpy.savez and numpy.savez_compressed. Arrays are keyed by name, without the .npy extension.
+func ReadNPZ(r io.ReaderAt, size int64) (map[string]*Array, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open npz archive: %w", err)
+	}
+	arrays := make(map[string]*Array, len(archive.File))
+	for _, file := range archive.File {
+		name := strings.TrimSuffix(file.Name, ".npy")
+		entry, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", file.Name, err)
+		}
+		a, err := Read(bufio.NewReader(entry))
+		entry.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+		arrays[name] = a
+	}
+	return arrays, nil
+}
+
+// SaveNPZ writes arrays to the .npz file at path.
+func SaveNPZ(path string, arrays map[string]*Array) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create npz file: %w", err)
+	}
+	if err := WriteNPZ(file, arrays); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// LoadNPZ reads the .npz file at path.
+func LoadNPZ(path string) (map[string]*Array, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open npz file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read npz file: %w", err)
+	}
+	arrays, err := ReadNPZ(file, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return arrays, nil
+}
@@ -0,0 +1,54 @@
+package npy_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/npy"
+)
+
+func TestSaveNPZ_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tensors.npz")
+	arrays := map[string]*npy.Array{
+		"input":  mustArray(t, []float32{0.1, 0.2, 0.3, 0.4}, 1, 1, 2, 2),
+		"output": mustArray(t, []int64{7, 8}),
+	}
+	if err := npy.SaveNPZ(path, arrays); err != nil {
+		t.Fatalf("SaveNPZ returned error: %v", err)
+	}
+	loaded, err := npy.LoadNPZ(path)
+	if err != nil {
+		t.Fatalf("LoadNPZ returned error: %v", err)
+	}
+	if len(loaded) != 2 || !slices.Equal(loaded["input"].Shape, []int{1, 1, 2, 2}) {
+		t.Fatalf("Unexpected arrays %v", loaded)
+	}
+	if values, _ := npy.Values[int64](loaded["output"]); !slices.Equal(values, []int64{7, 8}) {
+		t.Errorf("Unexpected output values %v", values)
+	}
+}
+
+func TestReadNPZ_Compressed(t *testing.T) {
+	// numpy.savez_compressed deflates each array.
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: "scores.npy", Method: zip.Deflate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := npy.Write(entry, mustArray(t, []uint8{1, 2, 3})); err != nil {
+		t.Fatal(err)
+	}
+	archive.Close()
+
+	arrays, err := npy.ReadNPZ(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ReadNPZ returned error: %v", err)
+	}
+	if values, _ := npy.Values[uint8](arrays["scores"]); !slices.Equal(values, []uint8{1, 2, 3}) {
+		t.Errorf("Unexpected scores %v", arrays["scores"])
+	}
+}
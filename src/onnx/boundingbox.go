@@ -3,16 +3,81 @@ package onnx
 import (
 	"fmt"
 	"image"
+	"sort"
 )
 
 // BoundingBox represents a rectangular region in an image, typically used for object detection results.
+// Coordinates are the top left (X1, Y1) and bottom right (X2, Y2) corners, in pixels unless the box
+// was converted with Normalized.
 type BoundingBox struct {
-	Label      string
-	Confidence float32
-	X1         float32
-	Y1         float32
-	X2         float32
-	Y2         float32
+	Label string `json:"label"`
+	// ClassID is the position of Label in the model output or, when Label was remapped by a
+	// ClassRemap, in the remapped label set, so it can collide with the ID of a model class.
+	ClassID    int     `json:"class_id"`
+	Confidence float32 `json:"confidence"`
+	X1         float32 `json:"x1"`
+	Y1         float32 `json:"y1"`
+	X2         float32 `json:"x2"`
+	Y2         float32 `json:"y2"`
+	// Index is the position of the raw detection, or anchor, which produced the box in the model output.
+	Index int `json:"index"`
+	// Scores holds the confidence of every class for the detection, when Processor.KeepScores is set.
+	// It is indexed by model class ID, which is ClassID only when the box was not remapped.
+	Scores []float32 `json:"scores,omitempty"`
+	// Keypoints holds the keypoints of the detected object, for pose models.
+	Keypoints []Keypoint `json:"keypoints,omitempty"`
+	// Mask is the instance mask of the detected object in image coordinates, for segmentation
+	// models. Pixels with a non-zero alpha belong to the object.
+	Mask *image.Alpha `json:"-"`
+}
+
+// Keypoint is a landmark of a detected object, such as a joint of a person, in image coordinates.
+type Keypoint struct {
+	X          float32 `json:"x"`
+	Y          float32 `json:"y"`
+	Confidence float32 `json:"confidence"`
+}
+
+// XYXY returns the corners of the box.
+func (b *BoundingBox) XYXY() (x1, y1, x2, y2 float32) {
+	return b.X1, b.Y1, b.X2, b.Y2
+}
+
+// XYWH returns the top left corner and the size of the box, as in COCO annotations.
+func (b *BoundingBox) XYWH() (x, y, w, h float32) {
+	return b.X1, b.Y1, b.X2 - b.X1, b.Y2 - b.Y1
+}
+
+// CXCYWH returns the center and the size of the box, as in YOLO outputs and labels.
+func (b *BoundingBox) CXCYWH() (cx, cy, w, h float32) {
+	return (b.X1 + b.X2) / 2, (b.Y1 + b.Y2) / 2, b.X2 - b.X1, b.Y2 - b.Y1
+}
+
+// SetXYWH sets the corners of the box from its top left corner and size.
+func (b *BoundingBox) SetXYWH(x, y, w, h float32) {
+	b.X1, b.Y1, b.X2, b.Y2 = x, y, x+w, y+h
+}
+
+// SetCXCYWH sets the corners of the box from its center and size.
+func (b *BoundingBox) SetCXCYWH(cx, cy, w, h float32) {
+	b.X1, b.Y1, b.X2, b.Y2 = cx-w/2, cy-h/2, cx+w/2, cy+h/2
+}
+
+// Normalized returns a copy of the box with coordinates divided by the image size, in [0, 1]
+// for boxes inside the image.
+func (b *BoundingBox) Normalized(width, height int) BoundingBox {
+	n := *b
+	n.X1, n.X2 = b.X1/float32(width), b.X2/float32(width)
+	n.Y1, n.Y2 = b.Y1/float32(height), b.Y2/float32(height)
+	return n
+}
+
+// Absolute returns a copy of a normalized box with coordinates in pixels of a width x height image.
+func (b *BoundingBox) Absolute(width, height int) BoundingBox {
+	a := *b
+	a.X1, a.X2 = b.X1*float32(width), b.X2*float32(width)
+	a.Y1, a.Y2 = b.Y1*float32(height), b.Y2*float32(height)
+	return a
 }
 
 // RectArea returns the area of the bounding box in pixels, after converting to an image.Rectangle.
@@ -22,26 +87,44 @@ func (b *BoundingBox) RectArea() int {
 }
 
 // Intersection returns the intersection area of this bounding box with another bounding box.
-// This is calculated by finding the intersection rectangle and returning its area.
-// If the rectangles do not intersect, this will return 0.
+// It is computed on the float coordinates of the boxes, so normalized boxes overlap as their
+// pixel counterparts do. If the boxes do not intersect, this will return 0.
 func (b *BoundingBox) Intersection(other *BoundingBox) float32 {
-	r1 := b.ToRect()
-	r2 := other.ToRect()
-	intersected := r1.Intersect(r2).Canon().Size()
-	return float32(intersected.X * intersected.Y)
+	x1, y1, x2, y2 := b.canon()
+	ox1, oy1, ox2, oy2 := other.canon()
+	w := min(x2, ox2) - max(x1, ox1)
+	h := min(y2, oy2) - max(y1, oy1)
+	if w <= 0 || h <= 0 {
+		return 0
+	}
+	return w * h
 }
 
 // Union returns the union area of this bounding box with another bounding box.
-// This is calculated by adding the areas of both rectangles and subtracting the intersection area.
+// This is calculated by adding the areas of both boxes and subtracting the intersection area.
 func (b *BoundingBox) Union(other *BoundingBox) float32 {
-	intersectArea := b.Intersection(other)
-	totalArea := float32(b.RectArea() + other.RectArea())
-	return totalArea - intersectArea
+	return b.area() + other.area() - b.Intersection(other)
 }
 
-// IoU returns the Intersection over Union (IoU) of this bounding box with another bounding box.
+// IoU returns the Intersection over Union (IoU) of this bounding box with another bounding box,
+// or 0 when both boxes are empty.
 func (b *BoundingBox) IoU(other *BoundingBox) float32 {
-	return b.Intersection(other) / b.Union(other)
+	union := b.Union(other)
+	if union <= 0 {
+		return 0
+	}
+	return b.Intersection(other) / union
+}
+
+// canon returns the corners of the box ordered so that x1 <= x2 and y1 <= y2.
+func (b *BoundingBox) canon() (x1, y1, x2, y2 float32) {
+	return min(b.X1, b.X2), min(b.Y1, b.Y2), max(b.X1, b.X2), max(b.Y1, b.Y2)
+}
+
+// area returns the area of the box on its float coordinates.
+func (b *BoundingBox) area() float32 {
+	x1, y1, x2, y2 := b.canon()
+	return (x2 - x1) * (y2 - y1)
 }
 
 // ToString returns a string representation of the BoundingBox.
@@ -54,3 +137,29 @@ func (b *BoundingBox) ToString() string {
 func (b *BoundingBox) ToRect() image.Rectangle {
 	return image.Rect(int(b.X1), int(b.Y1), int(b.X2), int(b.Y2)).Canon()
 }
+
+// NMS performs non-maximum suppression on bounding boxes.
+// Boxes are considered from the most to the least confident, and a box is dropped when its
+// IoU with an already kept box exceeds iouThreshold.
+func NMS(boxes []BoundingBox, iouThreshold float32) []BoundingBox {
+	sorted := make([]BoundingBox, len(boxes))
+	copy(sorted, boxes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Confidence > sorted[j].Confidence
+	})
+
+	kept := make([]BoundingBox, 0, len(sorted))
+	for _, candidateBox := range sorted {
+		overlapsExistingBox := false
+		for _, existingBox := range kept {
+			if (&candidateBox).IoU(&existingBox) > iouThreshold {
+				overlapsExistingBox = true
+				break
+			}
+		}
+		if !overlapsExistingBox {
+			kept = append(kept, candidateBox)
+		}
+	}
+	return kept
+}
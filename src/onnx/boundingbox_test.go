@@ -1,7 +1,9 @@
 package onnx_test
 
 import (
+	"encoding/json"
 	"image"
+	"strings"
 	"testing"
 
 	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
@@ -40,6 +42,28 @@ func TestBoundingBox_Intersection_Union_IoU(t *testing.T) {
 	}
 }
 
+func TestBoundingBox_IoU_FloatCoordinates(t *testing.T) {
+	a := onnx.BoundingBox{X1: 0, Y1: 0, X2: 10, Y2: 10}
+	b := onnx.BoundingBox{X1: 5, Y1: 0, X2: 15, Y2: 10}
+	if iou := a.IoU(&b); iou != float32(50.0/150) {
+		t.Errorf("Expected IoU 1/3, got %v", iou)
+	}
+	// Normalized boxes used to truncate to empty rectangles, and divide 0 by 0.
+	na, nb := a.Normalized(20, 10), b.Normalized(20, 10)
+	if iou := na.IoU(&nb); iou != float32(50.0/150) {
+		t.Errorf("Expected IoU 1/3 on normalized boxes, got %v", iou)
+	}
+	c := onnx.BoundingBox{X1: 0.5, Y1: 0.5, X2: 1.5, Y2: 1.5}
+	d := onnx.BoundingBox{X1: 20, Y1: 20, X2: 30, Y2: 30}
+	if iou := c.IoU(&d); iou != 0 {
+		t.Errorf("Expected no overlap, got %v", iou)
+	}
+	empty := onnx.BoundingBox{X1: 1, Y1: 1, X2: 1, Y2: 1}
+	if iou := empty.IoU(&empty); iou != 0 {
+		t.Errorf("Expected IoU 0 for empty boxes, got %v", iou)
+	}
+}
+
 func TestBoundingBox_ToString(t *testing.T) {
 	b := onnx.BoundingBox{Label: "cat", Confidence: 0.9, X1: 1, Y1: 2, X2: 3, Y2: 4}
 	s := b.ToString()
@@ -47,3 +71,68 @@ func TestBoundingBox_ToString(t *testing.T) {
 		t.Errorf("Expected non-empty string from ToString")
 	}
 }
+
+func TestNMS_KeepsMostConfident(t *testing.T) {
+	boxes := []onnx.BoundingBox{
+		{Label: "cat", Confidence: 0.6, X1: 0, Y1: 0, X2: 10, Y2: 10},
+		{Label: "cat", Confidence: 0.9, X1: 1, Y1: 1, X2: 11, Y2: 11},
+		{Label: "cat", Confidence: 0.7, X1: 50, Y1: 50, X2: 60, Y2: 60},
+	}
+	kept := onnx.NMS(boxes, 0.5)
+	if len(kept) != 2 {
+		t.Fatalf("Expected 2 boxes after NMS, got %d", len(kept))
+	}
+	if kept[0].Confidence != 0.9 || kept[1].Confidence != 0.7 {
+		t.Errorf("Expected the most confident boxes to be kept, got %v", kept)
+	}
+	if boxes[0].Confidence != 0.6 {
+		t.Errorf("Expected NMS to leave its input untouched")
+	}
+}
+
+func TestBoundingBox_Conversions(t *testing.T) {
+	b := onnx.BoundingBox{X1: 10, Y1: 20, X2: 50, Y2: 100}
+
+	if cx, cy, w, h := b.CXCYWH(); cx != 30 || cy != 60 || w != 40 || h != 80 {
+		t.Errorf("CXCYWH() = %v, %v, %v, %v", cx, cy, w, h)
+	}
+	if x, y, w, h := b.XYWH(); x != 10 || y != 20 || w != 40 || h != 80 {
+		t.Errorf("XYWH() = %v, %v, %v, %v", x, y, w, h)
+	}
+
+	var c onnx.BoundingBox
+	c.SetCXCYWH(30, 60, 40, 80)
+	if x1, y1, x2, y2 := c.XYXY(); x1 != 10 || y1 != 20 || x2 != 50 || y2 != 100 {
+		t.Errorf("SetCXCYWH gave corners %v, %v, %v, %v", x1, y1, x2, y2)
+	}
+	c.SetXYWH(10, 20, 40, 80)
+	if c.X1 != 10 || c.Y1 != 20 || c.X2 != 50 || c.Y2 != 100 {
+		t.Errorf("SetXYWH gave %+v, want %+v", c, b)
+	}
+
+	n := b.Normalized(100, 200)
+	if n.X1 != 0.1 || n.Y1 != 0.1 || n.X2 != 0.5 || n.Y2 != 0.5 {
+		t.Errorf("Normalized(100, 200) = %+v", n)
+	}
+	if a := n.Absolute(100, 200); a.X1 != b.X1 || a.Y1 != b.Y1 || a.X2 != b.X2 || a.Y2 != b.Y2 {
+		t.Errorf("Absolute(Normalized()) = %+v, want %+v", a, b)
+	}
+}
+
+func TestBoundingBox_JSON(t *testing.T) {
+	b := onnx.BoundingBox{Label: "dog", ClassID: 16, Confidence: 0.5, X1: 1, Y1: 2, X2: 3, Y2: 4, Index: 42}
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"label":"dog","class_id":16,"confidence":0.5,"x1":1,"y1":2,"x2":3,"y2":4,"index":42}`
+	if string(data) != want {
+		t.Errorf("json.Marshal = %s, want %s", data, want)
+	}
+
+	b.Scores = []float32{0.1, 0.5}
+	data, _ = json.Marshal(b)
+	if !strings.Contains(string(data), `"scores":[0.1,0.5]`) {
+		t.Errorf("Expected scores in %s", data)
+	}
+}
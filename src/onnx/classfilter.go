@@ -0,0 +1,73 @@
+package onnx
+
+import (
+	"math"
+	"slices"
+)
+
+// ClassFilter selects the classes kept by decoding, by model label or class ID.
+// Detections of other classes are dropped before non-maximum suppression.
+type ClassFilter struct {
+	// Include and IncludeIDs list the classes to keep. When both are empty, every class is kept.
+	Include    []string `json:"include,omitempty"`
+	IncludeIDs []int    `json:"include_ids,omitempty"`
+	// Exclude and ExcludeIDs list the classes to drop, even when they are included.
+	Exclude    []string `json:"exclude,omitempty"`
+	ExcludeIDs []int    `json:"exclude_ids,omitempty"`
+}
+
+// IsZero reports whether the filter keeps every class.
+func (f ClassFilter) IsZero() bool {
+	return len(f.Include) == 0 && len(f.IncludeIDs) == 0 && len(f.Exclude) == 0 && len(f.ExcludeIDs) == 0
+}
+
+// Allows reports whether the class with the given ID and label is kept.
+func (f ClassFilter) Allows(id int, label string) bool {
+	if slices.Contains(f.ExcludeIDs, id) || slices.Contains(f.Exclude, label) {
+		return false
+	}
+	if len(f.Include) == 0 && len(f.IncludeIDs) == 0 {
+		return true
+	}
+	return slices.Contains(f.IncludeIDs, id) || slices.Contains(f.Include, label)
+}
+
+// Labels returns every label named by the filter.
+func (f ClassFilter) Labels() []string {
+	return append(slices.Clone(f.Include), f.Exclude...)
+}
+
+// IDs returns every class ID named by the filter.
+func (f ClassFilter) IDs() []int {
+	return append(slices.Clone(f.IncludeIDs), f.ExcludeIDs...)
+}
+
+// classThresholds returns the confidence threshold of each class output by the model.
+// Classes rejected by ClassFilter get an infinite threshold so none of their detections is kept.
+func (p *Processor) classThresholds() []float32 {
+	thresholds := make([]float32, p.ModelOutputClasses)
+	for id := range thresholds {
+		label, _ := p.ModelClasses.Name(id)
+		threshold, ok := p.ClassThresholds[label]
+		if !ok {
+			threshold = p.ThresholdConfidence
+		}
+		if !p.ClassFilter.Allows(id, label) {
+			threshold = float32(math.Inf(1))
+		}
+		thresholds[id] = threshold
+	}
+	return thresholds
+}
+
+// bestClass returns the most confident class of a detection among the classes whose score, given
+// by score, reaches their threshold, so a detection whose top class is filtered out or below its
+// own threshold is kept as the next class which passes. It returns false when no class passes.
+func bestClass(thresholds []float32, score func(class int) float32) (classID int, probability float32, ok bool) {
+	for class, threshold := range thresholds {
+		if s := score(class); s >= threshold && (!ok || s > probability) {
+			classID, probability, ok = class, s, true
+		}
+	}
+	return classID, probability, ok
+}
@@ -0,0 +1,103 @@
+package onnx_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+func TestClassFilter_Allows(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter onnx.ClassFilter
+		want   []bool // for cat (0), dog (1), bird (2)
+	}{
+		{"zero", onnx.ClassFilter{}, []bool{true, true, true}},
+		{"include label", onnx.ClassFilter{Include: []string{"dog"}}, []bool{false, true, false}},
+		{"include id", onnx.ClassFilter{Include: []string{"dog"}, IncludeIDs: []int{2}}, []bool{false, true, true}},
+		{"exclude", onnx.ClassFilter{Exclude: []string{"cat"}, ExcludeIDs: []int{2}}, []bool{false, true, false}},
+		{"exclude wins", onnx.ClassFilter{Include: []string{"cat", "dog"}, Exclude: []string{"dog"}}, []bool{true, false, false}},
+	}
+	for _, tt := range tests {
+		for id, label := range []string{"cat", "dog", "bird"} {
+			if got := tt.filter.Allows(id, label); got != tt.want[id] {
+				t.Errorf("%s: Allows(%d, %q) = %v, want %v", tt.name, id, label, got, tt.want[id])
+			}
+		}
+	}
+	if !(onnx.ClassFilter{}).IsZero() || (onnx.ClassFilter{ExcludeIDs: []int{1}}).IsZero() {
+		t.Error("Unexpected IsZero result")
+	}
+}
+
+func TestProcessorOutputFromData_ClassThresholdsAndFilter(t *testing.T) {
+	// A person at 0.4, a toothbrush at 0.7 and a cat at 0.9, far apart.
+	output := []float32{
+		10, 50, 90, // xc
+		10, 50, 90, // yc
+		10, 10, 10, // w
+		10, 10, 10, // h
+		0.4, 0.0, 0.0, // person
+		0.0, 0.7, 0.0, // toothbrush
+		0.0, 0.0, 0.9, // cat
+	}
+	p := &onnx.Processor{
+		Image:               image.NewRGBA(image.Rect(0, 0, 100, 100)),
+		ModelClasses:        onnx.NewLabelSet([]string{"person", "toothbrush", "cat"}),
+		ModelHeight:         100,
+		ModelWidth:          100,
+		ModelOutputClasses:  3,
+		ModelDetections:     3,
+		ThresholdConfidence: 0.5,
+		ClassThresholds:     map[string]float32{"person": 0.35, "toothbrush": 0.8},
+	}
+
+	boxes, err := p.OutputFromData(output)
+	if err != nil {
+		t.Fatalf("Processor.OutputFromData returned error: %v", err)
+	}
+	labels := map[string]bool{}
+	for _, box := range boxes {
+		labels[box.Label] = true
+	}
+	if len(boxes) != 2 || !labels["person"] || !labels["cat"] {
+		t.Errorf("Expected the person and the cat with per-class thresholds, got %v", boxes)
+	}
+
+	p.ClassFilter = onnx.ClassFilter{Exclude: []string{"person"}}
+	boxes, _ = p.OutputFromData(output)
+	if len(boxes) != 1 || boxes[0].Label != "cat" {
+		t.Errorf("Expected only the cat once person is excluded, got %v", boxes)
+	}
+}
+
+func TestProcessorOutputFromData_BestAllowedClass(t *testing.T) {
+	// One detection whose top class, a dog at 0.8, is filtered out or below its own threshold,
+	// while a cat at 0.6 clears the default threshold.
+	output := []float32{50, 50, 10, 10, 0.6, 0.8}
+	p := &onnx.Processor{
+		Image:               image.NewRGBA(image.Rect(0, 0, 100, 100)),
+		ModelClasses:        onnx.NewLabelSet([]string{"cat", "dog"}),
+		ModelHeight:         100,
+		ModelWidth:          100,
+		ModelOutputClasses:  2,
+		ModelDetections:     1,
+		ThresholdConfidence: 0.5,
+		ClassFilter:         onnx.ClassFilter{Exclude: []string{"dog"}},
+	}
+	boxes, err := p.OutputFromData(output)
+	if err != nil {
+		t.Fatalf("Processor.OutputFromData returned error: %v", err)
+	}
+	if len(boxes) != 1 || boxes[0].Label != "cat" || boxes[0].ClassID != 0 || boxes[0].Confidence != 0.6 {
+		t.Errorf("Expected the cat once the dog is excluded, got %+v", boxes)
+	}
+
+	p.ClassFilter = onnx.ClassFilter{}
+	p.ClassThresholds = map[string]float32{"dog": 0.9}
+	boxes, _ = p.OutputFromData(output)
+	if len(boxes) != 1 || boxes[0].Label != "cat" {
+		t.Errorf("Expected the cat once the dog is below its threshold, got %+v", boxes)
+	}
+}
@@ -0,0 +1,143 @@
+package onnx
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+	"sort"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// Classification is a single ranked result of an image classification model.
+type Classification struct {
+	// Label is the class name, taken from ModelClasses.
+	Label string
+	// Index is the position of the class in the model output.
+	Index int
+	// Probability is the score of the class, after softmax when enabled.
+	Probability float32
+}
+
+// ClassificationProcessor handles image preprocessing and postprocessing for
+// classification models (ResNet, EfficientNet, YOLO-cls...) which output one
+// score per class, e.g. a tensor of shape (1, 1000).
+type ClassificationProcessor struct {
+	// Image is the image to be processed.
+	Image image.Image
+	// ModelClasses is the set of classes that the model can recognize.
+	// It should match the order of classes in the model.
+	ModelClasses LabelSet
+	// ModelHeight and ModelWidth are the dimensions to which input images are resized.
+	ModelHeight uint
+	ModelWidth  uint
+	// ModelInputChannels is the number of channels in the input image (3 for RGB).
+	ModelInputChannels uint
+	// ModelOutputClasses is the number of scores the model outputs.
+	ModelOutputClasses uint
+	// ApplySoftmax converts raw logits to probabilities before ranking.
+	// Leave it disabled for models which already end with a softmax layer.
+	ApplySoftmax bool
+	// TopK is the maximum number of results to return. Zero returns every class.
+	TopK int
+	// ThresholdConfidence is the minimum probability for a class to be returned.
+	ThresholdConfidence float32
+}
+
+// Input prepares the input tensor for the model.
+func (p *ClassificationProcessor) Input(tensor *ort.Tensor[float32]) error {
+	return p.InputToData(tensor.GetData())
+}
+
+// InputToData fills the input tensor with the image data.
+func (p *ClassificationProcessor) InputToData(data []float32) error {
+	return p.InputToDataContext(context.Background(), data)
+}
+
+// InputToDataContext fills the input tensor with the image data, stopping with an error
+// wrapping ctx.Err() when ctx ends before the image is fully processed.
+func (p *ClassificationProcessor) InputToDataContext(ctx context.Context, data []float32) error {
+	return imageToData(ctx, p.Image, p.ModelWidth, p.ModelHeight, p.ModelInputChannels, ResizeStretch, data)
+}
+
+// Output processes the output of the model and returns the ranked classes.
+func (p *ClassificationProcessor) Output(tensor *ort.Tensor[float32]) ([]Classification, error) {
+	return p.OutputFromData(tensor.GetData())
+}
+
+// OutputFromData processes the output data from the model and returns the ranked classes,
+// from the most to the least probable, filtered by ThresholdConfidence and limited to TopK.
+func (p *ClassificationProcessor) OutputFromData(output []float32) ([]Classification, error) {
+	return p.OutputFromDataContext(context.Background(), output)
+}
+
+// OutputFromDataContext is like OutputFromData, but stops with an error wrapping ctx.Err()
+// when ctx ends before postprocessing completes.
+func (p *ClassificationProcessor) OutputFromDataContext(ctx context.Context, output []float32) ([]Classification, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("postprocessing cancelled: %w", err)
+	}
+	classes := int(p.ModelOutputClasses)
+	if err := checkShape("output", output, classes); err != nil {
+		return nil, err
+	}
+	if p.ModelClasses.Len() < classes {
+		return nil, fmt.Errorf("model outputs %d classes but only %d class labels are defined", classes, p.ModelClasses.Len())
+	}
+
+	scores := output[:classes]
+	if p.ApplySoftmax {
+		scores = Softmax(scores)
+	}
+
+	results := make([]Classification, 0, classes)
+	for idx, score := range scores {
+		if score < p.ThresholdConfidence {
+			continue
+		}
+		label, err := p.ModelClasses.Name(idx)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, Classification{
+			Label:       label,
+			Index:       idx,
+			Probability: score,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Probability > results[j].Probability
+	})
+
+	if p.TopK > 0 && len(results) > p.TopK {
+		results = results[:p.TopK]
+	}
+	return results, nil
+}
+
+// Softmax returns the softmax of the given logits as a new slice.
+// The maximum logit is subtracted first to keep the exponentials numerically stable.
+func Softmax(logits []float32) []float32 {
+	probabilities := make([]float32, len(logits))
+	if len(logits) == 0 {
+		return probabilities
+	}
+
+	maxLogit := logits[0]
+	for _, v := range logits[1:] {
+		maxLogit = max(maxLogit, v)
+	}
+
+	var sum float64
+	for i, v := range logits {
+		e := math.Exp(float64(v - maxLogit))
+		probabilities[i] = float32(e)
+		sum += e
+	}
+	for i := range probabilities {
+		probabilities[i] = float32(float64(probabilities[i]) / sum)
+	}
+	return probabilities
+}
@@ -0,0 +1,107 @@
+package onnx_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+func TestSoftmax(t *testing.T) {
+	probabilities := onnx.Softmax([]float32{1, 2, 3})
+	var sum float32
+	for _, p := range probabilities {
+		sum += p
+	}
+	if !approxEqual(sum, 1, 1e-6) {
+		t.Errorf("Expected probabilities to sum to 1, got %f", sum)
+	}
+	if !approxEqual(probabilities[2], 0.66524096, 1e-6) {
+		t.Errorf("Expected highest probability 0.665241, got %f", probabilities[2])
+	}
+	if len(onnx.Softmax(nil)) != 0 {
+		t.Errorf("Expected empty softmax for empty logits")
+	}
+}
+
+func TestClassificationProcessorOutputFromData_TopK(t *testing.T) {
+	p := &onnx.ClassificationProcessor{
+		ModelClasses:       onnx.NewLabelSet([]string{"cat", "dog", "bird", "fish"}),
+		ModelOutputClasses: 4,
+		ApplySoftmax:       true,
+		TopK:               2,
+	}
+
+	results, err := p.OutputFromData([]float32{1, 4, 2, 3})
+	if err != nil {
+		t.Fatalf("OutputFromData returned error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].Label != "dog" || results[0].Index != 1 {
+		t.Errorf("Expected 'dog' (1) first, got '%s' (%d)", results[0].Label, results[0].Index)
+	}
+	if results[1].Label != "fish" || results[1].Probability > results[0].Probability {
+		t.Errorf("Expected 'fish' second with a lower probability, got %+v", results[1])
+	}
+}
+
+func TestClassificationProcessorOutputFromData_Threshold(t *testing.T) {
+	p := &onnx.ClassificationProcessor{
+		ModelClasses:        onnx.NewLabelSet([]string{"cat", "dog", "bird"}),
+		ModelOutputClasses:  3,
+		ThresholdConfidence: 0.3,
+	}
+
+	results, err := p.OutputFromData([]float32{0.1, 0.5, 0.4})
+	if err != nil {
+		t.Fatalf("OutputFromData returned error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results above threshold, got %d", len(results))
+	}
+	if results[0].Probability != 0.5 {
+		t.Errorf("Expected raw probability 0.5 without softmax, got %f", results[0].Probability)
+	}
+}
+
+func TestClassificationProcessorOutputFromData_Errors(t *testing.T) {
+	p := &onnx.ClassificationProcessor{
+		ModelClasses:       onnx.NewLabelSet([]string{"cat", "dog"}),
+		ModelOutputClasses: 3,
+	}
+	if _, err := p.OutputFromData([]float32{0.1, 0.2}); err == nil {
+		t.Errorf("Expected error for a truncated output tensor")
+	}
+	if _, err := p.OutputFromData([]float32{0.1, 0.2, 0.3}); err == nil {
+		t.Errorf("Expected error when class labels are missing")
+	}
+}
+
+func TestClassificationProcessorInputToData(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			img.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+	p := &onnx.ClassificationProcessor{
+		Image:              img,
+		ModelHeight:        4,
+		ModelWidth:         4,
+		ModelInputChannels: 3,
+	}
+
+	data := make([]float32, 4*4*3)
+	if err := p.InputToData(data); err != nil {
+		t.Fatalf("InputToData returned error: %v", err)
+	}
+	if !approxEqual(data[0], 1, 1e-6) || data[16] != 0 || data[32] != 0 {
+		t.Errorf("Expected a red planar tensor, got %v, %v, %v", data[0], data[16], data[32])
+	}
+	if err := p.InputToData(make([]float32, 10)); err == nil {
+		t.Errorf("Expected error for a too small destination tensor")
+	}
+}
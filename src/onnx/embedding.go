@@ -0,0 +1,103 @@
+package onnx
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// EmbeddingProcessor handles image preprocessing and postprocessing for embedding
+// models (CLIP visual encoder, DINOv2, face recognition...) which output one feature
+// vector per image, e.g. a tensor of shape (1, 512).
+type EmbeddingProcessor struct {
+	// Image is the image to be processed.
+	Image image.Image
+	// ModelHeight and ModelWidth are the dimensions to which input images are resized.
+	ModelHeight uint
+	ModelWidth  uint
+	// ModelInputChannels is the number of channels in the input image (3 for RGB).
+	ModelInputChannels uint
+	// EmbeddingSize is the number of features in the output vector.
+	EmbeddingSize uint
+}
+
+// Input prepares the input tensor for the model.
+func (p *EmbeddingProcessor) Input(tensor *ort.Tensor[float32]) error {
+	return p.InputToData(tensor.GetData())
+}
+
+// InputToData fills the input tensor with the image data.
+func (p *EmbeddingProcessor) InputToData(data []float32) error {
+	return p.InputToDataContext(context.Background(), data)
+}
+
+// InputToDataContext fills the input tensor with the image data, stopping with an error
+// wrapping ctx.Err() when ctx ends before the image is fully processed.
+func (p *EmbeddingProcessor) InputToDataContext(ctx context.Context, data []float32) error {
+	return imageToData(ctx, p.Image, p.ModelWidth, p.ModelHeight, p.ModelInputChannels, ResizeStretch, data)
+}
+
+// Output processes the output of the model and returns the L2-normalized embedding.
+func (p *EmbeddingProcessor) Output(tensor *ort.Tensor[float32]) ([]float32, error) {
+	return p.OutputFromData(tensor.GetData())
+}
+
+// OutputFromData copies the feature vector out of the output data and L2-normalizes it,
+// so the cosine similarity of two embeddings is their dot product.
+func (p *EmbeddingProcessor) OutputFromData(output []float32) ([]float32, error) {
+	return p.OutputFromDataContext(context.Background(), output)
+}
+
+// OutputFromDataContext is like OutputFromData, but stops with an error wrapping ctx.Err()
+// when ctx ends before postprocessing completes.
+func (p *EmbeddingProcessor) OutputFromDataContext(ctx context.Context, output []float32) ([]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("postprocessing cancelled: %w", err)
+	}
+	size := int(p.EmbeddingSize)
+	if err := checkShape("output", output, size); err != nil {
+		return nil, err
+	}
+
+	embedding := make([]float32, size)
+	copy(embedding, output[:size])
+	if !L2Normalize(embedding) {
+		return nil, fmt.Errorf("model returned a zero embedding")
+	}
+	return embedding, nil
+}
+
+// L2Normalize scales vector in place to unit length.
+// It returns false, leaving the vector untouched, when the vector has no length.
+func L2Normalize(vector []float32) bool {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return false
+	}
+	norm := math.Sqrt(sum)
+	for i, v := range vector {
+		vector[i] = float32(float64(v) / norm)
+	}
+	return true
+}
+
+// CosineSimilarity returns the cosine similarity of two vectors of the same length.
+// It returns 0 when either vector has no length.
+func CosineSimilarity(a, b []float32) float32 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return float32(dot / math.Sqrt(normA*normB))
+}
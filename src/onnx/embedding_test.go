@@ -0,0 +1,42 @@
+package onnx_test
+
+import (
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+func TestEmbeddingProcessorOutputFromData(t *testing.T) {
+	p := &onnx.EmbeddingProcessor{EmbeddingSize: 2}
+
+	output := []float32{3, 4, 99}
+	embedding, err := p.OutputFromData(output)
+	if err != nil {
+		t.Fatalf("OutputFromData returned error: %v", err)
+	}
+	if len(embedding) != 2 || !approxEqual(embedding[0], 0.6, 1e-6) || !approxEqual(embedding[1], 0.8, 1e-6) {
+		t.Errorf("Expected normalized embedding [0.6 0.8], got %v", embedding)
+	}
+	if output[0] != 3 {
+		t.Errorf("Expected the output tensor to be left untouched")
+	}
+
+	if _, err := p.OutputFromData([]float32{1}); err == nil {
+		t.Errorf("Expected error for a truncated output tensor")
+	}
+	if _, err := p.OutputFromData([]float32{0, 0}); err == nil {
+		t.Errorf("Expected error for a zero embedding")
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	if s := onnx.CosineSimilarity([]float32{1, 0}, []float32{2, 0}); !approxEqual(s, 1, 1e-6) {
+		t.Errorf("Expected similarity 1 for parallel vectors, got %f", s)
+	}
+	if s := onnx.CosineSimilarity([]float32{1, 0}, []float32{0, 3}); s != 0 {
+		t.Errorf("Expected similarity 0 for orthogonal vectors, got %f", s)
+	}
+	if s := onnx.CosineSimilarity([]float32{1, 1}, []float32{0, 0}); s != 0 {
+		t.Errorf("Expected similarity 0 with a zero vector, got %f", s)
+	}
+}
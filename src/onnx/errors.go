@@ -0,0 +1,35 @@
+package onnx
+
+import (
+	"fmt"
+)
+
+// ShapeError is returned when a tensor does not hold enough data for the shape a processor expects.
+// It usually means the TensorInputShape or TensorOutputShape does not match the model.
+type ShapeError struct {
+	// Tensor is the role of the tensor, "input" or "output".
+	Tensor string
+	// Shape is the shape expected by the processor, without the batch dimension.
+	Shape []int
+	// Expected is the number of values required by Shape.
+	Expected int
+	// Got is the number of values the tensor holds.
+	Got int
+}
+
+func (e *ShapeError) Error() string {
+	return fmt.Sprintf("%s tensor only holds %d floats, needs %d for shape %v (make sure it's the right shape!)",
+		e.Tensor, e.Got, e.Expected, e.Shape)
+}
+
+// checkShape returns a ShapeError when data is too small for shape.
+func checkShape(tensor string, data []float32, shape ...int) error {
+	expected := 1
+	for _, dim := range shape {
+		expected *= dim
+	}
+	if len(data) < expected {
+		return &ShapeError{Tensor: tensor, Shape: shape, Expected: expected, Got: len(data)}
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package onnx_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+func TestShapeError_Error(t *testing.T) {
+	err := &onnx.ShapeError{Tensor: "output", Shape: []int{84, 8400}, Expected: 705600, Got: 100}
+	msg := err.Error()
+	for _, part := range []string{"output", "100", "705600", "[84 8400]"} {
+		if !strings.Contains(msg, part) {
+			t.Errorf("Expected %q in error message %q", part, msg)
+		}
+	}
+}
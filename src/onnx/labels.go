@@ -0,0 +1,194 @@
+package onnx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LabelSet maps class IDs, the positions of the classes in the model output, to class names and back.
+type LabelSet struct {
+	names []string
+	ids   map[string]int
+}
+
+// NewLabelSet creates a label set from class names in the order of the model output.
+// When a name appears several times, ID returns its first position.
+func NewLabelSet(names []string) LabelSet {
+	s := LabelSet{names: append([]string(nil), names...), ids: make(map[string]int, len(names))}
+	for id, name := range names {
+		if _, ok := s.ids[name]; !ok {
+			s.ids[name] = id
+		}
+	}
+	return s
+}
+
+// Len returns the number of classes.
+func (s LabelSet) Len() int {
+	return len(s.names)
+}
+
+// Names returns a copy of the class names, indexed by class ID.
+func (s LabelSet) Names() []string {
+	return append([]string(nil), s.names...)
+}
+
+// Name returns the name of class id, or an error when id is out of range.
+func (s LabelSet) Name(id int) (string, error) {
+	if id < 0 || id >= len(s.names) {
+		return "", fmt.Errorf("class ID %d is out of range for %d model classes", id, len(s.names))
+	}
+	return s.names[id], nil
+}
+
+// ID returns the class ID of name.
+func (s LabelSet) ID(name string) (int, bool) {
+	id, ok := s.ids[name]
+	return id, ok
+}
+
+// Remap returns the label set of the classes output once remap is applied: the distinct
+// target names, in the order of their first source class. Dropped classes are left out.
+func (s LabelSet) Remap(remap ClassRemap) LabelSet {
+	var names []string
+	seen := make(map[string]bool, len(s.names))
+	for _, name := range s.names {
+		target, keep := remap.Label(name)
+		if keep && !seen[target] {
+			seen[target] = true
+			names = append(names, target)
+		}
+	}
+	return NewLabelSet(names)
+}
+
+// LoadLabels reads class names from a file. See ReadLabels for the supported formats.
+func LoadLabels(path string) (LabelSet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return LabelSet{}, fmt.Errorf("failed to open labels: %w", err)
+	}
+	defer file.Close()
+
+	labels, err := ReadLabels(file)
+	if err != nil {
+		return LabelSet{}, fmt.Errorf("failed to read labels from %s: %w", path, err)
+	}
+	return labels, nil
+}
+
+// ReadLabels reads class names in one of the following formats, detected from the content:
+//   - a JSON array of names, ["person", "bicycle"],
+//   - a JSON object from class ID to name, {"0": "person", "1": "bicycle"},
+//   - a Python dict as stored in Ultralytics metadata, {0: 'person', 1: 'bicycle'},
+//   - a coco.names style text file, with one name per line; blank lines are skipped.
+func ReadLabels(r io.Reader) (LabelSet, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return LabelSet{}, err
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case len(trimmed) == 0:
+		return LabelSet{}, fmt.Errorf("no labels found")
+	case trimmed[0] == '[':
+		var names []string
+		if err := json.Unmarshal(trimmed, &names); err != nil {
+			return LabelSet{}, fmt.Errorf("invalid JSON label array: %w", err)
+		}
+		return NewLabelSet(names), nil
+	case trimmed[0] == '{':
+		var byID map[string]string
+		if err := json.Unmarshal(trimmed, &byID); err != nil {
+			// Not JSON, try the Python dict of Ultralytics metadata.
+			names, err := ParseClassNames(string(trimmed))
+			if err != nil {
+				return LabelSet{}, fmt.Errorf("invalid label map: %w", err)
+			}
+			return NewLabelSet(names), nil
+		}
+		names, err := labelsByID(byID)
+		if err != nil {
+			return LabelSet{}, err
+		}
+		return NewLabelSet(names), nil
+	}
+
+	var names []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if name := strings.TrimSpace(scanner.Text()); name != "" {
+			names = append(names, name)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return LabelSet{}, err
+	}
+	return NewLabelSet(names), nil
+}
+
+// labelsByID orders the names of a label map by class ID. IDs must cover 0 to n-1, each once,
+// so keys such as "1" and "01" naming the same ID are rejected.
+func labelsByID(byID map[string]string) ([]string, error) {
+	names := make([]string, len(byID))
+	seen := make([]bool, len(byID))
+	for key, name := range byID {
+		id, err := strconv.Atoi(strings.TrimSpace(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid class ID %q", key)
+		}
+		if id < 0 || id >= len(names) {
+			return nil, fmt.Errorf("class ID %d is outside [0, %d)", id, len(names))
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("duplicate class ID %d", id)
+		}
+		seen[id] = true
+		names[id] = name
+	}
+	return names, nil
+}
+
+// ClassRemap renames detected classes at output time, from model label to output label.
+// Several labels mapped to the same output merge the classes, e.g. car, truck and bus to vehicle,
+// and labels mapped to the empty string are dropped. Labels missing from the map are kept as is.
+type ClassRemap map[string]string
+
+// Label returns the output label of a model label, and false when the class is dropped.
+func (r ClassRemap) Label(label string) (string, bool) {
+	target, ok := r[label]
+	if !ok {
+		return label, true
+	}
+	return target, target != ""
+}
+
+// Apply renames the boxes of the model classes labels in place and removes the dropped ones,
+// returning the shortened slice. The class ID of each box becomes the ID of its output label
+// in labels.Remap(r), so merged classes share an ID.
+func (r ClassRemap) Apply(boxes []BoundingBox, labels LabelSet) []BoundingBox {
+	if len(r) == 0 {
+		return boxes
+	}
+	remapped := labels.Remap(r)
+	kept := boxes[:0]
+	for _, box := range boxes {
+		label, keep := r.Label(box.Label)
+		if !keep {
+			continue
+		}
+		box.Label = label
+		if id, ok := remapped.ID(label); ok {
+			box.ClassID = id
+		}
+		kept = append(kept, box)
+	}
+	return kept
+}
@@ -0,0 +1,96 @@
+package onnx_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+func TestReadLabels_Formats(t *testing.T) {
+	tests := map[string]string{
+		"names":       "cat\n\n  dog  \r\nbird\n",
+		"json array":  `["cat", "dog", "bird"]`,
+		"json object": `{"2": "bird", "0": "cat", "1": "dog"}`,
+		"python dict": `{0: 'cat', 1: 'dog', 2: 'bird'}`,
+	}
+	for name, input := range tests {
+		labels, err := onnx.ReadLabels(strings.NewReader(input))
+		if err != nil {
+			t.Errorf("%s: ReadLabels returned error: %v", name, err)
+			continue
+		}
+		if got := labels.Names(); !slices.Equal(got, []string{"cat", "dog", "bird"}) {
+			t.Errorf("%s: unexpected labels %q", name, got)
+		}
+	}
+}
+
+func TestReadLabels_Errors(t *testing.T) {
+	for _, input := range []string{"", "  \n", `["cat", 1]`, `{"0": "cat", "2": "dog"}`, `{"zero": "cat"}`, `{"0": "cat", "00": "dog"}`, `{"1": "cat", " 1": "dog"}`} {
+		if _, err := onnx.ReadLabels(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected ReadLabels(%q) to fail", input)
+		}
+	}
+}
+
+func TestLoadLabels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "coco.names")
+	if err := os.WriteFile(path, []byte("person\nbicycle\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	labels, err := onnx.LoadLabels(path)
+	if err != nil || labels.Len() != 2 {
+		t.Fatalf("Expected 2 labels, got %v, %v", labels.Names(), err)
+	}
+	if _, err := onnx.LoadLabels(filepath.Join(t.TempDir(), "missing.names")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestLabelSet_Lookup(t *testing.T) {
+	labels := onnx.NewLabelSet([]string{"cat", "dog"})
+
+	if name, err := labels.Name(1); err != nil || name != "dog" {
+		t.Errorf("Name(1) = %q, %v", name, err)
+	}
+	if _, err := labels.Name(2); err == nil {
+		t.Error("Expected an error for an out-of-range class ID")
+	}
+	if _, err := labels.Name(-1); err == nil {
+		t.Error("Expected an error for a negative class ID")
+	}
+	if id, ok := labels.ID("cat"); !ok || id != 0 {
+		t.Errorf("ID(cat) = %d, %v", id, ok)
+	}
+	if _, ok := labels.ID("bird"); ok {
+		t.Error("Expected bird to be unknown")
+	}
+}
+
+func TestClassRemap(t *testing.T) {
+	remap := onnx.ClassRemap{"car": "vehicle", "truck": "vehicle", "bus": "vehicle", "tie": ""}
+	model := onnx.NewLabelSet([]string{"person", "car", "truck", "tie", "bus"})
+	labels := model.Remap(remap)
+	if got := labels.Names(); !slices.Equal(got, []string{"person", "vehicle"}) {
+		t.Errorf("Expected remapped labels [person vehicle], got %q", got)
+	}
+
+	boxes := remap.Apply([]onnx.BoundingBox{
+		{Label: "truck", ClassID: 2},
+		{Label: "tie", ClassID: 3},
+		{Label: "person", ClassID: 0},
+		{Label: "bus", ClassID: 4},
+	}, model)
+	if len(boxes) != 3 || boxes[0].Label != "vehicle" || boxes[1].Label != "person" || boxes[2].Label != "vehicle" {
+		t.Fatalf("Unexpected remapped boxes %v", boxes)
+	}
+	// Class IDs are those of the remapped labels, shared by merged classes.
+	if boxes[0].ClassID != 1 || boxes[1].ClassID != 0 || boxes[2].ClassID != 1 {
+		t.Errorf("Expected class IDs 1, 0, 1 of the remapped labels, got %d, %d, %d",
+			boxes[0].ClassID, boxes[1].ClassID, boxes[2].ClassID)
+	}
+}
@@ -0,0 +1,280 @@
+package onnx
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// ClassNamesKey is the custom metadata key under which Ultralytics exports store the class labels.
+const ClassNamesKey = "names"
+
+// ModelMetadata holds the metadata stored in an ONNX model file.
+type ModelMetadata struct {
+	Producer    string `json:"producer"`
+	GraphName   string `json:"graph_name"`
+	Domain      string `json:"domain"`
+	Description string `json:"description"`
+	Version     int64  `json:"version"`
+	// Custom holds the custom metadata map, e.g. the names, stride and imgsz keys of Ultralytics exports.
+	Custom map[string]string `json:"custom"`
+}
+
+// ReadModelMetadata reads the metadata of the model of nr, initializing the onnxruntime
+// environment from its library path if needed.
+func ReadModelMetadata(nr *OnnxRuntime) (ModelMetadata, error) {
+	if err := initializeEnvironment(nr.libraryPath); err != nil {
+		return ModelMetadata{}, err
+	}
+	metadata, err := ort.GetModelMetadata(nr.modelPath)
+	if err != nil {
+		return ModelMetadata{}, fmt.Errorf("failed to read metadata of model %s: %w", nr.modelPath, err)
+	}
+	return readModelMetadata(metadata)
+}
+
+// initializeEnvironment loads the onnxruntime library and initializes its environment,
+// unless another session already did so.
+func initializeEnvironment(libraryPath string) error {
+	if ort.IsInitialized() {
+		return nil
+	}
+	ort.SetSharedLibraryPath(libraryPath)
+	return ort.InitializeEnvironment()
+}
+
+// readModelMetadata copies the fields of metadata and destroys it.
+func readModelMetadata(metadata *ort.ModelMetadata) (ModelMetadata, error) {
+	defer metadata.Destroy()
+
+	var m ModelMetadata
+	var err error
+	if m.Producer, err = metadata.GetProducerName(); err != nil {
+		return ModelMetadata{}, fmt.Errorf("failed to read model producer: %w", err)
+	}
+	if m.GraphName, err = metadata.GetGraphName(); err != nil {
+		return ModelMetadata{}, fmt.Errorf("failed to read model graph name: %w", err)
+	}
+	if m.Domain, err = metadata.GetDomain(); err != nil {
+		return ModelMetadata{}, fmt.Errorf("failed to read model domain: %w", err)
+	}
+	if m.Description, err = metadata.GetDescription(); err != nil {
+		return ModelMetadata{}, fmt.Errorf("failed to read model description: %w", err)
+	}
+	if m.Version, err = metadata.GetVersion(); err != nil {
+		return ModelMetadata{}, fmt.Errorf("failed to read model version: %w", err)
+	}
+
+	keys, err := metadata.GetCustomMetadataMapKeys()
+	if err != nil {
+		return ModelMetadata{}, fmt.Errorf("failed to read custom metadata keys: %w", err)
+	}
+	m.Custom = make(map[string]string, len(keys))
+	for _, key := range keys {
+		value, _, err := metadata.LookupCustomMetadataMap(key)
+		if err != nil {
+			return ModelMetadata{}, fmt.Errorf("failed to read custom metadata %q: %w", key, err)
+		}
+		m.Custom[key] = value
+	}
+	return m, nil
+}
+
+// ClassNames parses the class labels stored under ClassNamesKey.
+// It returns nil without error when the model has no such key.
+func (m ModelMetadata) ClassNames() ([]string, error) {
+	names, ok := m.Custom[ClassNamesKey]
+	if !ok {
+		return nil, nil
+	}
+	classes, err := ParseClassNames(names)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %q metadata: %w", ClassNamesKey, err)
+	}
+	return classes, nil
+}
+
+// ParseClassNames parses a class list as written by Ultralytics exports, a Python dict
+// from class index to label such as {0: 'person', 1: 'bicycle'}. JSON objects with string
+// keys and plain lists of labels are accepted too. Indices must cover 0 to n-1.
+func ParseClassNames(s string) ([]string, error) {
+	p := &namesParser{s: s}
+	p.skipSpace()
+	var classes []string
+	var err error
+	switch p.peek() {
+	case '{':
+		classes, err = p.parseDict()
+	case '[':
+		classes, err = p.parseList()
+	default:
+		return nil, errors.New("expected a dict or list of class names")
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.i < len(p.s) {
+		return nil, fmt.Errorf("unexpected %q after class names at offset %d", p.s[p.i:], p.i)
+	}
+	return classes, nil
+}
+
+// namesParser reads the subset of Python literals used by class name dicts.
+type namesParser struct {
+	s string
+	i int
+}
+
+func (p *namesParser) peek() byte {
+	if p.i >= len(p.s) {
+		return 0
+	}
+	return p.s[p.i]
+}
+
+func (p *namesParser) skipSpace() {
+	for p.i < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+func (p *namesParser) expect(c byte) error {
+	p.skipSpace()
+	if p.peek() != c {
+		return fmt.Errorf("expected %q at offset %d", c, p.i)
+	}
+	p.i++
+	return nil
+}
+
+// parseSequence calls item for each comma separated item until close, allowing a trailing comma.
+func (p *namesParser) parseSequence(open, close byte, item func() error) error {
+	if err := p.expect(open); err != nil {
+		return err
+	}
+	for {
+		p.skipSpace()
+		if p.peek() == close {
+			p.i++
+			return nil
+		}
+		if err := item(); err != nil {
+			return err
+		}
+		p.skipSpace()
+		if p.peek() == ',' {
+			p.i++
+		} else if p.peek() != close {
+			return fmt.Errorf("expected ',' or %q at offset %d", close, p.i)
+		}
+	}
+}
+
+func (p *namesParser) parseDict() ([]string, error) {
+	byIndex := map[int]string{}
+	err := p.parseSequence('{', '}', func() error {
+		index, err := p.parseIndex()
+		if err != nil {
+			return err
+		}
+		if err := p.expect(':'); err != nil {
+			return err
+		}
+		p.skipSpace()
+		name, err := p.parseString()
+		if err != nil {
+			return err
+		}
+		if _, ok := byIndex[index]; ok {
+			return fmt.Errorf("duplicate class index %d", index)
+		}
+		byIndex[index] = name
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	classes := make([]string, len(byIndex))
+	for index, name := range byIndex {
+		if index < 0 || index >= len(classes) {
+			return nil, fmt.Errorf("class index %d is outside [0, %d)", index, len(classes))
+		}
+		classes[index] = name
+	}
+	return classes, nil
+}
+
+func (p *namesParser) parseList() ([]string, error) {
+	var classes []string
+	err := p.parseSequence('[', ']', func() error {
+		name, err := p.parseString()
+		if err != nil {
+			return err
+		}
+		classes = append(classes, name)
+		return nil
+	})
+	return classes, err
+}
+
+// parseIndex reads an integer key, optionally quoted as in JSON objects.
+func (p *namesParser) parseIndex() (int, error) {
+	p.skipSpace()
+	start := p.i
+	var digits string
+	if c := p.peek(); c == '\'' || c == '"' {
+		s, err := p.parseString()
+		if err != nil {
+			return 0, err
+		}
+		digits = s
+	} else {
+		for p.i < len(p.s) && (p.s[p.i] == '-' || (p.s[p.i] >= '0' && p.s[p.i] <= '9')) {
+			p.i++
+		}
+		digits = p.s[start:p.i]
+	}
+	index, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0, fmt.Errorf("invalid class index %q at offset %d", digits, start)
+	}
+	return index, nil
+}
+
+// parseString reads a single or double quoted string with backslash escapes.
+func (p *namesParser) parseString() (string, error) {
+	quote := p.peek()
+	if quote != '\'' && quote != '"' {
+		return "", fmt.Errorf("expected a quoted string at offset %d", p.i)
+	}
+	start := p.i
+	p.i++
+	var b strings.Builder
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		p.i++
+		switch {
+		case c == quote:
+			return b.String(), nil
+		case c == '\\' && p.i < len(p.s):
+			escaped := p.s[p.i]
+			p.i++
+			switch escaped {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(escaped)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated string at offset %d", start)
+}
@@ -0,0 +1,67 @@
+package onnx_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+func TestParseClassNames_UltralyticsDict(t *testing.T) {
+	classes, err := onnx.ParseClassNames(`{0: 'person', 1: 'bicycle', 2: "it's", 3: 'traffic light'}`)
+	if err != nil {
+		t.Fatalf("ParseClassNames returned error: %v", err)
+	}
+	want := []string{"person", "bicycle", "it's", "traffic light"}
+	if !slices.Equal(classes, want) {
+		t.Errorf("Expected %q, got %q", want, classes)
+	}
+}
+
+func TestParseClassNames_OtherFormats(t *testing.T) {
+	tests := map[string]string{
+		"json object":   `{"1": "dog", "0": "cat"}`,
+		"list":          `['cat', 'dog']`,
+		"escaped quote": `{0: 'cat', 1: 'd\'og'}`,
+		"trailing":      "{\n  0: 'cat',\n  1: 'dog',\n}",
+	}
+	for name, input := range tests {
+		classes, err := onnx.ParseClassNames(input)
+		if err != nil {
+			t.Errorf("%s: ParseClassNames returned error: %v", name, err)
+			continue
+		}
+		if len(classes) != 2 || classes[0] != "cat" {
+			t.Errorf("%s: unexpected classes %q", name, classes)
+		}
+	}
+}
+
+func TestParseClassNames_Errors(t *testing.T) {
+	for _, input := range []string{
+		``,
+		`{0: 'cat', 2: 'dog'}`,
+		`{0: 'cat', 0: 'dog'}`,
+		`{0: 'cat'`,
+		`{zero: 'cat'}`,
+		`['cat' 'dog']`,
+		`{0: 'cat'} extra`,
+	} {
+		if _, err := onnx.ParseClassNames(input); err == nil {
+			t.Errorf("Expected ParseClassNames(%q) to fail", input)
+		}
+	}
+}
+
+func TestModelMetadata_ClassNames(t *testing.T) {
+	m := onnx.ModelMetadata{Custom: map[string]string{"names": "{0: 'cat'}", "stride": "32"}}
+	classes, err := m.ClassNames()
+	if err != nil || !slices.Equal(classes, []string{"cat"}) {
+		t.Errorf("Expected [cat], got %q, %v", classes, err)
+	}
+
+	classes, err = onnx.ModelMetadata{}.ClassNames()
+	if err != nil || classes != nil {
+		t.Errorf("Expected no classes without names metadata, got %q, %v", classes, err)
+	}
+}
@@ -0,0 +1,258 @@
+package onnx
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// Point is a 2D point in image coordinates.
+type Point struct {
+	X float32
+	Y float32
+}
+
+// OrientedBoundingBox represents a rotated rectangular region in an image,
+// as produced by oriented object detection models such as YOLO-obb.
+type OrientedBoundingBox struct {
+	Label      string
+	Confidence float32
+	// CX and CY are the coordinates of the box center.
+	CX float32
+	CY float32
+	// Width and Height are the side lengths of the box before rotation.
+	Width  float32
+	Height float32
+	// Angle is the rotation of the box in radians, measured clockwise in image
+	// coordinates (y pointing down), following the YOLO-obb convention.
+	Angle float32
+}
+
+// Corners returns the four corners of the oriented bounding box.
+// The corners are returned in order, so consecutive points share an edge.
+func (b *OrientedBoundingBox) Corners() [4]Point {
+	sin, cos := math.Sincos(float64(b.Angle))
+	// Half-extent vectors along the width and height axes of the box.
+	wx, wy := float32(cos)*b.Width/2, float32(sin)*b.Width/2
+	hx, hy := -float32(sin)*b.Height/2, float32(cos)*b.Height/2
+	return [4]Point{
+		{X: b.CX + wx + hx, Y: b.CY + wy + hy},
+		{X: b.CX + wx - hx, Y: b.CY + wy - hy},
+		{X: b.CX - wx - hx, Y: b.CY - wy - hy},
+		{X: b.CX - wx + hx, Y: b.CY - wy + hy},
+	}
+}
+
+// Area returns the area of the oriented bounding box.
+func (b *OrientedBoundingBox) Area() float32 {
+	return b.Width * b.Height
+}
+
+// Intersection returns the intersection area of this oriented bounding box with another one.
+// The overlap is computed by clipping one box polygon against the other.
+// If the boxes do not intersect, this will return 0.
+func (b *OrientedBoundingBox) Intersection(other *OrientedBoundingBox) float32 {
+	corners := b.Corners()
+	otherCorners := other.Corners()
+	return polygonArea(clipPolygon(corners[:], otherCorners[:]))
+}
+
+// Union returns the union area of this oriented bounding box with another one.
+func (b *OrientedBoundingBox) Union(other *OrientedBoundingBox) float32 {
+	return b.Area() + other.Area() - b.Intersection(other)
+}
+
+// IoU returns the rotated Intersection over Union (IoU) of this oriented bounding box with another one.
+func (b *OrientedBoundingBox) IoU(other *OrientedBoundingBox) float32 {
+	union := b.Union(other)
+	if union <= 0 {
+		return 0
+	}
+	return b.Intersection(other) / union
+}
+
+// BoundingBox returns the smallest axis-aligned BoundingBox enclosing the oriented box.
+func (b *OrientedBoundingBox) BoundingBox() BoundingBox {
+	corners := b.Corners()
+	box := BoundingBox{
+		Label:      b.Label,
+		Confidence: b.Confidence,
+		X1:         corners[0].X,
+		Y1:         corners[0].Y,
+		X2:         corners[0].X,
+		Y2:         corners[0].Y,
+	}
+	for _, c := range corners[1:] {
+		box.X1 = min(box.X1, c.X)
+		box.Y1 = min(box.Y1, c.Y)
+		box.X2 = max(box.X2, c.X)
+		box.Y2 = max(box.Y2, c.Y)
+	}
+	return box
+}
+
+// ToString returns a string representation of the OrientedBoundingBox.
+func (b *OrientedBoundingBox) ToString() string {
+	return fmt.Sprintf("Object %s (confidence %f): center (%f, %f), size (%f, %f), angle %f",
+		b.Label, b.Confidence, b.CX, b.CY, b.Width, b.Height, b.Angle)
+}
+
+// OrientedNMS performs non-maximum suppression on oriented bounding boxes using rotated IoU.
+// Boxes are considered from the most to the least confident, and a box is dropped when its
+// IoU with an already kept box exceeds iouThreshold.
+func OrientedNMS(boxes []OrientedBoundingBox, iouThreshold float32) []OrientedBoundingBox {
+	sorted := make([]OrientedBoundingBox, len(boxes))
+	copy(sorted, boxes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Confidence > sorted[j].Confidence
+	})
+
+	kept := make([]OrientedBoundingBox, 0, len(sorted))
+	for _, candidateBox := range sorted {
+		overlapsExistingBox := false
+		for _, existingBox := range kept {
+			if (&candidateBox).IoU(&existingBox) > iouThreshold {
+				overlapsExistingBox = true
+				break
+			}
+		}
+		if !overlapsExistingBox {
+			kept = append(kept, candidateBox)
+		}
+	}
+	return kept
+}
+
+// OrientedOutput processes the output of a YOLO-obb model and returns a slice of oriented bounding boxes.
+func (p *Processor) OrientedOutput(tensor *ort.Tensor[float32]) ([]OrientedBoundingBox, error) {
+	return p.OrientedOutputFromData(tensor.GetData())
+}
+
+// OrientedOutputFromData processes the output data of a YOLO-obb model and returns a slice of oriented bounding boxes.
+// The expected layout is (4 + ModelOutputClasses + 1, ModelDetections): the box center and size,
+// one score per class, and the rotation angle in radians as the last row.
+func (p *Processor) OrientedOutputFromData(output []float32) ([]OrientedBoundingBox, error) {
+	detections := int(p.ModelDetections)
+	classes := int(p.ModelOutputClasses)
+	if err := checkShape("output", output, classes+5, detections); err != nil {
+		return nil, err
+	}
+
+	transform := newImageTransform(p.Resize, p.Image.Bounds(), p.ModelWidth, p.ModelHeight)
+	scaleX, scaleY := 1/float64(transform.scaleX), 1/float64(transform.scaleY)
+
+	thresholds := p.classThresholds()
+	boxes := make([]OrientedBoundingBox, 0)
+	if classes == 0 {
+		return boxes, nil
+	}
+	for idx := 0; idx < detections; idx++ {
+		classID, probability, ok := bestClass(thresholds, func(class int) float32 {
+			return output[detections*(class+4)+idx]
+		})
+		if !ok {
+			continue
+		}
+		label, err := p.ModelClasses.Name(classID)
+		if err != nil {
+			return nil, err
+		}
+
+		xc, yc := output[idx], output[detections+idx]
+		w, h := float64(output[2*detections+idx]), float64(output[3*detections+idx])
+		angle := float64(output[detections*(classes+4)+idx])
+
+		// A stretched image is scaled differently along each axis, so each box axis is rescaled along its own direction.
+		sin, cos := math.Sincos(angle)
+		cx, cy := transform.toImage(xc, yc)
+		boxes = append(boxes, OrientedBoundingBox{
+			Label:      label,
+			Confidence: probability,
+			CX:         cx,
+			CY:         cy,
+			Width:      float32(w * math.Hypot(scaleX*cos, scaleY*sin)),
+			Height:     float32(h * math.Hypot(scaleX*sin, scaleY*cos)),
+			Angle:      float32(math.Atan2(scaleY*sin, scaleX*cos)),
+		})
+	}
+
+	return OrientedNMS(boxes, p.iouThreshold()), nil
+}
+
+// clipPolygon clips the convex polygon subject against the convex polygon clip
+// using the Sutherland-Hodgman algorithm and returns the resulting polygon.
+func clipPolygon(subject, clip []Point) []Point {
+	// The clipping edges must be walked counter-clockwise for the inside test below.
+	if signedArea(clip) < 0 {
+		reversed := make([]Point, len(clip))
+		for i, p := range clip {
+			reversed[len(clip)-1-i] = p
+		}
+		clip = reversed
+	}
+
+	result := subject
+	for i := range clip {
+		if len(result) == 0 {
+			break
+		}
+		a, b := clip[i], clip[(i+1)%len(clip)]
+		input := result
+		result = make([]Point, 0, len(input)+1)
+		for j := range input {
+			current, previous := input[j], input[(j+len(input)-1)%len(input)]
+			currentInside := cross(a, b, current) >= 0
+			previousInside := cross(a, b, previous) >= 0
+			if currentInside {
+				if !previousInside {
+					result = append(result, lineIntersection(previous, current, a, b))
+				}
+				result = append(result, current)
+			} else if previousInside {
+				result = append(result, lineIntersection(previous, current, a, b))
+			}
+		}
+	}
+	return result
+}
+
+// cross returns the z component of the cross product (b - a) x (p - a).
+// It is positive when p lies to the left of the directed line a->b.
+func cross(a, b, p Point) float32 {
+	return (b.X-a.X)*(p.Y-a.Y) - (b.Y-a.Y)*(p.X-a.X)
+}
+
+// lineIntersection returns the intersection of segment p1-p2 with the infinite line a-b.
+func lineIntersection(p1, p2, a, b Point) Point {
+	d1 := cross(a, b, p1)
+	d2 := cross(a, b, p2)
+	if d1 == d2 {
+		return p1
+	}
+	t := d1 / (d1 - d2)
+	return Point{X: p1.X + t*(p2.X-p1.X), Y: p1.Y + t*(p2.Y-p1.Y)}
+}
+
+// signedArea returns the signed area of a polygon using the shoelace formula.
+func signedArea(polygon []Point) float32 {
+	var area float32
+	for i := range polygon {
+		j := (i + 1) % len(polygon)
+		area += polygon[i].X*polygon[j].Y - polygon[j].X*polygon[i].Y
+	}
+	return area / 2
+}
+
+// polygonArea returns the absolute area of a polygon.
+func polygonArea(polygon []Point) float32 {
+	if len(polygon) < 3 {
+		return 0
+	}
+	area := signedArea(polygon)
+	if area < 0 {
+		return -area
+	}
+	return area
+}
@@ -0,0 +1,103 @@
+package onnx_test
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+func approxEqual(a, b, tolerance float32) bool {
+	return float32(math.Abs(float64(a-b))) <= tolerance
+}
+
+func TestOrientedBoundingBox_Corners(t *testing.T) {
+	b := onnx.OrientedBoundingBox{CX: 10, CY: 10, Width: 4, Height: 2, Angle: math.Pi / 2}
+	corners := b.Corners()
+	box := b.BoundingBox()
+	// A 4x2 box rotated by 90 degrees becomes a 2x4 box.
+	if !approxEqual(box.X2-box.X1, 2, 1e-4) || !approxEqual(box.Y2-box.Y1, 4, 1e-4) {
+		t.Errorf("Expected 2x4 envelope, got %v (corners %v)", box, corners)
+	}
+}
+
+func TestOrientedBoundingBox_IoU(t *testing.T) {
+	b1 := onnx.OrientedBoundingBox{CX: 0, CY: 0, Width: 2, Height: 2}
+	if iou := b1.IoU(&b1); !approxEqual(iou, 1, 1e-5) {
+		t.Errorf("Expected IoU 1 with itself, got %f", iou)
+	}
+
+	// A square rotated by 45 degrees over the same square: the overlap is a regular octagon.
+	b2 := onnx.OrientedBoundingBox{CX: 0, CY: 0, Width: 2, Height: 2, Angle: math.Pi / 4}
+	octagon := float32(8 * (math.Sqrt2 - 1))
+	if inter := b1.Intersection(&b2); !approxEqual(inter, octagon, 1e-4) {
+		t.Errorf("Expected intersection %f, got %f", octagon, inter)
+	}
+	expected := octagon / (8 - octagon)
+	if iou := b1.IoU(&b2); !approxEqual(iou, expected, 1e-4) {
+		t.Errorf("Expected IoU %f, got %f", expected, iou)
+	}
+
+	b3 := onnx.OrientedBoundingBox{CX: 10, CY: 10, Width: 2, Height: 2, Angle: 0.3}
+	if iou := b1.IoU(&b3); iou != 0 {
+		t.Errorf("Expected IoU 0 for disjoint boxes, got %f", iou)
+	}
+}
+
+func TestOrientedNMS(t *testing.T) {
+	boxes := []onnx.OrientedBoundingBox{
+		{Label: "ship", Confidence: 0.6, CX: 0, CY: 0, Width: 10, Height: 4, Angle: 0.5},
+		{Label: "ship", Confidence: 0.9, CX: 0.2, CY: 0, Width: 10, Height: 4, Angle: 0.5},
+		{Label: "ship", Confidence: 0.7, CX: 50, CY: 50, Width: 10, Height: 4, Angle: 0.5},
+	}
+	kept := onnx.OrientedNMS(boxes, 0.5)
+	if len(kept) != 2 {
+		t.Fatalf("Expected 2 boxes after NMS, got %d", len(kept))
+	}
+	if kept[0].Confidence != 0.9 || kept[1].Confidence != 0.7 {
+		t.Errorf("Expected the most confident boxes to be kept, got %v", kept)
+	}
+}
+
+func TestProcessorOrientedOutputFromData(t *testing.T) {
+	// Output tensor layout: [xc, yc, w, h, class1_prob, class2_prob, angle] for 2 detections.
+	output := []float32{
+		50, 10, // xc
+		50, 10, // yc
+		20, 5, // w
+		10, 5, // h
+		0.1, 0.2, // class1_prob
+		0.9, 0.3, // class2_prob
+		math.Pi / 6, 0, // angle
+	}
+
+	p := &onnx.Processor{
+		Image:               image.NewRGBA(image.Rect(0, 0, 200, 200)),
+		ModelClasses:        onnx.NewLabelSet([]string{"plane", "ship"}),
+		ModelHeight:         100,
+		ModelWidth:          100,
+		ModelOutputClasses:  2,
+		ModelDetections:     2,
+		ThresholdConfidence: 0.5,
+	}
+
+	boxes, err := p.OrientedOutputFromData(output)
+	if err != nil {
+		t.Fatalf("OrientedOutputFromData returned error: %v", err)
+	}
+	if len(boxes) != 1 {
+		t.Fatalf("Expected 1 oriented box, got %d", len(boxes))
+	}
+	box := boxes[0]
+	if box.Label != "ship" {
+		t.Errorf("Expected label 'ship', got '%s'", box.Label)
+	}
+	if !approxEqual(box.CX, 100, 1e-4) || !approxEqual(box.Width, 40, 1e-3) || !approxEqual(box.Angle, math.Pi/6, 1e-5) {
+		t.Errorf("Unexpected scaled box: %s", box.ToString())
+	}
+
+	if _, err := p.OrientedOutputFromData(output[:5]); err == nil {
+		t.Errorf("Expected error for a truncated output tensor")
+	}
+}
@@ -0,0 +1,58 @@
+package onnx
+
+import (
+	"image/color"
+	"math"
+)
+
+// ClassColor returns a stable, visually distinct color for a class index.
+// Hues are spread using the golden ratio so neighbouring classes never share a color.
+func ClassColor(index int) color.RGBA {
+	hue := math.Mod(float64(index)*0.618033988749895, 1)
+	r, g, b := hsvToRGB(hue, 0.85, 0.95)
+	return color.RGBA{R: r, G: g, B: b, A: 255}
+}
+
+// NewClassPalette returns a palette with one ClassColor per class.
+func NewClassPalette(classes int) color.Palette {
+	palette := make(color.Palette, classes)
+	for i := range palette {
+		palette[i] = ClassColor(i)
+	}
+	return palette
+}
+
+// Blend mixes the RGB channels of top into base with weight alpha in [0, 1], keeping the
+// alpha channel of base. It is how masks and label maps are overlaid onto images.
+func Blend(base, top color.RGBA, alpha float64) color.RGBA {
+	mix := func(b, t uint8) uint8 {
+		return uint8(float64(b)*(1-alpha) + float64(t)*alpha + 0.5)
+	}
+	return color.RGBA{R: mix(base.R, top.R), G: mix(base.G, top.G), B: mix(base.B, top.B), A: base.A}
+}
+
+// hsvToRGB converts a color from HSV, with every component in [0, 1], to 8-bit RGB.
+func hsvToRGB(h, s, v float64) (uint8, uint8, uint8) {
+	sector := math.Floor(h * 6)
+	f := h*6 - sector
+	p := v * (1 - s)
+	q := v * (1 - f*s)
+	t := v * (1 - (1-f)*s)
+
+	var r, g, b float64
+	switch int(sector) % 6 {
+	case 0:
+		r, g, b = v, t, p
+	case 1:
+		r, g, b = q, v, p
+	case 2:
+		r, g, b = p, v, t
+	case 3:
+		r, g, b = p, q, v
+	case 4:
+		r, g, b = t, p, v
+	default:
+		r, g, b = v, p, q
+	}
+	return uint8(math.Round(r * 255)), uint8(math.Round(g * 255)), uint8(math.Round(b * 255))
+}
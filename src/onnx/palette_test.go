@@ -0,0 +1,47 @@
+package onnx_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+func TestClassColor_StableAndDistinct(t *testing.T) {
+	if onnx.ClassColor(3) != onnx.ClassColor(3) {
+		t.Errorf("Expected ClassColor to be stable for the same index")
+	}
+	seen := make(map[[3]uint8]int)
+	for i := 0; i < 80; i++ {
+		c := onnx.ClassColor(i)
+		if c.A != 255 {
+			t.Errorf("Expected opaque color for class %d, got alpha %d", i, c.A)
+		}
+		key := [3]uint8{c.R, c.G, c.B}
+		if prev, ok := seen[key]; ok {
+			t.Errorf("Classes %d and %d share the color %v", prev, i, c)
+		}
+		seen[key] = i
+	}
+}
+
+func TestNewClassPalette(t *testing.T) {
+	palette := onnx.NewClassPalette(5)
+	if len(palette) != 5 {
+		t.Fatalf("Expected 5 colors, got %d", len(palette))
+	}
+	if palette[4] != onnx.ClassColor(4) {
+		t.Errorf("Expected palette entries to match ClassColor")
+	}
+}
+
+func TestBlend(t *testing.T) {
+	base := color.RGBA{R: 200, G: 0, B: 100, A: 255}
+	top := color.RGBA{R: 0, G: 100, B: 101, A: 0}
+	if got, want := onnx.Blend(base, top, 0.25), (color.RGBA{R: 150, G: 25, B: 100, A: 255}); got != want {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if got := onnx.Blend(base, top, 0); got != base {
+		t.Errorf("Expected a zero alpha to keep %v, got %v", base, got)
+	}
+}
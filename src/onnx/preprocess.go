@@ -0,0 +1,128 @@
+package onnx
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/nfnt/resize"
+)
+
+// ResizeMode selects how images are fitted to the model input size.
+type ResizeMode int
+
+const (
+	// ResizeStretch resizes the image to the model input size, ignoring its aspect ratio.
+	ResizeStretch ResizeMode = iota
+	// ResizeLetterbox scales the image to fit the model input size while keeping its aspect
+	// ratio, and pads the remaining area with gray, as done when training YOLO models.
+	ResizeLetterbox
+)
+
+// letterboxFill is the value of padding pixels in letterboxed images (114 on the 0-255 scale).
+const letterboxFill = float32(114) / 255.0
+
+// String returns the name of the resize mode.
+func (m ResizeMode) String() string {
+	switch m {
+	case ResizeStretch:
+		return "stretch"
+	case ResizeLetterbox:
+		return "letterbox"
+	default:
+		return fmt.Sprintf("ResizeMode(%d)", int(m))
+	}
+}
+
+// ParseResizeMode returns the resize mode named s, "stretch" or "letterbox".
+func ParseResizeMode(s string) (ResizeMode, error) {
+	switch s {
+	case "stretch", "":
+		return ResizeStretch, nil
+	case "letterbox":
+		return ResizeLetterbox, nil
+	default:
+		return ResizeStretch, fmt.Errorf("unknown resize mode %q, expected \"stretch\" or \"letterbox\"", s)
+	}
+}
+
+// imageTransform maps coordinates between an image and the model input it was resized to.
+type imageTransform struct {
+	// scaleX and scaleY are the model input size of one image pixel.
+	scaleX float32
+	scaleY float32
+	// padX and padY are the offsets of the image content in the model input.
+	padX float32
+	padY float32
+	// origin is the top left corner of the image bounds.
+	origin image.Point
+	// contentWidth and contentHeight are the size of the resized image content.
+	contentWidth  uint
+	contentHeight uint
+}
+
+// newImageTransform computes how an image with the given bounds is fitted to a width x height model input.
+func newImageTransform(mode ResizeMode, bounds image.Rectangle, width, height uint) imageTransform {
+	t := imageTransform{origin: bounds.Min, contentWidth: width, contentHeight: height}
+	if mode == ResizeLetterbox && bounds.Dx() > 0 && bounds.Dy() > 0 {
+		scale := math.Min(float64(width)/float64(bounds.Dx()), float64(height)/float64(bounds.Dy()))
+		t.contentWidth = uint(math.Max(1, math.Round(float64(bounds.Dx())*scale)))
+		t.contentHeight = uint(math.Max(1, math.Round(float64(bounds.Dy())*scale)))
+		t.padX = float32((width - t.contentWidth) / 2)
+		t.padY = float32((height - t.contentHeight) / 2)
+	}
+	t.scaleX = float32(t.contentWidth) / float32(bounds.Dx())
+	t.scaleY = float32(t.contentHeight) / float32(bounds.Dy())
+	return t
+}
+
+// toImage converts a point of the model input to image coordinates.
+func (t imageTransform) toImage(x, y float32) (float32, float32) {
+	return (x-t.padX)/t.scaleX + float32(t.origin.X), (y-t.padY)/t.scaleY + float32(t.origin.Y)
+}
+
+// imageToData resizes img to the model dimensions and writes it into data as planar RGB
+// floats in [0, 1], as expected by the models handled by this package.
+// Cancellation of ctx is checked around the resize and between rows.
+func imageToData(ctx context.Context, img image.Image, width, height, channels uint, mode ResizeMode, data []float32) error {
+	if channels != 3 {
+		return fmt.Errorf("unsupported input channel count %d, images are fed as RGB", channels)
+	}
+	if err := checkShape("input", data, int(channels), int(height), int(width)); err != nil {
+		return err
+	}
+	channelSize := height * width
+	redChannel := data[0:channelSize]
+	greenChannel := data[channelSize : channelSize*2]
+	blueChannel := data[channelSize*2 : channelSize*3]
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("preprocessing cancelled: %w", err)
+	}
+	t := newImageTransform(mode, img.Bounds(), width, height)
+	resized := resize.Resize(t.contentWidth, t.contentHeight, img, resize.Lanczos3)
+	padX, padY := int(t.padX), int(t.padY)
+
+	i := 0
+	for y := 0; y < int(height); y++ {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("preprocessing cancelled: %w", err)
+		}
+		for x := 0; x < int(width); x++ {
+			cx, cy := x-padX, y-padY
+			if cx < 0 || cy < 0 || cx >= int(t.contentWidth) || cy >= int(t.contentHeight) {
+				redChannel[i], greenChannel[i], blueChannel[i] = letterboxFill, letterboxFill, letterboxFill
+				i++
+				continue
+			}
+			r, g, b, _ := resized.At(cx, cy).RGBA()
+			redChannel[i] = float32(r>>8) / 255.0
+			greenChannel[i] = float32(g>>8) / 255.0
+			blueChannel[i] = float32(b>>8) / 255.0
+			i++
+		}
+	}
+
+	return nil
+}
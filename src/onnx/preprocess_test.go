@@ -0,0 +1,86 @@
+package onnx_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+func TestParseResizeMode(t *testing.T) {
+	for name, want := range map[string]onnx.ResizeMode{"": onnx.ResizeStretch, "stretch": onnx.ResizeStretch, "letterbox": onnx.ResizeLetterbox} {
+		got, err := onnx.ParseResizeMode(name)
+		if err != nil || got != want {
+			t.Errorf("ParseResizeMode(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if _, err := onnx.ParseResizeMode("crop"); err == nil {
+		t.Errorf("Expected error for an unknown resize mode")
+	}
+	if onnx.ResizeLetterbox.String() != "letterbox" {
+		t.Errorf("Expected letterbox name, got %s", onnx.ResizeLetterbox.String())
+	}
+}
+
+func TestProcessorInputToData_Letterbox(t *testing.T) {
+	// A wide 8x4 white image in a 4x4 model input occupies the middle two rows.
+	img := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			img.Set(x, y, color.White)
+		}
+	}
+	p := &onnx.Processor{
+		Image:              img,
+		ModelHeight:        4,
+		ModelWidth:         4,
+		ModelInputChannels: 3,
+		Resize:             onnx.ResizeLetterbox,
+	}
+
+	data := make([]float32, 4*4*3)
+	if err := p.InputToData(data); err != nil {
+		t.Fatalf("InputToData returned error: %v", err)
+	}
+	gray := float32(114) / 255
+	for x := 0; x < 4; x++ {
+		if data[x] != gray || data[12+x] != gray {
+			t.Errorf("Expected padding in the first and last rows, got %v", data[:16])
+			break
+		}
+		if !approxEqual(data[4+x], 1, 1e-6) || !approxEqual(data[8+x], 1, 1e-6) {
+			t.Errorf("Expected image content in the middle rows, got %v", data[:16])
+			break
+		}
+	}
+	if p.Image.Bounds() != img.Bounds() {
+		t.Errorf("Expected the image to keep its bounds, got %v", p.Image.Bounds())
+	}
+}
+
+func TestProcessorOutputFromData_Letterbox(t *testing.T) {
+	// A 200x100 image is scaled by 0.5 into a 100x100 input, with 25 pixels of padding on top.
+	p := &onnx.Processor{
+		Image:               image.NewRGBA(image.Rect(0, 0, 200, 100)),
+		ModelClasses:        onnx.NewLabelSet([]string{"cat"}),
+		ModelHeight:         100,
+		ModelWidth:          100,
+		ModelOutputClasses:  1,
+		ModelDetections:     1,
+		ThresholdConfidence: 0.5,
+		Resize:              onnx.ResizeLetterbox,
+	}
+
+	boxes, err := p.OutputFromData([]float32{50, 50, 20, 10, 0.9})
+	if err != nil {
+		t.Fatalf("OutputFromData returned error: %v", err)
+	}
+	if len(boxes) != 1 {
+		t.Fatalf("Expected 1 box, got %d", len(boxes))
+	}
+	b := boxes[0]
+	if b.X1 != 80 || b.X2 != 120 || b.Y1 != 40 || b.Y2 != 60 {
+		t.Errorf("Expected box (80, 40), (120, 60), got %s", b.ToString())
+	}
+}
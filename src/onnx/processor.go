@@ -1,23 +1,41 @@
 package onnx
 
 import (
+	"context"
 	"fmt"
 	"image"
-	"sort"
 
-	"github.com/nfnt/resize"
 	ort "github.com/yalue/onnxruntime_go"
 )
 
+// defaultIoUThreshold is the IoU above which overlapping detections are merged during non-maximum suppression.
+const defaultIoUThreshold = 0.7
+
+// OutputLayout describes how detections are laid out in the output tensor of a detection model.
+type OutputLayout int
+
+const (
+	// LayoutYOLOv8 is the (4 + classes, detections) layout of YOLOv8 and YOLO11: one row per
+	// box coordinate then one row per class score, with a column per detection.
+	LayoutYOLOv8 OutputLayout = iota
+	// LayoutYOLOv5 is the (detections, 5 + classes) layout of YOLOv5 and YOLOv7: one row per
+	// detection with the box, an objectness score and the class scores. The confidence of a
+	// detection is its objectness multiplied by its best class score.
+	LayoutYOLOv5
+)
+
 // Processor handles image preprocessing and postprocessing for ONNX models.
 type Processor struct {
 	// Image is the image to be processed.
 	Image image.Image
-	// ModelClasses is the list of classes that the model can detect.
+	// Images are the images to be processed together by the batch methods.
+	// They are packed in order into a single input tensor.
+	Images []image.Image
+	// ModelClasses is the set of classes that the model can detect.
 	// This is used to map the class ID to the class name.
 	// It should match the order of classes in the model.
-	// For YOLOv11s, this is a list of 80 classes.
-	ModelClasses []string
+	// For YOLOv11s, this is a set of 80 classes.
+	ModelClasses LabelSet
 	// ModelHeight and ModelWidth are the dimensions to which input images are resized.
 	ModelHeight uint
 	ModelWidth  uint
@@ -29,6 +47,22 @@ type Processor struct {
 	ModelDetections uint
 	// ThresholdConfidence is the minimum confidence threshold for detections.
 	ThresholdConfidence float32
+	// ClassThresholds overrides ThresholdConfidence for the model labels it lists.
+	ClassThresholds map[string]float32
+	// ClassFilter selects the classes decoded; detections of other classes never reach NMS.
+	// Each detection takes the most confident class allowed by the filter which reaches its threshold.
+	ClassFilter ClassFilter
+	// ThresholdIoU is the IoU above which the least confident of two overlapping detections is
+	// dropped by non-maximum suppression. Zero uses the default of 0.7.
+	ThresholdIoU float32
+	// Layout is the layout of the model output, LayoutYOLOv8 by default.
+	Layout OutputLayout
+	// Resize is how images are fitted to the model input size, ResizeStretch by default.
+	Resize ResizeMode
+	// Remap renames, merges or drops classes after non-maximum suppression.
+	Remap ClassRemap
+	// KeepScores fills BoundingBox.Scores with the confidence of every class.
+	KeepScores bool
 }
 
 // Input prepares the input tensor for the model.
@@ -38,97 +72,189 @@ func (p *Processor) Input(tensor *ort.Tensor[float32]) error {
 
 // InputToData fills the input tensor with the image data.
 func (p *Processor) InputToData(data []float32) error {
-	channelSize := p.ModelHeight * p.ModelWidth
-	if len(data) < int(channelSize*p.ModelInputChannels) {
-		return fmt.Errorf("destination tensor only holds %d floats, needs %d (make sure it's the right shape!)", len(data), channelSize*p.ModelInputChannels)
+	return p.InputToDataContext(context.Background(), data)
+}
+
+// InputToDataContext fills the input tensor with the image data, stopping with an error
+// wrapping ctx.Err() when ctx ends before the image is fully processed.
+// Image is left as is, so the output is decoded against its bounds.
+func (p *Processor) InputToDataContext(ctx context.Context, data []float32) error {
+	return imageToData(ctx, p.Image, p.ModelWidth, p.ModelHeight, p.ModelInputChannels, p.Resize, data)
+}
+
+// Output processes the output of the model and returns a slice of bounding boxes.
+func (p *Processor) Output(tensor *ort.Tensor[float32]) ([]BoundingBox, error) {
+	return p.OutputFromData(tensor.GetData())
+}
+
+// OutputFromData processes the output data from the model and returns a slice of bounding boxes.
+// A ShapeError is returned when the output is too small for the expected detections and classes.
+// An image without any detection yields an empty slice and no error.
+func (p *Processor) OutputFromData(output []float32) ([]BoundingBox, error) {
+	return p.OutputFromDataContext(context.Background(), output)
+}
+
+// OutputFromDataContext is like OutputFromData, but stops with an error wrapping ctx.Err()
+// when ctx ends before decoding completes.
+func (p *Processor) OutputFromDataContext(ctx context.Context, output []float32) ([]BoundingBox, error) {
+	return p.OutputForBoundsContext(ctx, output, p.Image.Bounds())
+}
+
+// OutputForBoundsContext decodes the output of a single image with the given bounds, like
+// OutputFromDataContext but without the image itself, so outputs kept from an earlier run can
+// be decoded again with other thresholds, filters or NMS settings.
+func (p *Processor) OutputForBoundsContext(ctx context.Context, output []float32, bounds image.Rectangle) ([]BoundingBox, error) {
+	if err := checkShape("output", output, p.outputShape()...); err != nil {
+		return nil, err
+	}
+	return p.decode(ctx, output, bounds)
+}
+
+// InputBatch prepares the input tensor for a batch of images.
+func (p *Processor) InputBatch(tensor *ort.Tensor[float32]) error {
+	return p.InputBatchToData(tensor.GetData())
+}
+
+// InputBatchToData fills the input tensor with the data of every image of Images, one after the other.
+// When there are fewer images than the tensor holds, the remaining batch slots are zeroed, so a
+// final partial batch can run through a model with a fixed batch size.
+func (p *Processor) InputBatchToData(data []float32) error {
+	return p.InputBatchToDataContext(context.Background(), data)
+}
+
+// InputBatchToDataContext is like InputBatchToData, but stops with an error wrapping ctx.Err()
+// when ctx ends before every image is processed.
+func (p *Processor) InputBatchToDataContext(ctx context.Context, data []float32) error {
+	channels, height, width := int(p.ModelInputChannels), int(p.ModelHeight), int(p.ModelWidth)
+	if err := checkShape("input", data, len(p.Images), channels, height, width); err != nil {
+		return err
 	}
-	redChannel := data[0:channelSize]
-	greenChannel := data[channelSize : channelSize*2]
-	blueChannel := data[channelSize*2 : channelSize*3]
-
-	originalBounds := p.Image.Bounds()
-	originalWidth := originalBounds.Dx()
-	originalHeight := originalBounds.Dy()
-
-	p.Image = resize.Resize(p.ModelWidth, p.ModelHeight, p.Image, resize.Lanczos3)
-
-	i := 0
-	for y := 0; y < int(p.ModelHeight); y++ {
-		for x := 0; x < int(p.ModelWidth); x++ {
-			r, g, b, _ := p.Image.At(x, y).RGBA()
-			redChannel[i] = float32(r>>8) / 255.0
-			greenChannel[i] = float32(g>>8) / 255.0
-			blueChannel[i] = float32(b>>8) / 255.0
-			i++
+
+	imageSize := channels * height * width
+	for i, img := range p.Images {
+		if err := imageToData(ctx, img, p.ModelWidth, p.ModelHeight, p.ModelInputChannels, p.Resize, data[i*imageSize:(i+1)*imageSize]); err != nil {
+			return fmt.Errorf("failed to process image %d of the batch: %w", i, err)
 		}
 	}
+	clear(data[len(p.Images)*imageSize:])
+	return nil
+}
 
-	// Resize output image back to original dimensions and overwrite frame.Image
-	p.Image = resize.Resize(uint(originalWidth), uint(originalHeight), p.Image, resize.Lanczos3)
+// OutputBatch processes the output of the model for a batch of images.
+func (p *Processor) OutputBatch(tensor *ort.Tensor[float32]) ([][]BoundingBox, error) {
+	return p.OutputBatchFromData(tensor.GetData())
+}
 
-	return nil
+// OutputBatchFromData processes the output data of a batch and returns the bounding boxes of each
+// image of Images, in the same order. Results of padded batch slots are ignored.
+func (p *Processor) OutputBatchFromData(output []float32) ([][]BoundingBox, error) {
+	return p.OutputBatchFromDataContext(context.Background(), output)
 }
 
-// Output processes the output of the model and returns a slice of bounding boxes.
-func (p *Processor) Output(tensor *ort.Tensor[float32]) []BoundingBox {
-	return p.OutputFromData(tensor.GetData())
+// OutputBatchFromDataContext is like OutputBatchFromData, but stops with an error wrapping ctx.Err()
+// when ctx ends before every image is decoded.
+func (p *Processor) OutputBatchFromDataContext(ctx context.Context, output []float32) ([][]BoundingBox, error) {
+	shape := p.outputShape()
+	if err := checkShape("output", output, append([]int{len(p.Images)}, shape...)...); err != nil {
+		return nil, err
+	}
+
+	imageSize := shape[0] * shape[1]
+	results := make([][]BoundingBox, len(p.Images))
+	for i, img := range p.Images {
+		boxes, err := p.decode(ctx, output[i*imageSize:(i+1)*imageSize], img.Bounds())
+		if err != nil {
+			return nil, err
+		}
+		results[i] = boxes
+	}
+	return results, nil
 }
 
-// OutputFromData processes the output data from the model and returns a slice of bounding boxes.
-func (p *Processor) OutputFromData(output []float32) []BoundingBox {
-	if len(output) < int(p.ModelDetections*p.ModelOutputClasses) {
-		fmt.Printf("Output tensor does not have enough data for %d detections with %d classes", p.ModelDetections, p.ModelOutputClasses)
-		return nil
+// outputShape returns the shape of the output of a single image, according to the layout.
+func (p *Processor) outputShape() []int {
+	if p.Layout == LayoutYOLOv5 {
+		return []int{int(p.ModelDetections), int(p.ModelOutputClasses) + 5}
+	}
+	return []int{int(p.ModelOutputClasses) + 4, int(p.ModelDetections)}
+}
+
+// decode extracts the bounding boxes of a single image from its slice of the model output,
+// mapping them from the model input back to the image bounds.
+func (p *Processor) decode(ctx context.Context, output []float32, bounds image.Rectangle) ([]BoundingBox, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("decoding cancelled: %w", err)
 	}
 
-	boundingBoxes := make([]BoundingBox, 0, p.ModelDetections)
-	var classID int
-	var probability float32
-
-	for idx := 0; idx < int(p.ModelDetections); idx++ {
-		probability = -1e9
-		for col := 0; col < int(p.ModelOutputClasses); col++ {
-			currentProb := output[(int(p.ModelDetections)*(col+4))+idx]
-			if currentProb > probability {
-				probability = currentProb
-				classID = col
+	detections, classes := int(p.ModelDetections), int(p.ModelOutputClasses)
+	// value returns attribute attr of detection idx: the box coordinates come first, then the
+	// class scores, preceded by the objectness score in the YOLOv5 layout.
+	value := func(idx, attr int) float32 {
+		return output[attr*detections+idx]
+	}
+	firstClass := 4
+	if p.Layout == LayoutYOLOv5 {
+		value = func(idx, attr int) float32 {
+			return output[idx*(classes+5)+attr]
+		}
+		firstClass = 5
+	}
+
+	transform := newImageTransform(p.Resize, bounds, p.ModelWidth, p.ModelHeight)
+	thresholds := p.classThresholds()
+	boundingBoxes := make([]BoundingBox, 0)
+	if classes == 0 {
+		return boundingBoxes, nil
+	}
+	for idx := 0; idx < detections; idx++ {
+		score := func(class int) float32 {
+			if p.Layout == LayoutYOLOv5 {
+				return value(idx, firstClass+class) * value(idx, 4)
 			}
+			return value(idx, firstClass+class)
 		}
-		if probability < p.ThresholdConfidence {
+		classID, probability, ok := bestClass(thresholds, score)
+		if !ok {
 			continue
 		}
-		xc, yc := output[idx], output[int(p.ModelDetections)+idx]
-		w, h := output[2*int(p.ModelDetections)+idx], output[3*int(p.ModelDetections)+idx]
-		x1 := (xc - w/2) / float32(p.ModelWidth) * float32(p.Image.Bounds().Max.X)
-		y1 := (yc - h/2) / float32(p.ModelHeight) * float32(p.Image.Bounds().Max.Y)
-		x2 := (xc + w/2) / float32(p.ModelWidth) * float32(p.Image.Bounds().Max.X)
-		y2 := (yc + h/2) / float32(p.ModelHeight) * float32(p.Image.Bounds().Max.Y)
-		boundingBoxes = append(boundingBoxes, BoundingBox{
-			Label:      p.ModelClasses[classID],
+		label, err := p.ModelClasses.Name(classID)
+		if err != nil {
+			return nil, err
+		}
+		xc, yc := value(idx, 0), value(idx, 1)
+		w, h := value(idx, 2), value(idx, 3)
+		x1, y1 := transform.toImage(xc-w/2, yc-h/2)
+		x2, y2 := transform.toImage(xc+w/2, yc+h/2)
+		box := BoundingBox{
+			Label:      label,
+			ClassID:    classID,
 			Confidence: probability,
 			X1:         x1,
 			Y1:         y1,
 			X2:         x2,
 			Y2:         y2,
-		})
-	}
-
-	sort.Slice(boundingBoxes, func(i, j int) bool {
-		return boundingBoxes[i].Confidence < boundingBoxes[j].Confidence
-	})
-
-	mergedResults := make([]BoundingBox, 0, len(boundingBoxes))
-	for _, candidateBox := range boundingBoxes {
-		overlapsExistingBox := false
-		for _, existingBox := range mergedResults {
-			if (&candidateBox).IoU(&existingBox) > 0.7 {
-				overlapsExistingBox = true
-				break
-			}
+			Index:      idx,
 		}
-		if !overlapsExistingBox {
-			mergedResults = append(mergedResults, candidateBox)
+		if p.KeepScores {
+			box.Scores = make([]float32, classes)
+			for col := range box.Scores {
+				box.Scores[col] = score(col)
+			}
 		}
+		boundingBoxes = append(boundingBoxes, box)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("decoding cancelled: %w", err)
+	}
+
+	return p.Remap.Apply(NMS(boundingBoxes, p.iouThreshold()), p.ModelClasses), nil
+}
+
+// iouThreshold returns ThresholdIoU, or the default threshold when it is not set.
+func (p *Processor) iouThreshold() float32 {
+	if p.ThresholdIoU == 0 {
+		return defaultIoUThreshold
 	}
-	return mergedResults
+	return p.ThresholdIoU
 }
@@ -1,6 +1,8 @@
 package onnx_test
 
 import (
+	"context"
+	"errors"
 	"image"
 	"image/color"
 	"testing"
@@ -12,7 +14,7 @@ func TestProcessorOutputFromData_SingleDetection(t *testing.T) {
 	// Setup: 1 detection, 2 classes, threshold 0.5
 	modelDetections := uint(1)
 	modelOutputClasses := uint(2)
-	modelClasses := []string{"cat", "dog"}
+	modelClasses := onnx.NewLabelSet([]string{"cat", "dog"})
 	width, height := 100, 100
 
 	// Output tensor layout: [xc, yc, w, h, class1_prob, class2_prob]
@@ -32,7 +34,10 @@ func TestProcessorOutputFromData_SingleDetection(t *testing.T) {
 		ThresholdConfidence: 0.5,
 	}
 
-	boxes := p.OutputFromData(output)
+	boxes, err := p.OutputFromData(output)
+	if err != nil {
+		t.Fatalf("Processor.OutputFromData returned error: %v", err)
+	}
 
 	if len(boxes) != 1 {
 		t.Fatalf("Expected 1 bounding box, got %d", len(boxes))
@@ -58,7 +63,7 @@ func TestProcessorInputToData_FillsTensor(t *testing.T) {
 	}
 	modelDetections := uint(1)
 	modelOutputClasses := uint(2)
-	modelClasses := []string{"cat", "dog"}
+	modelClasses := onnx.NewLabelSet([]string{"cat", "dog"})
 
 	p := &onnx.Processor{
 		Image:               img,
@@ -91,3 +96,480 @@ func TestProcessorInputToData_FillsTensor(t *testing.T) {
 		t.Errorf("Expected tensor data to be filled, but all values are zero")
 	}
 }
+
+func TestProcessorInputToData_UnsupportedChannels(t *testing.T) {
+	p := &onnx.Processor{
+		Image:              image.NewRGBA(image.Rect(0, 0, 10, 10)),
+		ModelHeight:        10,
+		ModelWidth:         10,
+		ModelInputChannels: 1,
+	}
+	if err := p.InputToData(make([]float32, 100)); err == nil {
+		t.Error("Expected an error for a single channel input")
+	}
+}
+
+func TestProcessorOutputFromData_NoDetection(t *testing.T) {
+	p := &onnx.Processor{
+		Image:               image.NewRGBA(image.Rect(0, 0, 100, 100)),
+		ModelClasses:        onnx.NewLabelSet([]string{"cat", "dog"}),
+		ModelHeight:         100,
+		ModelWidth:          100,
+		ModelOutputClasses:  2,
+		ModelDetections:     1,
+		ThresholdConfidence: 0.5,
+	}
+
+	boxes, err := p.OutputFromData([]float32{50, 50, 20, 20, 0.1, 0.2})
+	if err != nil {
+		t.Fatalf("Expected no error for an empty scene, got %v", err)
+	}
+	if boxes == nil || len(boxes) != 0 {
+		t.Errorf("Expected an empty, non-nil slice, got %v", boxes)
+	}
+}
+
+func TestProcessorOutputFromData_ShapeError(t *testing.T) {
+	p := &onnx.Processor{
+		Image:              image.NewRGBA(image.Rect(0, 0, 100, 100)),
+		ModelClasses:       onnx.NewLabelSet([]string{"cat", "dog"}),
+		ModelOutputClasses: 2,
+		ModelDetections:    10,
+	}
+
+	_, err := p.OutputFromData(make([]float32, 20))
+	var shapeErr *onnx.ShapeError
+	if !errors.As(err, &shapeErr) {
+		t.Fatalf("Expected a ShapeError, got %v", err)
+	}
+	if shapeErr.Tensor != "output" || shapeErr.Expected != 60 || shapeErr.Got != 20 {
+		t.Errorf("Unexpected shape error %+v", shapeErr)
+	}
+}
+
+func TestProcessorInputBatchToData_PadsPartialBatch(t *testing.T) {
+	width, height, channels := 4, 4, 3
+	images := make([]image.Image, 2)
+	for i := range images {
+		img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 8; x++ {
+				img.Set(x, y, color.RGBA{R: uint8(100 * (i + 1)), A: 255})
+			}
+		}
+		images[i] = img
+	}
+
+	p := &onnx.Processor{
+		Images:             images,
+		ModelHeight:        uint(height),
+		ModelWidth:         uint(width),
+		ModelInputChannels: uint(channels),
+	}
+
+	imageSize := width * height * channels
+	data := make([]float32, 3*imageSize)
+	for i := range data {
+		data[i] = -1
+	}
+	if err := p.InputBatchToData(data); err != nil {
+		t.Fatalf("InputBatchToData returned error: %v", err)
+	}
+	if !approxEqual(data[0], 100.0/255, 1e-6) || !approxEqual(data[imageSize], 200.0/255, 1e-6) {
+		t.Errorf("Expected each image in its own batch slot, got %f and %f", data[0], data[imageSize])
+	}
+	for _, v := range data[2*imageSize:] {
+		if v != 0 {
+			t.Fatalf("Expected the padded batch slot to be zeroed, got %f", v)
+		}
+	}
+
+	if err := p.InputBatchToData(make([]float32, imageSize)); err == nil {
+		t.Errorf("Expected error when the batch tensor is too small for the images")
+	}
+}
+
+func TestProcessorOutputBatchFromData(t *testing.T) {
+	p := &onnx.Processor{
+		Images: []image.Image{
+			image.NewRGBA(image.Rect(0, 0, 100, 100)),
+			image.NewRGBA(image.Rect(0, 0, 200, 200)),
+		},
+		ModelClasses:        onnx.NewLabelSet([]string{"cat", "dog"}),
+		ModelHeight:         100,
+		ModelWidth:          100,
+		ModelOutputClasses:  2,
+		ModelDetections:     1,
+		ThresholdConfidence: 0.5,
+	}
+
+	// Three batch slots: a dog, a cat, and a padded slot which must be ignored.
+	output := []float32{
+		50, 50, 20, 20, 0.1, 0.9,
+		50, 50, 20, 20, 0.8, 0.1,
+		50, 50, 20, 20, 0.9, 0.9,
+	}
+	results, err := p.OutputBatchFromData(output)
+	if err != nil {
+		t.Fatalf("OutputBatchFromData returned error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected results for 2 images, got %d", len(results))
+	}
+	if len(results[0]) != 1 || results[0][0].Label != "dog" || results[0][0].X1 != 40 {
+		t.Errorf("Unexpected boxes for the first image: %v", results[0])
+	}
+	if len(results[1]) != 1 || results[1][0].Label != "cat" || results[1][0].X1 != 80 {
+		t.Errorf("Expected boxes scaled to the second image size, got %v", results[1])
+	}
+
+	if _, err := p.OutputBatchFromData(output[:6]); err == nil {
+		t.Errorf("Expected error when the output holds fewer images than the batch")
+	}
+}
+
+func TestProcessorContextVariants_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := &onnx.Processor{
+		Image:              image.NewRGBA(image.Rect(0, 0, 10, 10)),
+		Images:             []image.Image{image.NewRGBA(image.Rect(0, 0, 10, 10))},
+		ModelClasses:       onnx.NewLabelSet([]string{"cat", "dog"}),
+		ModelHeight:        10,
+		ModelWidth:         10,
+		ModelInputChannels: 3,
+		ModelOutputClasses: 2,
+		ModelDetections:    1,
+	}
+
+	if err := p.InputToDataContext(ctx, make([]float32, 300)); !errors.Is(err, context.Canceled) {
+		t.Errorf("InputToDataContext: expected context.Canceled, got %v", err)
+	}
+	if err := p.InputBatchToDataContext(ctx, make([]float32, 300)); !errors.Is(err, context.Canceled) {
+		t.Errorf("InputBatchToDataContext: expected context.Canceled, got %v", err)
+	}
+	if _, err := p.OutputFromDataContext(ctx, make([]float32, 6)); !errors.Is(err, context.Canceled) {
+		t.Errorf("OutputFromDataContext: expected context.Canceled, got %v", err)
+	}
+	if _, err := p.OutputBatchFromDataContext(ctx, make([]float32, 6)); !errors.Is(err, context.Canceled) {
+		t.Errorf("OutputBatchFromDataContext: expected context.Canceled, got %v", err)
+	}
+}
+
+func TestImageProcessorsContextVariants_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	input := make([]float32, 300)
+
+	classification := &onnx.ClassificationProcessor{
+		Image: img, ModelClasses: onnx.NewLabelSet([]string{"cat"}),
+		ModelHeight: 10, ModelWidth: 10, ModelInputChannels: 3, ModelOutputClasses: 1,
+	}
+	if err := classification.InputToDataContext(ctx, input); !errors.Is(err, context.Canceled) {
+		t.Errorf("ClassificationProcessor.InputToDataContext: expected context.Canceled, got %v", err)
+	}
+	if _, err := classification.OutputFromDataContext(ctx, []float32{1}); !errors.Is(err, context.Canceled) {
+		t.Errorf("ClassificationProcessor.OutputFromDataContext: expected context.Canceled, got %v", err)
+	}
+
+	segmentation := &onnx.SegmentationProcessor{
+		Image: img, ModelClasses: onnx.NewLabelSet([]string{"background"}),
+		ModelHeight: 10, ModelWidth: 10, ModelInputChannels: 3, ModelOutputClasses: 1, OutputHeight: 1, OutputWidth: 1,
+	}
+	if err := segmentation.InputToDataContext(ctx, input); !errors.Is(err, context.Canceled) {
+		t.Errorf("SegmentationProcessor.InputToDataContext: expected context.Canceled, got %v", err)
+	}
+	if _, err := segmentation.OutputFromDataContext(ctx, []float32{1}); !errors.Is(err, context.Canceled) {
+		t.Errorf("SegmentationProcessor.OutputFromDataContext: expected context.Canceled, got %v", err)
+	}
+
+	embedding := &onnx.EmbeddingProcessor{Image: img, ModelHeight: 10, ModelWidth: 10, ModelInputChannels: 3, EmbeddingSize: 1}
+	if err := embedding.InputToDataContext(ctx, input); !errors.Is(err, context.Canceled) {
+		t.Errorf("EmbeddingProcessor.InputToDataContext: expected context.Canceled, got %v", err)
+	}
+	if _, err := embedding.OutputFromDataContext(ctx, []float32{1}); !errors.Is(err, context.Canceled) {
+		t.Errorf("EmbeddingProcessor.OutputFromDataContext: expected context.Canceled, got %v", err)
+	}
+}
+
+func TestProcessorOutputFromData_YOLOv5Layout(t *testing.T) {
+	p := &onnx.Processor{
+		Image:               image.NewRGBA(image.Rect(0, 0, 100, 100)),
+		ModelClasses:        onnx.NewLabelSet([]string{"cat", "dog"}),
+		ModelHeight:         100,
+		ModelWidth:          100,
+		ModelOutputClasses:  2,
+		ModelDetections:     2,
+		ThresholdConfidence: 0.5,
+		Layout:              onnx.LayoutYOLOv5,
+	}
+
+	// One row per detection: [xc, yc, w, h, objectness, class1_prob, class2_prob].
+	output := []float32{
+		50, 50, 20, 20, 0.9, 0.1, 0.9,
+		20, 20, 10, 10, 0.4, 0.9, 0.1,
+	}
+	boxes, err := p.OutputFromData(output)
+	if err != nil {
+		t.Fatalf("OutputFromData returned error: %v", err)
+	}
+	if len(boxes) != 1 {
+		t.Fatalf("Expected the low objectness detection to be dropped, got %d boxes", len(boxes))
+	}
+	if boxes[0].Label != "dog" || !approxEqual(boxes[0].Confidence, 0.81, 1e-6) || boxes[0].X1 != 40 {
+		t.Errorf("Unexpected box %s", boxes[0].ToString())
+	}
+}
+
+func TestProcessorOutputFromData_ThresholdIoU(t *testing.T) {
+	// Two overlapping dogs with an IoU of about 0.67.
+	output := []float32{
+		50, 54, // xc
+		50, 50, // yc
+		20, 20, // w
+		20, 20, // h
+		0.1, 0.1, // class1_prob
+		0.9, 0.8, // class2_prob
+	}
+	p := &onnx.Processor{
+		Image:               image.NewRGBA(image.Rect(0, 0, 100, 100)),
+		ModelClasses:        onnx.NewLabelSet([]string{"cat", "dog"}),
+		ModelHeight:         100,
+		ModelWidth:          100,
+		ModelOutputClasses:  2,
+		ModelDetections:     2,
+		ThresholdConfidence: 0.5,
+	}
+
+	boxes, _ := p.OutputFromData(output)
+	if len(boxes) != 2 {
+		t.Errorf("Expected both boxes with the default IoU threshold, got %d", len(boxes))
+	}
+	p.ThresholdIoU = 0.5
+	boxes, _ = p.OutputFromData(output)
+	if len(boxes) != 1 || boxes[0].Confidence != 0.9 {
+		t.Errorf("Expected only the most confident box with IoU threshold 0.5, got %v", boxes)
+	}
+}
+
+func TestProcessorOutputFromData_ClassIDOutOfRange(t *testing.T) {
+	// The model outputs 2 classes but only one label is known.
+	output := []float32{50, 50, 20, 20, 0.1, 0.9}
+	p := &onnx.Processor{
+		Image:               image.NewRGBA(image.Rect(0, 0, 100, 100)),
+		ModelClasses:        onnx.NewLabelSet([]string{"cat"}),
+		ModelHeight:         100,
+		ModelWidth:          100,
+		ModelOutputClasses:  2,
+		ModelDetections:     1,
+		ThresholdConfidence: 0.5,
+	}
+
+	if _, err := p.OutputFromData(output); err == nil {
+		t.Error("Expected an error for a class ID without label")
+	}
+}
+
+func TestProcessorOutputFromData_Remap(t *testing.T) {
+	// A car, a truck and a person far apart.
+	output := []float32{
+		10, 50, 90, // xc
+		10, 50, 90, // yc
+		10, 10, 10, // w
+		10, 10, 10, // h
+		0.9, 0.1, 0.1, // car
+		0.1, 0.8, 0.1, // truck
+		0.1, 0.1, 0.7, // person
+	}
+	p := &onnx.Processor{
+		Image:               image.NewRGBA(image.Rect(0, 0, 100, 100)),
+		ModelClasses:        onnx.NewLabelSet([]string{"car", "truck", "person"}),
+		ModelHeight:         100,
+		ModelWidth:          100,
+		ModelOutputClasses:  3,
+		ModelDetections:     3,
+		ThresholdConfidence: 0.5,
+		Remap:               onnx.ClassRemap{"car": "vehicle", "truck": "vehicle", "person": ""},
+	}
+
+	boxes, err := p.OutputFromData(output)
+	if err != nil {
+		t.Fatalf("Processor.OutputFromData returned error: %v", err)
+	}
+	if len(boxes) != 2 || boxes[0].Label != "vehicle" || boxes[1].Label != "vehicle" {
+		t.Errorf("Expected two vehicles and no person, got %v", boxes)
+	}
+	for _, box := range boxes {
+		if box.ClassID != 0 {
+			t.Errorf("Expected merged vehicles to have the class ID 0 of the remapped labels, got %d", box.ClassID)
+		}
+	}
+}
+
+func TestProcessorOutputFromData_ClassIDIndexAndScores(t *testing.T) {
+	// The second detection is a dog.
+	output := []float32{
+		0, 50, // xc
+		0, 50, // yc
+		0, 20, // w
+		0, 20, // h
+		0.0, 0.3, // cat
+		0.0, 0.7, // dog
+	}
+	p := &onnx.Processor{
+		Image:               image.NewRGBA(image.Rect(0, 0, 100, 100)),
+		ModelClasses:        onnx.NewLabelSet([]string{"cat", "dog"}),
+		ModelHeight:         100,
+		ModelWidth:          100,
+		ModelOutputClasses:  2,
+		ModelDetections:     2,
+		ThresholdConfidence: 0.5,
+		Remap:               onnx.ClassRemap{"dog": "pet"},
+	}
+
+	boxes, err := p.OutputFromData(output)
+	if err != nil || len(boxes) != 1 {
+		t.Fatalf("Expected 1 box, got %v, %v", boxes, err)
+	}
+	if boxes[0].Label != "pet" || boxes[0].ClassID != 1 || boxes[0].Index != 1 {
+		t.Errorf("Expected remapped class ID 1 from detection 1, got %+v", boxes[0])
+	}
+	if boxes[0].Scores != nil {
+		t.Errorf("Expected no scores without KeepScores, got %v", boxes[0].Scores)
+	}
+
+	p.KeepScores = true
+	boxes, _ = p.OutputFromData(output)
+	if scores := boxes[0].Scores; len(scores) != 2 || scores[0] != 0.3 || scores[1] != 0.7 {
+		t.Errorf("Expected scores [0.3 0.7], got %v", scores)
+	}
+}
+
+func TestProcessorOutputForBoundsContext(t *testing.T) {
+	output := []float32{
+		50, 50, 20, 20,
+		0.1, 0.9,
+	}
+	p := &onnx.Processor{
+		ModelClasses:        onnx.NewLabelSet([]string{"cat", "dog"}),
+		ModelHeight:         100,
+		ModelWidth:          100,
+		ModelInputChannels:  3,
+		ModelOutputClasses:  2,
+		ModelDetections:     1,
+		ThresholdConfidence: 0.5,
+	}
+
+	// The 100x100 model input maps to a 200x100 image.
+	boxes, err := p.OutputForBoundsContext(context.Background(), output, image.Rect(0, 0, 200, 100))
+	if err != nil {
+		t.Fatalf("Processor.OutputForBoundsContext returned error: %v", err)
+	}
+	if len(boxes) != 1 || boxes[0].Label != "dog" || boxes[0].X1 != 80 || boxes[0].X2 != 120 || boxes[0].Y1 != 40 {
+		t.Errorf("Unexpected boxes %+v", boxes)
+	}
+
+	p.ThresholdConfidence = 0.95
+	boxes, err = p.OutputForBoundsContext(context.Background(), output, image.Rect(0, 0, 200, 100))
+	if err != nil || len(boxes) != 0 {
+		t.Errorf("Expected no box above 0.95, got %+v, %v", boxes, err)
+	}
+
+	if _, err := p.OutputForBoundsContext(context.Background(), output[:4], image.Rect(0, 0, 200, 100)); err == nil {
+		t.Error("Expected a shape error for a truncated output")
+	}
+}
+
+// Regression test: decoding used to sort detections by ascending confidence before NMS,
+// keeping the weakest of two overlapping boxes.
+func TestProcessorOutputFromData_NMSKeepsMostConfident(t *testing.T) {
+	// Two nearly identical dogs, the weaker one first in the output.
+	output := []float32{
+		50, 51, // xc
+		50, 50, // yc
+		20, 20, // w
+		20, 20, // h
+		0.1, 0.1, // class1_prob
+		0.6, 0.9, // class2_prob
+	}
+	p := &onnx.Processor{
+		Image:               image.NewRGBA(image.Rect(0, 0, 100, 100)),
+		ModelClasses:        onnx.NewLabelSet([]string{"cat", "dog"}),
+		ModelHeight:         100,
+		ModelWidth:          100,
+		ModelOutputClasses:  2,
+		ModelDetections:     2,
+		ThresholdConfidence: 0.5,
+	}
+	boxes, err := p.OutputFromData(output)
+	if err != nil {
+		t.Fatalf("OutputFromData returned error: %v", err)
+	}
+	if len(boxes) != 1 || boxes[0].Confidence != 0.9 || boxes[0].X1 != 41 {
+		t.Errorf("Expected only the most confident box, got %+v", boxes)
+	}
+}
+
+// Regression test: boxes used to be scaled to bounds.Max instead of the size of the bounds,
+// misplacing them on images whose bounds do not start at the origin, such as sub-images.
+func TestProcessorOutputFromData_OffsetBounds(t *testing.T) {
+	output := []float32{
+		50, 50, 20, 20,
+		0.1, 0.9,
+	}
+	// A 200x100 sub-image starting at (100, 50).
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200)).SubImage(image.Rect(100, 50, 300, 150))
+	p := &onnx.Processor{
+		Image:               img,
+		ModelClasses:        onnx.NewLabelSet([]string{"cat", "dog"}),
+		ModelHeight:         100,
+		ModelWidth:          100,
+		ModelOutputClasses:  2,
+		ModelDetections:     1,
+		ThresholdConfidence: 0.5,
+	}
+	boxes, err := p.OutputFromData(output)
+	if err != nil {
+		t.Fatalf("OutputFromData returned error: %v", err)
+	}
+	want := onnx.BoundingBox{X1: 180, Y1: 90, X2: 220, Y2: 110}
+	if len(boxes) != 1 || boxes[0].X1 != want.X1 || boxes[0].Y1 != want.Y1 || boxes[0].X2 != want.X2 || boxes[0].Y2 != want.Y2 {
+		t.Errorf("Expected a box at %v in image coordinates, got %+v", want.ToRect(), boxes)
+	}
+}
+
+// Regression test: InputToData used to replace Image with a resized copy starting at (0, 0),
+// so boxes decoded after it lost the origin of sub-images.
+func TestProcessorInputThenOutput_OffsetBounds(t *testing.T) {
+	output := []float32{
+		50, 50, 20, 20,
+		0.1, 0.9,
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200)).SubImage(image.Rect(100, 50, 300, 150))
+	p := &onnx.Processor{
+		Image:               img,
+		ModelClasses:        onnx.NewLabelSet([]string{"cat", "dog"}),
+		ModelHeight:         100,
+		ModelWidth:          100,
+		ModelInputChannels:  3,
+		ModelOutputClasses:  2,
+		ModelDetections:     1,
+		ThresholdConfidence: 0.5,
+	}
+	if err := p.InputToData(make([]float32, 3*100*100)); err != nil {
+		t.Fatalf("InputToData returned error: %v", err)
+	}
+	if p.Image != img {
+		t.Errorf("Expected InputToData to leave Image unchanged, got bounds %v", p.Image.Bounds())
+	}
+	boxes, err := p.OutputFromData(output)
+	if err != nil {
+		t.Fatalf("OutputFromData returned error: %v", err)
+	}
+	want := onnx.BoundingBox{X1: 180, Y1: 90, X2: 220, Y2: 110}
+	if len(boxes) != 1 || boxes[0].X1 != want.X1 || boxes[0].Y1 != want.Y1 || boxes[0].X2 != want.X2 || boxes[0].Y2 != want.Y2 {
+		t.Errorf("Expected a box at %v in image coordinates, got %+v", want.ToRect(), boxes)
+	}
+}
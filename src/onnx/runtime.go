@@ -1,6 +1,10 @@
 // Package onnx provides structures and methods for handling ONNX model runtime configurations.
 package onnx
 
+import (
+	ort "github.com/yalue/onnxruntime_go"
+)
+
 // OnnxRuntime holds the model path and library paths for neural inference.
 type OnnxRuntime struct {
 	// modelPath is the path to the ML model file.
@@ -11,8 +15,18 @@ type OnnxRuntime struct {
 	tensorInputShape TensorInputShape
 	// TensorOutputShape
 	tensorOutputShape TensorOutputShape
+	// inputName and outputName are the names of the model input and output tensors.
+	inputName  string
+	outputName string
 }
 
+const (
+	// DefaultInputName is the name of the input tensor of models exported by Ultralytics.
+	DefaultInputName = "images"
+	// DefaultOutputName is the name of the output tensor of models exported by Ultralytics.
+	DefaultOutputName = "output0"
+)
+
 // TensorInputShape defines the expected shape of input tensors for the ONNX model.
 type TensorInputShape struct {
 	BatchSize int64
@@ -22,10 +36,28 @@ type TensorInputShape struct {
 }
 
 // TensorOutputShape defines the expected shape of output tensors for the ONNX model.
+// Detection models output (BatchSize, Classes, Detections). Classification models
+// output a single score vector per image and leave Detections to zero.
+// Segmentation models output (BatchSize, Classes, Height, Width) score planes.
 type TensorOutputShape struct {
 	BatchSize  int64
 	Classes    int64
 	Detections int64
+	Height     int64
+	Width      int64
+}
+
+// Shape returns the ONNX shape described by the TensorOutputShape.
+// The detections axis is omitted when Detections is zero, and replaced by the
+// plane dimensions when Height and Width are set.
+func (s TensorOutputShape) Shape() ort.Shape {
+	if s.Height > 0 && s.Width > 0 {
+		return ort.NewShape(s.BatchSize, s.Classes, s.Height, s.Width)
+	}
+	if s.Detections == 0 {
+		return ort.NewShape(s.BatchSize, s.Classes)
+	}
+	return ort.NewShape(s.BatchSize, s.Classes, s.Detections)
 }
 
 // NewOnnxRuntime creates a new OnnxRuntime instance with the given model and library paths and tensor shapes.
@@ -35,9 +67,19 @@ func NewOnnxRuntime(modelPath, libraryPath string, inputShape TensorInputShape,
 		libraryPath:       libraryPath,
 		tensorInputShape:  inputShape,
 		tensorOutputShape: outputShape,
+		inputName:         DefaultInputName,
+		outputName:        DefaultOutputName,
 	}
 }
 
+// WithTensorNames sets the names of the model input and output tensors, for models which do
+// not use DefaultInputName and DefaultOutputName. It returns the OnnxRuntime for chaining.
+func (o *OnnxRuntime) WithTensorNames(inputName, outputName string) *OnnxRuntime {
+	o.inputName = inputName
+	o.outputName = outputName
+	return o
+}
+
 // GetModelPath returns the path to the ONNX model file.
 func (o *OnnxRuntime) GetModelPath() string {
 	return o.modelPath
@@ -57,3 +99,13 @@ func (o *OnnxRuntime) GetTensorInputShape() TensorInputShape {
 func (o *OnnxRuntime) GetTensorOutputShape() TensorOutputShape {
 	return o.tensorOutputShape
 }
+
+// GetInputName returns the name of the model input tensor.
+func (o *OnnxRuntime) GetInputName() string {
+	return o.inputName
+}
+
+// GetOutputName returns the name of the model output tensor.
+func (o *OnnxRuntime) GetOutputName() string {
+	return o.outputName
+}
@@ -46,3 +46,31 @@ func TestNewOnnxRuntime_EdgeCases(t *testing.T) {
 		t.Errorf("Expected empty output shape, got %+v", r.GetTensorOutputShape())
 	}
 }
+
+func TestTensorOutputShape_Shape(t *testing.T) {
+	detection := onnx.TensorOutputShape{BatchSize: 1, Classes: 84, Detections: 8400}
+	if got := detection.Shape().String(); got != "[1 84 8400]" {
+		t.Errorf("Expected detection shape [1 84 8400], got %s", got)
+	}
+	classification := onnx.TensorOutputShape{BatchSize: 1, Classes: 1000}
+	if got := classification.Shape().String(); got != "[1 1000]" {
+		t.Errorf("Expected classification shape [1 1000], got %s", got)
+	}
+	segmentation := onnx.TensorOutputShape{BatchSize: 1, Classes: 21, Height: 128, Width: 128}
+	if got := segmentation.Shape().String(); got != "[1 21 128 128]" {
+		t.Errorf("Expected segmentation shape [1 21 128 128], got %s", got)
+	}
+}
+
+func TestOnnxRuntime_TensorNames(t *testing.T) {
+	r := onnx.NewOnnxRuntime("model.onnx", "libonnx.so", onnx.TensorInputShape{}, onnx.TensorOutputShape{})
+	if r.GetInputName() != onnx.DefaultInputName || r.GetOutputName() != onnx.DefaultOutputName {
+		t.Errorf("Expected default tensor names, got %s and %s", r.GetInputName(), r.GetOutputName())
+	}
+	if r.WithTensorNames("input", "logits") != r {
+		t.Errorf("Expected WithTensorNames to return the runtime")
+	}
+	if r.GetInputName() != "input" || r.GetOutputName() != "logits" {
+		t.Errorf("Expected custom tensor names, got %s and %s", r.GetInputName(), r.GetOutputName())
+	}
+}
@@ -0,0 +1,197 @@
+package onnx
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// SegmentationProcessor handles image preprocessing and postprocessing for semantic
+// segmentation models (DeepLabV3, SegFormer...) which output per-pixel class scores
+// with a shape of (1, Classes, OutputHeight, OutputWidth).
+type SegmentationProcessor struct {
+	// Image is the image to be processed.
+	Image image.Image
+	// ModelClasses is the set of classes that the model can segment.
+	// It should match the order of classes in the model.
+	ModelClasses LabelSet
+	// ModelHeight and ModelWidth are the dimensions to which input images are resized.
+	ModelHeight uint
+	ModelWidth  uint
+	// ModelInputChannels is the number of channels in the input image (3 for RGB).
+	ModelInputChannels uint
+	// ModelOutputClasses is the number of score planes the model outputs.
+	ModelOutputClasses uint
+	// OutputHeight and OutputWidth are the dimensions of the score planes.
+	// Some models output a downsampled map, e.g. a quarter of the input size for SegFormer.
+	OutputHeight uint
+	OutputWidth  uint
+	// Palette is the color of each class in the label map, with at least one color per class.
+	// When nil, a palette is generated with NewClassPalette, with class 0, the background,
+	// transparent.
+	Palette color.Palette
+}
+
+// ClassArea holds the pixel area covered by a class in a segmentation result.
+type ClassArea struct {
+	Label string
+	Index int
+	// Pixels is the number of pixels assigned to the class.
+	Pixels int
+	// Fraction is the share of the image covered by the class, in [0, 1].
+	Fraction float32
+}
+
+// SegmentationResult is the output of a segmentation model, at the original image size.
+type SegmentationResult struct {
+	// LabelMap holds the class index of every pixel, rendered with the class palette.
+	LabelMap *image.Paletted
+	// Areas lists the classes present in the image, from the largest to the smallest.
+	Areas []ClassArea
+}
+
+// Input prepares the input tensor for the model.
+func (p *SegmentationProcessor) Input(tensor *ort.Tensor[float32]) error {
+	return p.InputToData(tensor.GetData())
+}
+
+// InputToData fills the input tensor with the image data.
+func (p *SegmentationProcessor) InputToData(data []float32) error {
+	return p.InputToDataContext(context.Background(), data)
+}
+
+// InputToDataContext fills the input tensor with the image data, stopping with an error
+// wrapping ctx.Err() when ctx ends before the image is fully processed.
+func (p *SegmentationProcessor) InputToDataContext(ctx context.Context, data []float32) error {
+	return imageToData(ctx, p.Image, p.ModelWidth, p.ModelHeight, p.ModelInputChannels, ResizeStretch, data)
+}
+
+// Output processes the output of the model and returns the segmentation result.
+func (p *SegmentationProcessor) Output(tensor *ort.Tensor[float32]) (*SegmentationResult, error) {
+	return p.OutputFromData(tensor.GetData())
+}
+
+// OutputFromData computes the per-pixel argmax of the model scores, resizes the label map
+// back to the original image size and computes the area covered by each class.
+func (p *SegmentationProcessor) OutputFromData(output []float32) (*SegmentationResult, error) {
+	return p.OutputFromDataContext(context.Background(), output)
+}
+
+// OutputFromDataContext is like OutputFromData, but stops with an error wrapping ctx.Err()
+// when ctx ends before postprocessing completes. Cancellation is checked between rows.
+func (p *SegmentationProcessor) OutputFromDataContext(ctx context.Context, output []float32) (*SegmentationResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("postprocessing cancelled: %w", err)
+	}
+	if p.OutputHeight == 0 || p.OutputWidth == 0 {
+		return nil, fmt.Errorf("invalid score map size %dx%d, both dimensions must be positive", p.OutputWidth, p.OutputHeight)
+	}
+	if p.ModelOutputClasses == 0 {
+		return nil, fmt.Errorf("invalid class count 0, the model must output at least one score plane")
+	}
+	classes := int(p.ModelOutputClasses)
+	planeSize := int(p.OutputHeight * p.OutputWidth)
+	if err := checkShape("output", output, classes, int(p.OutputHeight), int(p.OutputWidth)); err != nil {
+		return nil, err
+	}
+	if classes > 256 {
+		return nil, fmt.Errorf("label maps support at most 256 classes, model outputs %d", classes)
+	}
+	if p.ModelClasses.Len() < classes {
+		return nil, fmt.Errorf("model outputs %d classes but only %d class labels are defined", classes, p.ModelClasses.Len())
+	}
+
+	labels := make([]uint8, planeSize)
+	for idx := 0; idx < planeSize; idx++ {
+		best := output[idx]
+		for c := 1; c < classes; c++ {
+			if score := output[c*planeSize+idx]; score > best {
+				best = score
+				labels[idx] = uint8(c)
+			}
+		}
+	}
+
+	palette := p.Palette
+	if palette == nil {
+		// Class 0 is the background of segmentation models, left transparent so overlays keep it.
+		palette = NewClassPalette(classes)
+		palette[0] = color.RGBA{}
+	} else if len(palette) < classes {
+		return nil, fmt.Errorf("palette holds %d colors for %d classes", len(palette), classes)
+	}
+
+	// Nearest neighbour resize, so every pixel keeps a valid class index.
+	bounds := p.Image.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	labelMap := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+	pixels := make([]int, classes)
+	for y := 0; y < height; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("postprocessing cancelled: %w", err)
+		}
+		sy := y * int(p.OutputHeight) / height
+		for x := 0; x < width; x++ {
+			sx := x * int(p.OutputWidth) / width
+			label := labels[sy*int(p.OutputWidth)+sx]
+			labelMap.Pix[y*labelMap.Stride+x] = label
+			pixels[label]++
+		}
+	}
+
+	areas := make([]ClassArea, 0)
+	for idx, count := range pixels {
+		if count == 0 {
+			continue
+		}
+		label, err := p.ModelClasses.Name(idx)
+		if err != nil {
+			return nil, err
+		}
+		areas = append(areas, ClassArea{
+			Label:    label,
+			Index:    idx,
+			Pixels:   count,
+			Fraction: float32(count) / float32(width*height),
+		})
+	}
+	sort.SliceStable(areas, func(i, j int) bool {
+		return areas[i].Pixels > areas[j].Pixels
+	})
+
+	return &SegmentationResult{LabelMap: labelMap, Areas: areas}, nil
+}
+
+// Gray returns the label map as a grayscale image whose pixel values are the class indices.
+func (r *SegmentationResult) Gray() *image.Gray {
+	gray := image.NewGray(r.LabelMap.Bounds())
+	copy(gray.Pix, r.LabelMap.Pix)
+	return gray
+}
+
+// Overlay blends the class colors of the label map onto a copy of img.
+// Alpha is the opacity of the overlay in [0, 1]; classes whose palette color is fully
+// transparent, such as a background, leave the image untouched.
+func (r *SegmentationResult) Overlay(img image.Image, alpha float64) *image.RGBA {
+	bounds := img.Bounds()
+	overlay := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	labelBounds := r.LabelMap.Bounds()
+
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			src := color.RGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.RGBA)
+			if x < labelBounds.Dx() && y < labelBounds.Dy() {
+				classColor := color.RGBAModel.Convert(r.LabelMap.At(x, y)).(color.RGBA)
+				if classColor.A != 0 {
+					src = Blend(src, classColor, alpha)
+				}
+			}
+			overlay.SetRGBA(x, y, src)
+		}
+	}
+	return overlay
+}
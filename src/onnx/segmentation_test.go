@@ -0,0 +1,133 @@
+package onnx_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+// segmentationOutput returns a 2 class, 2x2 score map where the right column is "road".
+func segmentationOutput() []float32 {
+	return []float32{
+		0.9, 0.1, // background scores, row 0
+		0.8, 0.3, // background scores, row 1
+		0.1, 0.9, // road scores, row 0
+		0.2, 0.7, // road scores, row 1
+	}
+}
+
+func TestSegmentationProcessorOutputFromData(t *testing.T) {
+	p := &onnx.SegmentationProcessor{
+		Image:              image.NewRGBA(image.Rect(0, 0, 4, 4)),
+		ModelClasses:       onnx.NewLabelSet([]string{"background", "road"}),
+		ModelOutputClasses: 2,
+		OutputHeight:       2,
+		OutputWidth:        2,
+	}
+
+	result, err := p.OutputFromData(segmentationOutput())
+	if err != nil {
+		t.Fatalf("OutputFromData returned error: %v", err)
+	}
+	if result.LabelMap.Bounds() != image.Rect(0, 0, 4, 4) {
+		t.Fatalf("Expected label map at the original size, got %v", result.LabelMap.Bounds())
+	}
+	if result.LabelMap.ColorIndexAt(0, 3) != 0 || result.LabelMap.ColorIndexAt(3, 0) != 1 {
+		t.Errorf("Unexpected label map %v", result.LabelMap.Pix)
+	}
+	if len(result.Areas) != 2 || result.Areas[0].Pixels != 8 || result.Areas[0].Fraction != 0.5 {
+		t.Errorf("Expected two classes covering half of the image each, got %+v", result.Areas)
+	}
+	if gray := result.Gray(); gray.GrayAt(3, 3).Y != 1 {
+		t.Errorf("Expected gray value 1 for road, got %d", gray.GrayAt(3, 3).Y)
+	}
+}
+
+func TestSegmentationProcessorOutputFromData_Errors(t *testing.T) {
+	p := &onnx.SegmentationProcessor{
+		Image:              image.NewRGBA(image.Rect(0, 0, 4, 4)),
+		ModelClasses:       onnx.NewLabelSet([]string{"background"}),
+		ModelOutputClasses: 2,
+		OutputHeight:       2,
+		OutputWidth:        2,
+	}
+	if _, err := p.OutputFromData(segmentationOutput()[:4]); err == nil {
+		t.Errorf("Expected error for a truncated output tensor")
+	}
+	if _, err := p.OutputFromData(segmentationOutput()); err == nil {
+		t.Errorf("Expected error when class labels are missing")
+	}
+
+	p.ModelClasses = onnx.NewLabelSet([]string{"background", "road"})
+	p.OutputWidth = 0
+	if _, err := p.OutputFromData(nil); err == nil {
+		t.Errorf("Expected error for a zero output width")
+	}
+	p.OutputWidth, p.OutputHeight = 2, 0
+	if _, err := p.OutputFromData(nil); err == nil {
+		t.Errorf("Expected error for a zero output height")
+	}
+	p.OutputHeight, p.Palette = 2, color.Palette{color.RGBA{}}
+	if _, err := p.OutputFromData(segmentationOutput()); err == nil {
+		t.Errorf("Expected error for a palette shorter than the classes")
+	}
+	p.ModelOutputClasses = 0
+	if _, err := p.OutputFromData(nil); err == nil {
+		t.Errorf("Expected error for zero classes")
+	}
+}
+
+func TestSegmentationResult_Overlay(t *testing.T) {
+	p := &onnx.SegmentationProcessor{
+		Image:              image.NewRGBA(image.Rect(0, 0, 2, 2)),
+		ModelClasses:       onnx.NewLabelSet([]string{"background", "road"}),
+		ModelOutputClasses: 2,
+		OutputHeight:       2,
+		OutputWidth:        2,
+		Palette:            color.Palette{color.RGBA{}, color.RGBA{R: 255, A: 255}},
+	}
+	result, err := p.OutputFromData(segmentationOutput())
+	if err != nil {
+		t.Fatalf("OutputFromData returned error: %v", err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	overlay := result.Overlay(img, 0.5)
+	if got := overlay.RGBAAt(0, 0); got != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("Expected transparent background to keep the pixel, got %v", got)
+	}
+	if got := overlay.RGBAAt(1, 0); got != (color.RGBA{R: 255, G: 128, B: 128, A: 255}) {
+		t.Errorf("Expected road pixel blended with red, got %v", got)
+	}
+}
+
+func TestSegmentationResult_OverlayDefaultPalette(t *testing.T) {
+	p := &onnx.SegmentationProcessor{
+		Image:              image.NewRGBA(image.Rect(0, 0, 2, 2)),
+		ModelClasses:       onnx.NewLabelSet([]string{"background", "road"}),
+		ModelOutputClasses: 2,
+		OutputHeight:       2,
+		OutputWidth:        2,
+	}
+	result, err := p.OutputFromData(segmentationOutput())
+	if err != nil {
+		t.Fatalf("OutputFromData returned error: %v", err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for i := range img.Pix {
+		img.Pix[i] = 200
+	}
+	overlay := result.Overlay(img, 0.5)
+	if got := overlay.RGBAAt(0, 0); got != (color.RGBA{R: 200, G: 200, B: 200, A: 200}) {
+		t.Errorf("Expected the background pixel to keep its value, got %v", got)
+	}
+	if got := overlay.RGBAAt(1, 0); got == (color.RGBA{R: 200, G: 200, B: 200, A: 200}) {
+		t.Errorf("Expected the road pixel to be tinted, got %v", got)
+	}
+}
@@ -1,6 +1,12 @@
 package onnx
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
 	ort "github.com/yalue/onnxruntime_go"
 )
 
@@ -10,6 +16,12 @@ type ONNXSession struct {
 	TensorInput  *ort.Tensor[float32]
 	TensorOutput *ort.Tensor[float32]
 	Options      *ort.SessionOptions
+	// Metadata is the metadata stored in the model file.
+	Metadata ModelMetadata
+
+	// slot holds a token while the session is in use, since runs share the input and output tensors.
+	slot     chan struct{}
+	slotOnce sync.Once
 }
 
 // InputTensor represents the input tensor for the ONNX model.
@@ -26,13 +38,12 @@ type OutputTensor struct {
 	Data []float32
 }
 
-// NewONNXSession initializes the ONNX model and session.
+// NewONNXSession initializes the ONNX model and session, and reads the model metadata.
+// The onnxruntime environment is initialized by the first session and shared by the next ones.
 func NewONNXSession(nr *OnnxRuntime) (*ONNXSession, error) {
 	onnxSession := &ONNXSession{}
 
-	ort.SetSharedLibraryPath(nr.libraryPath)
-
-	err := ort.InitializeEnvironment()
+	err := initializeEnvironment(nr.libraryPath)
 	if err != nil {
 		return nil, err
 	}
@@ -42,7 +53,7 @@ func NewONNXSession(nr *OnnxRuntime) (*ONNXSession, error) {
 
 	// Create the ONNX session with the model path and input/output tensors
 	session, err := ort.NewAdvancedSession(nr.modelPath,
-		[]string{"images"}, []string{"output0"},
+		[]string{nr.inputName}, []string{nr.outputName},
 		[]ort.ArbitraryTensor{onnxSession.TensorInput},
 		[]ort.ArbitraryTensor{onnxSession.TensorOutput},
 		onnxSession.Options)
@@ -54,6 +65,17 @@ func NewONNXSession(nr *OnnxRuntime) (*ONNXSession, error) {
 
 	onnxSession.Session = session
 
+	metadata, err := session.GetModelMetadata()
+	if err != nil {
+		onnxSession.Close()
+		return nil, fmt.Errorf("failed to get model metadata: %w", err)
+	}
+	onnxSession.Metadata, err = readModelMetadata(metadata)
+	if err != nil {
+		onnxSession.Close()
+		return nil, err
+	}
+
 	return onnxSession, nil
 }
 
@@ -90,11 +112,92 @@ func (onnxSession *ONNXSession) SetInputTensor(shape TensorInputShape) {
 // Adjust this shape based on your specific model requirements
 // For example, if the model outputs bounding boxes, you might have a different shape
 // Here we assume the output is a tensor with 84 classes and 8400 detections
+// Classification models use a zero Detections to get a (1, 1000) like tensor
 func (onnxSession *ONNXSession) SetOutputTensor(shape TensorOutputShape) {
-	outputShape := ort.NewShape(shape.BatchSize, shape.Classes, shape.Detections)
-	outputTensor, err := ort.NewEmptyTensor[float32](outputShape)
+	outputTensor, err := ort.NewEmptyTensor[float32](shape.Shape())
 	if err != nil {
 		onnxSession.Close()
 	}
 	onnxSession.TensorOutput = outputTensor
 }
+
+// Acquire waits until the session is free and reserves it for the caller, who must call Release.
+// It returns an error wrapping ctx.Err() when ctx ends first.
+func (onnxSession *ONNXSession) Acquire(ctx context.Context) error {
+	select {
+	case onnxSession.token() <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("session acquisition cancelled: %w", ctx.Err())
+	}
+}
+
+// Release frees the session reserved by Acquire.
+func (onnxSession *ONNXSession) Release() {
+	<-onnxSession.token()
+}
+
+// token returns the channel guarding the session, creating it on first use.
+func (onnxSession *ONNXSession) token() chan struct{} {
+	onnxSession.slotOnce.Do(func() {
+		onnxSession.slot = make(chan struct{}, 1)
+	})
+	return onnxSession.slot
+}
+
+// Do reserves the session, then calls fn while holding it.
+// A session run cannot be interrupted, so when ctx ends before fn returns, Do returns right away
+// with an error wrapping ctx.Err(); fn keeps the session until it completes and its result is discarded.
+// fn must therefore not use memory the caller may reuse once Do returns.
+func (onnxSession *ONNXSession) Do(ctx context.Context, fn func() error) error {
+	if err := onnxSession.Acquire(ctx); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		onnxSession.Release()
+		return fmt.Errorf("session run cancelled: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer onnxSession.Release()
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("session run abandoned: %w", ctx.Err())
+	}
+}
+
+// RunContext copies input into the input tensor, runs the session and returns a copy of the output tensor.
+// Concurrent calls are serialized, and ctx bounds both the wait for the session and the run itself.
+// Input can be reused as soon as RunContext returns, even when the run was abandoned.
+func (onnxSession *ONNXSession) RunContext(ctx context.Context, input []float32) ([]float32, error) {
+	if onnxSession.Session == nil || onnxSession.TensorInput == nil || onnxSession.TensorOutput == nil {
+		return nil, errors.New("session is not initialized")
+	}
+
+	// The run may outlive the call when ctx ends, so it works on its own copy of the input
+	// rather than on a buffer the caller is free to reuse.
+	input = slices.Clone(input)
+	var output []float32
+	err := onnxSession.Do(ctx, func() error {
+		data := onnxSession.TensorInput.GetData()
+		if len(input) != len(data) {
+			return fmt.Errorf("input holds %d floats but the session input tensor holds %d", len(input), len(data))
+		}
+		copy(data, input)
+		if err := onnxSession.Session.Run(); err != nil {
+			return fmt.Errorf("failed to run session: %w", err)
+		}
+		output = slices.Clone(onnxSession.TensorOutput.GetData())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
@@ -1,7 +1,10 @@
 package onnx_test
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
 )
@@ -63,3 +66,77 @@ func TestNewONNXSession_InvalidShape(t *testing.T) {
 		t.Errorf("Expected error with invalid tensor shapes, got nil")
 	}
 }
+
+func TestONNXSession_AcquireHonoursContext(t *testing.T) {
+	s := &onnx.ONNXSession{}
+	if err := s.Acquire(context.Background()); err != nil {
+		t.Fatalf("Acquire returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded while the session is held, got %v", err)
+	}
+
+	s.Release()
+	if err := s.Acquire(context.Background()); err != nil {
+		t.Errorf("Expected Acquire to succeed after Release, got %v", err)
+	}
+	s.Release()
+}
+
+func TestONNXSession_DoReleasesCallerOnCancellation(t *testing.T) {
+	s := &onnx.ONNXSession{}
+	ctx, cancel := context.WithCancel(context.Background())
+	started, finish := make(chan struct{}), make(chan struct{})
+
+	result := make(chan error, 1)
+	go func() {
+		result <- s.Do(ctx, func() error {
+			close(started)
+			<-finish
+			return nil
+		})
+	}()
+
+	<-started
+	cancel()
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	// The abandoned run still holds the session until it completes.
+	busy, cancelBusy := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelBusy()
+	if err := s.Acquire(busy); err == nil {
+		t.Fatalf("Expected the session to stay held by the abandoned run")
+	}
+	close(finish)
+	if err := s.Acquire(context.Background()); err != nil {
+		t.Errorf("Expected the session to be released once the run completes, got %v", err)
+	}
+	s.Release()
+}
+
+func TestONNXSession_DoReturnsRunError(t *testing.T) {
+	s := &onnx.ONNXSession{}
+	runErr := errors.New("run failed")
+	if err := s.Do(context.Background(), func() error { return runErr }); !errors.Is(err, runErr) {
+		t.Errorf("Expected the run error, got %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	if err := s.Do(cancelled, func() error { called = true; return nil }); err == nil || called {
+		t.Errorf("Expected a cancelled context to skip the run, got %v (called %v)", err, called)
+	}
+}
+
+func TestONNXSession_RunContextRequiresSession(t *testing.T) {
+	s := &onnx.ONNXSession{}
+	if _, err := s.RunContext(context.Background(), []float32{1}); err == nil {
+		t.Errorf("Expected error when running an uninitialized session")
+	}
+}
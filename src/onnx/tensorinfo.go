@@ -0,0 +1,391 @@
+package onnx
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// TensorInfo describes an input or output tensor of a model.
+type TensorInfo struct {
+	Name string `json:"name"`
+	// ElementType is the NumPy name of the element type, e.g. float32 or int64.
+	ElementType string `json:"element_type"`
+	// Shape holds the dimensions of the tensor, -1 for dynamic dimensions.
+	Shape []int64 `json:"shape"`
+	// Dims holds the symbolic name of each dimension of Shape, e.g. batch or height,
+	// and an empty string for fixed or unnamed dimensions. It is nil when no dimension is named.
+	Dims []string `json:"dims,omitempty"`
+}
+
+// String formats the tensor as its name, element type and shape, dynamic dimensions
+// being written as their symbolic name, or ? when unnamed: images float32 [batch 3 640 640].
+func (t TensorInfo) String() string {
+	dims := make([]string, len(t.Shape))
+	for i, dim := range t.Shape {
+		switch {
+		case dim >= 0:
+			dims[i] = strconv.FormatInt(dim, 10)
+		case i < len(t.Dims) && t.Dims[i] != "":
+			dims[i] = t.Dims[i]
+		default:
+			dims[i] = "?"
+		}
+	}
+	return fmt.Sprintf("%s %s [%s]", t.Name, t.ElementType, strings.Join(dims, " "))
+}
+
+// Dynamic reports whether some dimensions of the tensor are only known when running the model.
+func (t TensorInfo) Dynamic() bool {
+	for _, dim := range t.Shape {
+		if dim < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadTensorInfo reads the input and output tensors of the model of nr, initializing the
+// onnxruntime environment from its library path if needed.
+func ReadTensorInfo(nr *OnnxRuntime) (inputs, outputs []TensorInfo, err error) {
+	if err := initializeEnvironment(nr.libraryPath); err != nil {
+		return nil, nil, err
+	}
+	ortInputs, ortOutputs, err := ort.GetInputOutputInfo(nr.modelPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read tensors of model %s: %w", nr.modelPath, err)
+	}
+	// onnxruntime reports dynamic dimensions as -1, their names are read from the graph itself.
+	dims, err := symbolicDims(nr.modelPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read tensors of model %s: %w", nr.modelPath, err)
+	}
+	return newTensorInfos(ortInputs, dims), newTensorInfos(ortOutputs, dims), nil
+}
+
+// newTensorInfos converts the tensor infos reported by onnxruntime, naming their dimensions
+// from dims, by tensor name.
+func newTensorInfos(infos []ort.InputOutputInfo, dims map[string][]string) []TensorInfo {
+	tensors := make([]TensorInfo, len(infos))
+	for i, info := range infos {
+		tensors[i] = TensorInfo{
+			Name:        info.Name,
+			ElementType: elementTypeName(info.DataType),
+			Shape:       append([]int64(nil), info.Dimensions...),
+		}
+		if names := dims[info.Name]; len(names) == len(info.Dimensions) {
+			tensors[i].Dims = names
+		}
+	}
+	return tensors
+}
+
+// elementTypeName returns the NumPy name of t, or the onnxruntime name of types NumPy lacks.
+func elementTypeName(t ort.TensorElementDataType) string {
+	switch t {
+	case ort.TensorElementDataTypeFloat:
+		return "float32"
+	case ort.TensorElementDataTypeFloat16:
+		return "float16"
+	case ort.TensorElementDataTypeDouble:
+		return "float64"
+	case ort.TensorElementDataTypeUint8:
+		return "uint8"
+	case ort.TensorElementDataTypeInt8:
+		return "int8"
+	case ort.TensorElementDataTypeUint16:
+		return "uint16"
+	case ort.TensorElementDataTypeInt16:
+		return "int16"
+	case ort.TensorElementDataTypeUint32:
+		return "uint32"
+	case ort.TensorElementDataTypeInt32:
+		return "int32"
+	case ort.TensorElementDataTypeUint64:
+		return "uint64"
+	case ort.TensorElementDataTypeInt64:
+		return "int64"
+	case ort.TensorElementDataTypeBool:
+		return "bool"
+	case ort.TensorElementDataTypeString:
+		return "string"
+	case ort.TensorElementDataTypeBFloat16:
+		return "bfloat16"
+	default:
+		return t.String()
+	}
+}
+
+// Field numbers of the ONNX protobuf messages leading to the dimensions of the graph tensors.
+const (
+	modelGraphField      = 7  // ModelProto.graph
+	graphInputField      = 11 // GraphProto.input
+	graphOutputField     = 12 // GraphProto.output
+	valueInfoNameField   = 1  // ValueInfoProto.name
+	valueInfoTypeField   = 2  // ValueInfoProto.type
+	typeTensorField      = 1  // TypeProto.tensor_type
+	tensorShapeField     = 2  // TypeProto.Tensor.shape
+	shapeDimField        = 1  // TensorShapeProto.dim
+	dimensionParamField  = 2  // TensorShapeProto.Dimension.dim_param
+	protoVarint          = 0
+	protoFixed64         = 1
+	protoLengthDelimited = 2
+	protoFixed32         = 5
+)
+
+// maxValueInfoSize bounds the size of the graph input and output descriptions read into memory.
+const maxValueInfoSize = 1 << 20
+
+// symbolicDims returns the symbolic dimension names of the graph inputs and outputs of the ONNX
+// model file at path, by tensor name. Fixed dimensions have an empty name, and tensors without
+// named dimensions are left out. The file is streamed: only the tensor descriptions are read,
+// the weights are skipped.
+func symbolicDims(path string) (map[string][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open model: %w", err)
+	}
+	defer file.Close()
+	dims, err := readSymbolicDims(&protoStream{r: bufio.NewReader(file)})
+	if err != nil {
+		return nil, fmt.Errorf("invalid ONNX model: %w", err)
+	}
+	return dims, nil
+}
+
+// readSymbolicDims reads the dimension names of the ModelProto of s, as symbolicDims.
+func readSymbolicDims(s *protoStream) (map[string][]string, error) {
+	dims := make(map[string][]string)
+	for {
+		number, wireType, err := s.key()
+		if err == io.EOF {
+			return dims, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if number != modelGraphField || wireType != protoLengthDelimited {
+			if err := s.skip(number, wireType); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		length, err := s.length(number)
+		if err != nil {
+			return nil, err
+		}
+		end := s.offset + length
+		for s.offset < end {
+			number, wireType, err := s.key()
+			if err != nil {
+				return nil, err
+			}
+			if (number != graphInputField && number != graphOutputField) || wireType != protoLengthDelimited {
+				if err := s.skip(number, wireType); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			value, err := s.value(number)
+			if err != nil {
+				return nil, err
+			}
+			name, names, err := readValueInfoDims(value)
+			if err != nil {
+				return nil, err
+			}
+			for _, n := range names {
+				if n != "" {
+					dims[name] = names
+					break
+				}
+			}
+		}
+		if s.offset != end {
+			return nil, fmt.Errorf("fields overrun the %d bytes of field %d", length, modelGraphField)
+		}
+	}
+}
+
+// protoStream reads protobuf fields from a stream, keeping track of the bytes consumed.
+type protoStream struct {
+	r      *bufio.Reader
+	offset uint64
+}
+
+// ReadByte reads a byte, for binary.ReadUvarint.
+func (s *protoStream) ReadByte() (byte, error) {
+	b, err := s.r.ReadByte()
+	if err == nil {
+		s.offset++
+	}
+	return b, err
+}
+
+// key reads the key of the next field, returning io.EOF at the end of the stream.
+func (s *protoStream) key() (number, wireType int, err error) {
+	start := s.offset
+	key, err := binary.ReadUvarint(s)
+	if err == io.EOF && s.offset == start {
+		return 0, 0, io.EOF
+	}
+	if err != nil {
+		return 0, 0, errors.New("invalid field key")
+	}
+	return int(key >> 3), int(key & 7), nil
+}
+
+// length reads the length of the length delimited field number.
+func (s *protoStream) length(number int) (uint64, error) {
+	length, err := binary.ReadUvarint(s)
+	if err != nil {
+		return 0, fmt.Errorf("truncated field %d", number)
+	}
+	return length, nil
+}
+
+// value reads the bytes of the length delimited field number.
+func (s *protoStream) value(number int) ([]byte, error) {
+	length, err := s.length(number)
+	if err != nil {
+		return nil, err
+	}
+	if length > maxValueInfoSize {
+		return nil, fmt.Errorf("field %d of %d bytes is too large", number, length)
+	}
+	value := make([]byte, length)
+	n, err := io.ReadFull(s.r, value)
+	s.offset += uint64(n)
+	if err != nil {
+		return nil, fmt.Errorf("truncated field %d", number)
+	}
+	return value, nil
+}
+
+// skip skips the value of field number of the given wire type.
+func (s *protoStream) skip(number, wireType int) error {
+	var size uint64
+	switch wireType {
+	case protoVarint:
+		if _, err := binary.ReadUvarint(s); err != nil {
+			return fmt.Errorf("invalid varint in field %d", number)
+		}
+		return nil
+	case protoFixed64:
+		size = 8
+	case protoFixed32:
+		size = 4
+	case protoLengthDelimited:
+		length, err := s.length(number)
+		if err != nil {
+			return err
+		}
+		size = length
+	default:
+		return fmt.Errorf("unsupported wire type %d in field %d", wireType, number)
+	}
+	for size > 0 {
+		n, err := s.r.Discard(int(min(size, 1<<30)))
+		s.offset += uint64(n)
+		size -= uint64(n)
+		if err != nil {
+			return fmt.Errorf("truncated field %d", number)
+		}
+	}
+	return nil
+}
+
+// readValueInfoDims returns the name of the ValueInfoProto data and the symbolic name of each
+// dimension of its tensor type.
+func readValueInfoDims(data []byte) (name string, dims []string, err error) {
+	err = protoFields(data, func(number int, value []byte) error {
+		switch number {
+		case valueInfoNameField:
+			name = string(value)
+			return nil
+		case valueInfoTypeField:
+			return protoPath(value, []int{typeTensorField, tensorShapeField}, func(shape []byte) error {
+				return protoFields(shape, func(number int, value []byte) error {
+					if number != shapeDimField {
+						return nil
+					}
+					var param string
+					err := protoFields(value, func(number int, value []byte) error {
+						if number == dimensionParamField {
+							param = string(value)
+						}
+						return nil
+					})
+					dims = append(dims, param)
+					return err
+				})
+			})
+		default:
+			return nil
+		}
+	})
+	return name, dims, err
+}
+
+// protoPath calls fn with the message reached from data through the nested fields of path.
+func protoPath(data []byte, path []int, fn func(value []byte) error) error {
+	if len(path) == 0 {
+		return fn(data)
+	}
+	return protoFields(data, func(number int, value []byte) error {
+		if number != path[0] {
+			return nil
+		}
+		return protoPath(value, path[1:], fn)
+	})
+}
+
+// protoFields calls fn with the number and bytes of each length delimited field of the protobuf
+// message data, in order, skipping the other fields.
+func protoFields(data []byte, fn func(number int, value []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("invalid field key")
+		}
+		data = data[n:]
+		number, wireType := int(key>>3), int(key&7)
+		switch wireType {
+		case protoVarint:
+			_, n = binary.Uvarint(data)
+			if n <= 0 {
+				return fmt.Errorf("invalid varint in field %d", number)
+			}
+			data = data[n:]
+		case protoFixed64, protoFixed32:
+			size := 8
+			if wireType == protoFixed32 {
+				size = 4
+			}
+			if len(data) < size {
+				return fmt.Errorf("truncated field %d", number)
+			}
+			data = data[size:]
+		case protoLengthDelimited:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return fmt.Errorf("truncated field %d", number)
+			}
+			value := data[n : n+int(length)]
+			data = data[n+int(length):]
+			if err := fn(number, value); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported wire type %d in field %d", wireType, number)
+		}
+	}
+	return nil
+}
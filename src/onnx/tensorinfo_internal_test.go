@@ -0,0 +1,73 @@
+package onnx
+
+import (
+	"encoding/binary"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// field encodes a length delimited protobuf field.
+func field(number int, value ...[]byte) []byte {
+	data := binary.AppendUvarint(nil, uint64(number<<3|2))
+	content := slices.Concat(value...)
+	data = binary.AppendUvarint(data, uint64(len(content)))
+	return append(data, content...)
+}
+
+// varintField encodes a varint protobuf field.
+func varintField(number int, value uint64) []byte {
+	return binary.AppendUvarint(binary.AppendUvarint(nil, uint64(number<<3)), value)
+}
+
+// valueInfo encodes a ValueInfoProto of a float tensor, with dims given as an int64 dim_value
+// or a string dim_param.
+func valueInfo(name string, dims ...any) []byte {
+	var shape [][]byte
+	for _, dim := range dims {
+		switch dim := dim.(type) {
+		case int:
+			shape = append(shape, field(1, varintField(1, uint64(dim))))
+		case string:
+			shape = append(shape, field(1, field(2, []byte(dim))))
+		}
+	}
+	tensor := slices.Concat(varintField(1, 1), field(2, shape...))
+	return slices.Concat(field(1, []byte(name)), field(2, field(1, tensor)))
+}
+
+func TestSymbolicDims(t *testing.T) {
+	graph := slices.Concat(
+		field(2, []byte("main_graph")),
+		field(5, make([]byte, 1000)), // an initializer, skipped
+		field(11, valueInfo("images", "batch", 3, "height", "width")),
+		field(11, valueInfo("scale", 1)),
+		field(12, valueInfo("output0", "batch", 84, "anchors")),
+	)
+	model := slices.Concat(varintField(1, 9), field(2, []byte("pytorch")), field(7, graph))
+
+	path := filepath.Join(t.TempDir(), "model.onnx")
+	if err := os.WriteFile(path, model, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dims, err := symbolicDims(path)
+	if err != nil {
+		t.Fatalf("symbolicDims returned error: %v", err)
+	}
+	want := map[string][]string{
+		"images":  {"batch", "", "height", "width"},
+		"output0": {"batch", "", "anchors"},
+	}
+	if !maps.EqualFunc(dims, want, slices.Equal) {
+		t.Errorf("Expected %q, got %q", want, dims)
+	}
+
+	if err := os.WriteFile(path, model[:len(model)-3], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := symbolicDims(path); err == nil {
+		t.Error("Expected an error for a truncated model")
+	}
+}
@@ -0,0 +1,26 @@
+package onnx_test
+
+import (
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+func TestTensorInfo_String(t *testing.T) {
+	tensor := onnx.TensorInfo{
+		Name:        "images",
+		ElementType: "float32",
+		Shape:       []int64{-1, 3, -1, 640},
+		Dims:        []string{"batch", "", "", ""},
+	}
+	if got, want := tensor.String(), "images float32 [batch 3 ? 640]"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if !tensor.Dynamic() {
+		t.Error("Expected a tensor with -1 dimensions to be dynamic")
+	}
+	tensor.Shape = []int64{1, 3, 640, 640}
+	if tensor.Dynamic() {
+		t.Error("Expected a fixed shape not to be dynamic")
+	}
+}
@@ -0,0 +1,245 @@
+// Package tune picks the decoding thresholds of a detector from a labeled dataset.
+//
+// The model runs once per image with detector.Detector.Infer, then Sweep decodes the cached raw
+// outputs again for each NMS IoU threshold and evaluates the detections at every confidence
+// threshold, globally and per class:
+//
+//	result, err := tune.Sweep(ctx, d.Options(), samples, tune.Options{Objective: tune.ObjectiveF1})
+//	if err != nil {
+//		return err
+//	}
+//	err = detector.SaveThresholds("thresholds.json", result.Thresholds)
+package tune
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/detector"
+	"github.com/deadelus/go-clean-onnxruntime/src/evaluate"
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+// Objective is what the chosen thresholds optimize.
+type Objective string
+
+const (
+	// ObjectiveF1 picks the thresholds of highest F1 score.
+	ObjectiveF1 Objective = "f1"
+	// ObjectivePrecision picks the thresholds of highest recall reaching TargetPrecision.
+	ObjectivePrecision Objective = "precision"
+)
+
+// Sample is the raw output of the model for a labeled image and the ground truth of that image.
+// Ground truth labels must be the labels output by the detector, after remapping. Labels the
+// detector does not output are evaluated but get no class threshold.
+type Sample struct {
+	Output      *detector.RawOutput
+	GroundTruth []onnx.BoundingBox
+	// Crowd holds the crowd regions of the image, see evaluate.Image.
+	Crowd []onnx.BoundingBox
+}
+
+// Options configures a sweep.
+// Zero fields are replaced by the defaults documented on each field.
+type Options struct {
+	// Objective defaults to ObjectiveF1.
+	Objective Objective
+	// TargetPrecision is the precision to reach with ObjectivePrecision.
+	TargetPrecision float64
+	// IoUThresholds are the NMS IoU thresholds tried. They default to 0.3 to 0.8 by 0.05.
+	IoUThresholds []float32
+	// MinConfidence is the lowest confidence threshold tried, which the raw outputs are decoded
+	// with. It defaults to 0.01. Confidence thresholds are tried from it to 1 by 0.01.
+	MinConfidence float32
+	// MatchIoU is the IoU from which a detection matches a ground truth box. It defaults to 0.5.
+	MatchIoU float64
+}
+
+// WithDefaults returns a copy of the options with zero fields replaced by their default.
+func (o Options) WithDefaults() Options {
+	if o.Objective == "" {
+		o.Objective = ObjectiveF1
+	}
+	if len(o.IoUThresholds) == 0 {
+		for i := 0; i <= 10; i++ {
+			o.IoUThresholds = append(o.IoUThresholds, float32(30+5*i)/100)
+		}
+	}
+	if o.MinConfidence == 0 {
+		o.MinConfidence = 0.01
+	}
+	if o.MatchIoU == 0 {
+		o.MatchIoU = 0.5
+	}
+	return o
+}
+
+// Validate checks the options. It should be called on options with defaults applied.
+func (o Options) Validate() error {
+	var errs []error
+	switch o.Objective {
+	case ObjectiveF1:
+	case ObjectivePrecision:
+		if o.TargetPrecision <= 0 || o.TargetPrecision > 1 {
+			errs = append(errs, fmt.Errorf("target precision %v is outside (0, 1]", o.TargetPrecision))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown objective %q, expected %q or %q", o.Objective, ObjectiveF1, ObjectivePrecision))
+	}
+	for _, iou := range o.IoUThresholds {
+		if iou <= 0 || iou > 1 {
+			errs = append(errs, fmt.Errorf("IoU threshold %v is outside (0, 1]", iou))
+		}
+	}
+	if o.MinConfidence < 0 || o.MinConfidence > 1 {
+		errs = append(errs, fmt.Errorf("minimum confidence %v is outside [0, 1]", o.MinConfidence))
+	}
+	if o.MatchIoU <= 0 || o.MatchIoU > 1 {
+		errs = append(errs, fmt.Errorf("match IoU %v is outside (0, 1]", o.MatchIoU))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid sweep options: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Result holds the chosen thresholds and their evaluation.
+type Result struct {
+	// Thresholds are the chosen global confidence and NMS IoU thresholds, with a confidence
+	// threshold per class. They can be saved with detector.SaveThresholds.
+	Thresholds detector.ThresholdsSpec `json:"thresholds"`
+	// Global is the evaluation of all classes at the global thresholds.
+	Global Choice `json:"global"`
+	// Classes is the evaluation of each class at its threshold, with the chosen NMS IoU.
+	Classes []Choice `json:"classes"`
+	// IoU is the best global choice at each NMS IoU threshold tried.
+	IoU []IoUChoice `json:"iou"`
+}
+
+// Choice is a chosen confidence threshold and the evaluation at that threshold.
+type Choice struct {
+	Label string              `json:"label"`
+	Point evaluate.CurvePoint `json:"point"`
+	// Met is false when no threshold reaches the target precision. Point is then the
+	// threshold of highest F1 score.
+	Met bool `json:"met"`
+}
+
+// IoUChoice is the best global choice at an NMS IoU threshold.
+type IoUChoice struct {
+	IoU float32 `json:"iou"`
+	Choice
+}
+
+// Sweep decodes the samples for each NMS IoU threshold and picks the thresholds meeting the
+// objective. The NMS IoU threshold is chosen on the global objective, then the confidence of
+// each class on its own curve. Other decoding settings, such as the class filter, come from
+// detectorOptions.
+//
+// Detections are decoded once per NMS IoU threshold, at the lowest confidence tried, and
+// filtered by confidence afterwards. NMS keeps the same boxes above a threshold either way,
+// but per class thresholds may differ slightly once decoded together since NMS is class agnostic.
+func Sweep(ctx context.Context, detectorOptions detector.Options, samples []Sample, options Options) (*Result, error) {
+	options = options.WithDefaults()
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	if len(samples) == 0 {
+		return nil, errors.New("no samples to sweep thresholds on")
+	}
+
+	result := &Result{}
+	var best []evaluate.Curve
+	for _, iou := range options.IoUThresholds {
+		curves, err := sweepIoU(ctx, detectorOptions, samples, iou, options)
+		if err != nil {
+			return nil, err
+		}
+		choice := options.choose(evaluate.Total(curves))
+		result.IoU = append(result.IoU, IoUChoice{IoU: iou, Choice: choice})
+		if best == nil || options.better(choice, result.Global) {
+			best = curves
+			result.Global = choice
+			result.Thresholds.IoU = iou
+		}
+	}
+
+	result.Thresholds.Confidence = result.Global.Point.Confidence
+	result.Thresholds.Classes = make(map[string]float32, len(best))
+	sources := modelLabels(detectorOptions)
+	for _, curve := range best {
+		if curve.GroundTruth == 0 {
+			continue
+		}
+		choice := options.choose(curve)
+		result.Classes = append(result.Classes, choice)
+		// Class thresholds apply to model labels, those of the classes remapped to the curve label.
+		for _, label := range sources[curve.Label] {
+			result.Thresholds.Classes[label] = choice.Point.Confidence
+		}
+	}
+	return result, nil
+}
+
+// modelLabels returns the model labels of each output label of the detector, those of the
+// classes remapped to it. Dropped classes are left out.
+func modelLabels(detectorOptions detector.Options) map[string][]string {
+	sources := make(map[string][]string, len(detectorOptions.Classes))
+	for _, label := range detectorOptions.Classes {
+		if target, keep := detectorOptions.Remap.Label(label); keep {
+			sources[target] = append(sources[target], label)
+		}
+	}
+	return sources
+}
+
+// sweepIoU decodes the samples with NMS IoU threshold iou and returns the curve of each class.
+func sweepIoU(ctx context.Context, detectorOptions detector.Options, samples []Sample, iou float32, options Options) ([]evaluate.Curve, error) {
+	detectorOptions = detectorOptions.WithThresholds(detector.ThresholdsSpec{Confidence: options.MinConfidence, IoU: iou})
+	images := make([]evaluate.Image, len(samples))
+	for i, sample := range samples {
+		predictions, err := detectorOptions.DecodeContext(ctx, sample.Output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode sample %d: %w", i, err)
+		}
+		images[i] = evaluate.Image{Predictions: predictions, GroundTruth: sample.GroundTruth, Crowd: sample.Crowd}
+	}
+	return evaluate.Curves(images, evaluate.Options{IoUThreshold: options.MatchIoU}), nil
+}
+
+// choose returns the point of curve meeting the objective, among the confidence thresholds tried.
+func (o Options) choose(curve evaluate.Curve) Choice {
+	// The tolerance keeps float32 thresholds such as 0.01 on their point.
+	first := int(math.Ceil(float64(o.MinConfidence)*(evaluate.ConfidencePoints-1) - 1e-4))
+	points := curve.Points[first:]
+
+	if o.Objective == ObjectivePrecision {
+		// Recall decreases with the confidence, so the first point reaching the target has the best recall.
+		for _, point := range points {
+			if point.TP > 0 && point.Precision >= o.TargetPrecision {
+				return Choice{Label: curve.Label, Point: point, Met: true}
+			}
+		}
+	}
+	best := points[0]
+	for _, point := range points[1:] {
+		if point.F1 > best.F1 {
+			best = point
+		}
+	}
+	return Choice{Label: curve.Label, Point: best, Met: o.Objective == ObjectiveF1}
+}
+
+// better reports whether choice a meets the objective better than b.
+func (o Options) better(a, b Choice) bool {
+	if a.Met != b.Met {
+		return a.Met
+	}
+	if o.Objective == ObjectivePrecision && a.Met {
+		return a.Point.Recall > b.Point.Recall
+	}
+	return a.Point.F1 > b.Point.F1
+}
@@ -0,0 +1,168 @@
+package tune_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/detector"
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+	"github.com/deadelus/go-clean-onnxruntime/src/tune"
+)
+
+var detectorOptions = detector.Options{
+	InputWidth:  100,
+	InputHeight: 100,
+	Detections:  4,
+	Classes:     []string{"cat", "dog"},
+}
+
+// rawOutput returns the YOLOv8 output of 20x20 detections at the given centers, padded to 4
+// detections without score.
+func rawOutput(centers [][2]float32, cat, dog []float32) *detector.RawOutput {
+	data := make([]float32, 6*4)
+	for i, c := range centers {
+		data[i], data[4+i], data[8+i], data[12+i] = c[0], c[1], 20, 20
+		data[16+i], data[20+i] = cat[i], dog[i]
+	}
+	return &detector.RawOutput{ImageSize: detector.Size{Width: 100, Height: 100}, Data: data}
+}
+
+func groundTruth(label string, x, y float32) onnx.BoundingBox {
+	return onnx.BoundingBox{Label: label, Confidence: 1, X1: x - 10, Y1: y - 10, X2: x + 10, Y2: y + 10}
+}
+
+// samples holds 2 images. The first has a cat found at 0.8 with a duplicate at 0.7 overlapping
+// it with an IoU of 2/3, a false cat at 0.3 and a dog found at 0.6. The second has a cat found
+// at 0.4, a false dog at 0.35 and a missed dog.
+func samples() []tune.Sample {
+	return []tune.Sample{
+		{
+			Output: rawOutput(
+				[][2]float32{{20, 20}, {70, 20}, {20, 70}, {24, 20}},
+				[]float32{0.8, 0.3, 0, 0.7},
+				[]float32{0, 0, 0.6, 0},
+			),
+			GroundTruth: []onnx.BoundingBox{groundTruth("cat", 20, 20), groundTruth("dog", 20, 70)},
+		},
+		{
+			Output: rawOutput(
+				[][2]float32{{50, 50}, {80, 80}},
+				[]float32{0.4, 0},
+				[]float32{0, 0.35},
+			),
+			GroundTruth: []onnx.BoundingBox{groundTruth("cat", 50, 50), groundTruth("dog", 10, 90)},
+		},
+	}
+}
+
+func TestSweep_F1(t *testing.T) {
+	result, err := tune.Sweep(context.Background(), detectorOptions, samples(), tune.Options{IoUThresholds: []float32{0.8, 0.5}})
+	if err != nil {
+		t.Fatalf("Sweep returned error: %v", err)
+	}
+
+	// NMS at 0.5 drops the duplicate cat. From 0.36 the 3 remaining true positives are kept
+	// without false positive: precision 1, recall 3/4.
+	thresholds := result.Thresholds
+	if thresholds.IoU != 0.5 || thresholds.Confidence != 0.36 {
+		t.Errorf("Expected IoU 0.5 and confidence 0.36, got %+v", thresholds)
+	}
+	if p := result.Global.Point; p.TP != 3 || p.FP != 0 || p.FN != 1 || !result.Global.Met {
+		t.Errorf("Unexpected global choice %+v", result.Global)
+	}
+	if len(result.IoU) != 2 || result.IoU[0].IoU != 0.8 || result.IoU[0].Point.F1 >= result.IoU[1].Point.F1 {
+		t.Errorf("Expected a better F1 at IoU 0.5, got %+v", result.IoU)
+	}
+
+	// The cats are all found from 0.31, past the false cat. The dog threshold skips the false dog.
+	if thresholds.Classes["cat"] != 0.31 || thresholds.Classes["dog"] != 0.36 {
+		t.Errorf("Unexpected class thresholds %v", thresholds.Classes)
+	}
+	if len(result.Classes) != 2 || result.Classes[0].Label != "cat" || result.Classes[0].Point.F1 != 1 {
+		t.Errorf("Unexpected class choices %+v", result.Classes)
+	}
+}
+
+func TestSweep_RemappedClasses(t *testing.T) {
+	options := detectorOptions
+	options.ModelPath, options.LibraryPath = "model.onnx", "lib.so"
+	options.Remap = onnx.ClassRemap{"cat": "pet", "dog": "pet"}
+	s := samples()
+	for _, sample := range s {
+		for i := range sample.GroundTruth {
+			sample.GroundTruth[i].Label = "pet"
+		}
+	}
+	s[1].GroundTruth = append(s[1].GroundTruth, groundTruth("horse", 90, 10))
+
+	result, err := tune.Sweep(context.Background(), options, s, tune.Options{IoUThresholds: []float32{0.5}})
+	if err != nil {
+		t.Fatalf("Sweep returned error: %v", err)
+	}
+	// The pet threshold applies to both model classes, the horse is not a model class.
+	classes := result.Thresholds.Classes
+	if len(classes) != 2 || classes["cat"] == 0 || classes["cat"] != classes["dog"] {
+		t.Errorf("Expected the pet threshold on cat and dog, got %v", classes)
+	}
+	if err := options.WithThresholds(result.Thresholds).WithDefaults().Validate(); err != nil {
+		t.Errorf("Expected the swept thresholds to be valid detector options, got %v", err)
+	}
+}
+
+func TestSweep_TargetPrecision(t *testing.T) {
+	options := tune.Options{Objective: tune.ObjectivePrecision, TargetPrecision: 0.7, IoUThresholds: []float32{0.5}}
+	result, err := tune.Sweep(context.Background(), detectorOptions, samples(), options)
+	if err != nil {
+		t.Fatalf("Sweep returned error: %v", err)
+	}
+	// From 0.31 the false dog at 0.35 is the only false positive: precision 3/4.
+	if result.Thresholds.Confidence != 0.31 || !result.Global.Met {
+		t.Errorf("Expected confidence 0.31 to reach the target, got %+v", result.Global)
+	}
+
+	options.TargetPrecision = 1
+	result, err = tune.Sweep(context.Background(), detectorOptions, samples(), options)
+	if err != nil {
+		t.Fatalf("Sweep returned error: %v", err)
+	}
+	if result.Thresholds.Confidence != 0.36 || result.Thresholds.Classes["dog"] != 0.36 {
+		t.Errorf("Expected confidence 0.36 to reach the target, got %+v", result.Thresholds)
+	}
+}
+
+func TestSweep_Unmet(t *testing.T) {
+	s := samples()[1:]
+	// The only dog prediction is false, so no dog threshold reaches any precision.
+	options := tune.Options{Objective: tune.ObjectivePrecision, TargetPrecision: 0.5, IoUThresholds: []float32{0.5}}
+	result, err := tune.Sweep(context.Background(), detectorOptions, s, options)
+	if err != nil {
+		t.Fatalf("Sweep returned error: %v", err)
+	}
+	for _, choice := range result.Classes {
+		if choice.Label == "dog" && choice.Met {
+			t.Errorf("Expected the dog target to be unmet, got %+v", choice)
+		}
+		if choice.Label == "cat" && !choice.Met {
+			t.Errorf("Expected the cat target to be met, got %+v", choice)
+		}
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	for _, options := range []tune.Options{
+		{Objective: tune.ObjectivePrecision},
+		{Objective: "recall"},
+		{IoUThresholds: []float32{1.5}},
+		{MinConfidence: 2},
+	} {
+		if err := options.WithDefaults().Validate(); err == nil {
+			t.Errorf("Expected an error for %+v", options)
+		}
+	}
+	if err := (tune.Options{}).WithDefaults().Validate(); err != nil {
+		t.Errorf("Expected default options to be valid, got %v", err)
+	}
+	if _, err := tune.Sweep(context.Background(), detectorOptions, nil, tune.Options{}); err == nil {
+		t.Error("Expected an error without samples")
+	}
+}
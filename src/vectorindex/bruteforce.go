@@ -0,0 +1,94 @@
+package vectorindex
+
+import (
+	"sort"
+	"sync"
+)
+
+// BruteForce is an exact index which compares queries with every stored vector.
+// It is the best choice for up to a few tens of thousands of vectors.
+type BruteForce struct {
+	mu        sync.RWMutex
+	dimension int
+	ids       []string
+	vectors   [][]float32
+	positions map[string]int
+}
+
+// NewBruteForce creates an empty exact index for vectors of the given dimension.
+func NewBruteForce(dimension int) *BruteForce {
+	return &BruteForce{
+		dimension: dimension,
+		positions: make(map[string]int),
+	}
+}
+
+// Add stores a normalized copy of vector under id.
+func (b *BruteForce) Add(id string, vector []float32) error {
+	unit, err := normalized(vector, b.dimension)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.positions[id]; ok {
+		return ErrDuplicateID
+	}
+	b.positions[id] = len(b.ids)
+	b.ids = append(b.ids, id)
+	b.vectors = append(b.vectors, unit)
+	return nil
+}
+
+// Remove deletes the vector stored under id and reports whether it was present.
+func (b *BruteForce) Remove(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	pos, ok := b.positions[id]
+	if !ok {
+		return false
+	}
+
+	// Move the last vector into the freed slot to keep storage compact.
+	last := len(b.ids) - 1
+	b.ids[pos], b.vectors[pos] = b.ids[last], b.vectors[last]
+	b.positions[b.ids[pos]] = pos
+	b.ids, b.vectors = b.ids[:last], b.vectors[:last]
+	delete(b.positions, id)
+	return true
+}
+
+// Search returns up to k vectors, from the most to the least similar to query.
+func (b *BruteForce) Search(query []float32, k int) ([]SearchResult, error) {
+	unit, err := normalized(query, b.dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	results := make([]SearchResult, len(b.ids))
+	for i, vector := range b.vectors {
+		results[i] = SearchResult{ID: b.ids[i], Score: dot(unit, vector)}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if k >= 0 && len(results) > k {
+		results = results[:k]
+	}
+	return results, nil
+}
+
+// Len returns the number of vectors in the index.
+func (b *BruteForce) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.ids)
+}
+
+// Dimension returns the number of dimensions of the indexed vectors.
+func (b *BruteForce) Dimension() int {
+	return b.dimension
+}
@@ -0,0 +1,57 @@
+package vectorindex_test
+
+import (
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/vectorindex"
+)
+
+func TestBruteForce_Search(t *testing.T) {
+	index := vectorindex.NewBruteForce(2)
+	for id, vector := range map[string][]float32{
+		"east":  {1, 0},
+		"north": {0, 1},
+		"west":  {-2, 0},
+		"ne":    {1, 1},
+	} {
+		if err := index.Add(id, vector); err != nil {
+			t.Fatalf("Add(%s) returned error: %v", id, err)
+		}
+	}
+
+	results, err := index.Search([]float32{3, 0.1}, 2)
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != "east" || results[1].ID != "ne" {
+		t.Fatalf("Expected [east ne], got %v", results)
+	}
+	if results[0].Score <= results[1].Score || results[0].Score > 1 {
+		t.Errorf("Expected decreasing cosine scores, got %v", results)
+	}
+}
+
+func TestBruteForce_Remove(t *testing.T) {
+	index := vectorindex.NewBruteForce(2)
+	_ = index.Add("a", []float32{1, 0})
+	_ = index.Add("b", []float32{0, 1})
+	_ = index.Add("c", []float32{1, 1})
+
+	if !index.Remove("a") {
+		t.Fatalf("Expected Remove to report an existing vector")
+	}
+	if index.Remove("a") {
+		t.Errorf("Expected Remove to report a missing vector")
+	}
+	if index.Len() != 2 {
+		t.Errorf("Expected 2 vectors, got %d", index.Len())
+	}
+
+	results, _ := index.Search([]float32{1, 0}, -1)
+	if len(results) != 2 || results[0].ID != "c" {
+		t.Errorf("Expected [c b] after removal, got %v", results)
+	}
+	if err := index.Add("a", []float32{1, 0}); err != nil {
+		t.Errorf("Expected a removed ID to be reusable, got %v", err)
+	}
+}
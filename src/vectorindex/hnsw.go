@@ -0,0 +1,336 @@
+package vectorindex
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// HNSWConfig holds the parameters of an HNSW index.
+type HNSWConfig struct {
+	// M is the number of neighbours linked to each node on the upper layers.
+	// The bottom layer links up to 2*M neighbours.
+	M int
+	// EfConstruction is the size of the candidate list used when inserting vectors.
+	// Larger values build a better graph at the cost of slower insertions.
+	EfConstruction int
+	// EfSearch is the size of the candidate list used when searching.
+	// Larger values improve recall at the cost of slower searches.
+	EfSearch int
+	// Seed initializes the random generator drawing the layer of each node.
+	Seed int64
+}
+
+// DefaultHNSWConfig returns the parameters commonly used for embeddings of a few hundred dimensions.
+func DefaultHNSWConfig() HNSWConfig {
+	return HNSWConfig{
+		M:              16,
+		EfConstruction: 200,
+		EfSearch:       64,
+		Seed:           1,
+	}
+}
+
+// hnswNode is a vector of the graph with its neighbours on every layer it belongs to.
+type hnswNode struct {
+	id        string
+	vector    []float32
+	neighbors [][]int
+	deleted   bool
+}
+
+// HNSW is an approximate index based on Hierarchical Navigable Small World graphs.
+// Removed vectors are tombstoned: they keep routing searches through the graph but are
+// never returned. Save and load the index to compact it.
+type HNSW struct {
+	mu         sync.RWMutex
+	dimension  int
+	config     HNSWConfig
+	levelMult  float64
+	random     *rand.Rand
+	nodes      []*hnswNode
+	positions  map[string]int
+	entryPoint int
+	maxLevel   int
+}
+
+// NewHNSW creates an empty approximate index for vectors of the given dimension.
+// Zero fields of config are replaced by the values of DefaultHNSWConfig.
+func NewHNSW(dimension int, config HNSWConfig) *HNSW {
+	defaults := DefaultHNSWConfig()
+	if config.M <= 1 {
+		config.M = defaults.M
+	}
+	if config.EfConstruction <= 0 {
+		config.EfConstruction = defaults.EfConstruction
+	}
+	if config.EfSearch <= 0 {
+		config.EfSearch = defaults.EfSearch
+	}
+	return &HNSW{
+		dimension:  dimension,
+		config:     config,
+		levelMult:  1 / math.Log(float64(config.M)),
+		random:     rand.New(rand.NewSource(config.Seed)),
+		positions:  make(map[string]int),
+		entryPoint: -1,
+	}
+}
+
+// Add stores a normalized copy of vector under id and links it into the graph.
+func (h *HNSW) Add(id string, vector []float32) error {
+	unit, err := normalized(vector, h.dimension)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.positions[id]; ok {
+		return ErrDuplicateID
+	}
+	h.insert(id, unit, int(math.Floor(-math.Log(1-h.random.Float64())*h.levelMult)))
+	return nil
+}
+
+// insert links a new node of the given level into the graph.
+func (h *HNSW) insert(id string, unit []float32, level int) {
+	node := &hnswNode{id: id, vector: unit, neighbors: make([][]int, level+1)}
+	index := len(h.nodes)
+	h.nodes = append(h.nodes, node)
+	h.positions[id] = index
+
+	if h.entryPoint < 0 {
+		h.entryPoint, h.maxLevel = index, level
+		return
+	}
+
+	entry := h.entryPoint
+	for layer := h.maxLevel; layer > level; layer-- {
+		entry = h.greedyClosest(unit, entry, layer)
+	}
+	entries := []int{entry}
+	for layer := min(level, h.maxLevel); layer >= 0; layer-- {
+		candidates := h.searchLayer(unit, entries, h.config.EfConstruction, layer)
+		neighbors := closestIndexes(candidates, h.maxNeighbors(layer))
+		node.neighbors[layer] = neighbors
+		for _, neighbor := range neighbors {
+			h.link(neighbor, index, layer)
+		}
+		entries = make([]int, len(candidates))
+		for i, c := range candidates {
+			entries[i] = c.index
+		}
+	}
+
+	if level > h.maxLevel {
+		h.entryPoint, h.maxLevel = index, level
+	}
+}
+
+// link adds target to the neighbours of node on a layer, keeping only the closest ones.
+func (h *HNSW) link(node, target, layer int) {
+	n := h.nodes[node]
+	n.neighbors[layer] = append(n.neighbors[layer], target)
+	if len(n.neighbors[layer]) <= h.maxNeighbors(layer) {
+		return
+	}
+	candidates := make([]candidate, len(n.neighbors[layer]))
+	for i, neighbor := range n.neighbors[layer] {
+		candidates[i] = candidate{index: neighbor, score: dot(n.vector, h.nodes[neighbor].vector)}
+	}
+	sortCandidates(candidates)
+	n.neighbors[layer] = closestIndexes(candidates, h.maxNeighbors(layer))
+}
+
+// maxNeighbors returns the maximum number of neighbours of a node on a layer.
+func (h *HNSW) maxNeighbors(layer int) int {
+	if layer == 0 {
+		return 2 * h.config.M
+	}
+	return h.config.M
+}
+
+// greedyClosest walks a layer from entry towards the node closest to query.
+func (h *HNSW) greedyClosest(query []float32, entry, layer int) int {
+	best := dot(query, h.nodes[entry].vector)
+	for changed := true; changed; {
+		changed = false
+		for _, neighbor := range h.nodes[entry].neighbors[layer] {
+			if score := dot(query, h.nodes[neighbor].vector); score > best {
+				best, entry, changed = score, neighbor, true
+			}
+		}
+	}
+	return entry
+}
+
+// searchLayer returns up to ef nodes of a layer closest to query, most similar first.
+func (h *HNSW) searchLayer(query []float32, entries []int, ef, layer int) []candidate {
+	visited := make(map[int]bool, ef*4)
+	frontier := &maxHeap{}
+	found := &minHeap{}
+	for _, entry := range entries {
+		visited[entry] = true
+		c := candidate{index: entry, score: dot(query, h.nodes[entry].vector)}
+		heap.Push(frontier, c)
+		heap.Push(found, c)
+		if found.Len() > ef {
+			heap.Pop(found)
+		}
+	}
+
+	for frontier.Len() > 0 {
+		current := heap.Pop(frontier).(candidate)
+		if found.Len() >= ef && current.score < (*found)[0].score {
+			break
+		}
+		for _, neighbor := range h.nodes[current.index].neighbors[layer] {
+			if visited[neighbor] {
+				continue
+			}
+			visited[neighbor] = true
+			c := candidate{index: neighbor, score: dot(query, h.nodes[neighbor].vector)}
+			if found.Len() < ef || c.score > (*found)[0].score {
+				heap.Push(frontier, c)
+				heap.Push(found, c)
+				if found.Len() > ef {
+					heap.Pop(found)
+				}
+			}
+		}
+	}
+
+	results := []candidate(*found)
+	sortCandidates(results)
+	return results
+}
+
+// Remove tombstones the vector stored under id and reports whether it was present.
+func (h *HNSW) Remove(id string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	index, ok := h.positions[id]
+	if !ok {
+		return false
+	}
+	h.nodes[index].deleted = true
+	delete(h.positions, id)
+	return true
+}
+
+// Search returns up to k vectors, from the most to the least similar to query.
+// The results are approximate: raise HNSWConfig.EfSearch to improve recall.
+// A negative k ranks every vector exactly, as BruteForce does.
+func (h *HNSW) Search(query []float32, k int) ([]SearchResult, error) {
+	unit, err := normalized(query, h.dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.entryPoint < 0 || k == 0 {
+		return []SearchResult{}, nil
+	}
+	if k < 0 {
+		results := make([]SearchResult, 0, len(h.positions))
+		for _, node := range h.nodes {
+			if !node.deleted {
+				results = append(results, SearchResult{ID: node.id, Score: dot(unit, node.vector)})
+			}
+		}
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Score > results[j].Score
+		})
+		return results, nil
+	}
+
+	entry := h.entryPoint
+	for layer := h.maxLevel; layer > 0; layer-- {
+		entry = h.greedyClosest(unit, entry, layer)
+	}
+	// Tombstoned nodes take room in the candidate list, so widen it accordingly.
+	ef := max(h.config.EfSearch, k) + len(h.nodes) - len(h.positions)
+	candidates := h.searchLayer(unit, []int{entry}, ef, 0)
+
+	results := make([]SearchResult, 0, min(k, len(candidates)))
+	for _, c := range candidates {
+		if len(results) == k {
+			break
+		}
+		node := h.nodes[c.index]
+		if node.deleted {
+			continue
+		}
+		results = append(results, SearchResult{ID: node.id, Score: c.score})
+	}
+	return results, nil
+}
+
+// Len returns the number of vectors in the index, excluding removed ones.
+func (h *HNSW) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.positions)
+}
+
+// Dimension returns the number of dimensions of the indexed vectors.
+func (h *HNSW) Dimension() int {
+	return h.dimension
+}
+
+// candidate is a node of the graph with its similarity to a query.
+type candidate struct {
+	index int
+	score float32
+}
+
+// sortCandidates orders candidates from the most to the least similar.
+func sortCandidates(candidates []candidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+}
+
+// closestIndexes returns the node indexes of the first n sorted candidates.
+func closestIndexes(candidates []candidate, n int) []int {
+	indexes := make([]int, 0, min(n, len(candidates)))
+	for _, c := range candidates {
+		if len(indexes) == n {
+			break
+		}
+		indexes = append(indexes, c.index)
+	}
+	return indexes
+}
+
+// maxHeap pops the most similar candidate first.
+type maxHeap []candidate
+
+func (h maxHeap) Len() int           { return len(h) }
+func (h maxHeap) Less(i, j int) bool { return h[i].score > h[j].score }
+func (h maxHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *maxHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// minHeap pops the least similar candidate first.
+type minHeap []candidate
+
+func (h minHeap) Len() int           { return len(h) }
+func (h minHeap) Less(i, j int) bool { return h[i].score < h[j].score }
+func (h minHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *minHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
@@ -0,0 +1,99 @@
+package vectorindex_test
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/vectorindex"
+)
+
+func TestHNSW_RecallAgainstBruteForce(t *testing.T) {
+	const dimension, count, k = 16, 1000, 10
+	vectors := randomVectors(count, dimension, 42)
+
+	exact := vectorindex.NewBruteForce(dimension)
+	approximate := vectorindex.NewHNSW(dimension, vectorindex.HNSWConfig{M: 8, EfConstruction: 100, EfSearch: 50, Seed: 7})
+	for i, vector := range vectors {
+		id := strconv.Itoa(i)
+		if err := exact.Add(id, vector); err != nil {
+			t.Fatalf("BruteForce.Add returned error: %v", err)
+		}
+		if err := approximate.Add(id, vector); err != nil {
+			t.Fatalf("HNSW.Add returned error: %v", err)
+		}
+	}
+
+	found, total := 0, 0
+	for _, query := range randomVectors(50, dimension, 43) {
+		want, _ := exact.Search(query, k)
+		got, err := approximate.Search(query, k)
+		if err != nil {
+			t.Fatalf("HNSW.Search returned error: %v", err)
+		}
+		ids := make(map[string]bool, len(got))
+		for _, r := range got {
+			ids[r.ID] = true
+		}
+		for _, r := range want {
+			if ids[r.ID] {
+				found++
+			}
+			total++
+		}
+	}
+	if recall := float64(found) / float64(total); recall < 0.9 {
+		t.Errorf("Expected recall@%d >= 0.9, got %.2f", k, recall)
+	}
+}
+
+func TestHNSW_Remove(t *testing.T) {
+	index := vectorindex.NewHNSW(2, vectorindex.DefaultHNSWConfig())
+	_ = index.Add("a", []float32{1, 0})
+	_ = index.Add("b", []float32{0, 1})
+	_ = index.Add("c", []float32{1, 0.2})
+
+	if !index.Remove("a") || index.Remove("a") {
+		t.Fatalf("Expected Remove to report the vector once")
+	}
+	results, err := index.Search([]float32{1, 0}, 5)
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != "c" {
+		t.Errorf("Expected [c b] after removal, got %v", results)
+	}
+	if index.Len() != 2 {
+		t.Errorf("Expected 2 vectors, got %d", index.Len())
+	}
+}
+
+func TestHNSW_EmptySearch(t *testing.T) {
+	index := vectorindex.NewHNSW(2, vectorindex.HNSWConfig{})
+	results, err := index.Search([]float32{1, 0}, 3)
+	if err != nil || len(results) != 0 {
+		t.Errorf("Expected no results from an empty index, got %v, %v", results, err)
+	}
+}
+
+func TestHNSW_SearchAll(t *testing.T) {
+	const dimension = 8
+	exact := vectorindex.NewBruteForce(dimension)
+	approximate := vectorindex.NewHNSW(dimension, vectorindex.HNSWConfig{M: 4, EfSearch: 5, Seed: 3})
+	for i, vector := range randomVectors(100, dimension, 5) {
+		exact.Add(strconv.Itoa(i), vector)
+		approximate.Add(strconv.Itoa(i), vector)
+	}
+	approximate.Remove("7")
+	exact.Remove("7")
+
+	query := randomVectors(1, dimension, 6)[0]
+	want, _ := exact.Search(query, -1)
+	got, err := approximate.Search(query, -1)
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected a negative k to rank all %d vectors like BruteForce, got %d", len(want), len(got))
+	}
+}
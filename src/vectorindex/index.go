@@ -0,0 +1,80 @@
+// Package vectorindex provides in-process indexes to search embeddings by cosine similarity.
+//
+// Two implementations are available: BruteForce, which compares the query with every
+// stored vector, and HNSW, an approximate Hierarchical Navigable Small World graph
+// which scales to large collections. Both can be saved to and loaded from disk.
+package vectorindex
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/onnx"
+)
+
+var (
+	// ErrDuplicateID is returned when adding a vector with an ID already in the index.
+	ErrDuplicateID = errors.New("vector ID already exists in the index")
+	// ErrZeroVector is returned when adding or searching a vector with no length.
+	ErrZeroVector = errors.New("vector has zero length and cannot be normalized")
+)
+
+// DimensionError is returned when a vector does not match the dimension of the index.
+type DimensionError struct {
+	Expected int
+	Got      int
+}
+
+func (e *DimensionError) Error() string {
+	return fmt.Sprintf("vector has %d dimensions, index expects %d", e.Got, e.Expected)
+}
+
+// SearchResult is a vector of the index matching a query.
+type SearchResult struct {
+	// ID is the identifier the vector was added with.
+	ID string
+	// Score is the cosine similarity between the query and the vector, in [-1, 1].
+	Score float32
+}
+
+// Index stores vectors by ID and searches them by cosine similarity.
+// Implementations are safe for concurrent use.
+type Index interface {
+	// Add stores a copy of vector under id. Vectors are L2-normalized on insertion.
+	Add(id string, vector []float32) error
+	// Remove deletes the vector stored under id and reports whether it was present.
+	Remove(id string) bool
+	// Search returns up to k vectors, from the most to the least similar to query.
+	// A negative k returns every vector.
+	Search(query []float32, k int) ([]SearchResult, error)
+	// Len returns the number of vectors in the index.
+	Len() int
+	// Dimension returns the number of dimensions of the indexed vectors.
+	Dimension() int
+	// Save writes the index to w, to be read back with Load.
+	Save(w io.Writer) error
+}
+
+// normalized checks the dimension of vector and returns a unit length copy of it.
+func normalized(vector []float32, dimension int) ([]float32, error) {
+	if len(vector) != dimension {
+		return nil, &DimensionError{Expected: dimension, Got: len(vector)}
+	}
+	unit := slices.Clone(vector)
+	if !onnx.L2Normalize(unit) {
+		return nil, ErrZeroVector
+	}
+	return unit, nil
+}
+
+// dot returns the dot product of two vectors of the same length, which is
+// their cosine similarity when both are normalized.
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
@@ -0,0 +1,50 @@
+package vectorindex_test
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/vectorindex"
+)
+
+// randomVectors returns n reproducible random vectors of the given dimension.
+func randomVectors(n, dimension int, seed int64) [][]float32 {
+	random := rand.New(rand.NewSource(seed))
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		vectors[i] = make([]float32, dimension)
+		for j := range vectors[i] {
+			vectors[i][j] = float32(random.NormFloat64())
+		}
+	}
+	return vectors
+}
+
+func TestIndexes_RejectInvalidVectors(t *testing.T) {
+	indexes := map[string]vectorindex.Index{
+		"bruteforce": vectorindex.NewBruteForce(3),
+		"hnsw":       vectorindex.NewHNSW(3, vectorindex.DefaultHNSWConfig()),
+	}
+	for name, index := range indexes {
+		var dimErr *vectorindex.DimensionError
+		if err := index.Add("a", []float32{1, 2}); !errors.As(err, &dimErr) || dimErr.Expected != 3 {
+			t.Errorf("%s: expected a DimensionError, got %v", name, err)
+		}
+		if err := index.Add("a", []float32{0, 0, 0}); !errors.Is(err, vectorindex.ErrZeroVector) {
+			t.Errorf("%s: expected ErrZeroVector, got %v", name, err)
+		}
+		if err := index.Add("a", []float32{1, 0, 0}); err != nil {
+			t.Fatalf("%s: Add returned error: %v", name, err)
+		}
+		if err := index.Add("a", []float32{0, 1, 0}); !errors.Is(err, vectorindex.ErrDuplicateID) {
+			t.Errorf("%s: expected ErrDuplicateID, got %v", name, err)
+		}
+		if _, err := index.Search([]float32{1}, 1); err == nil {
+			t.Errorf("%s: expected error for a query of the wrong dimension", name)
+		}
+		if index.Dimension() != 3 || index.Len() != 1 {
+			t.Errorf("%s: expected dimension 3 and 1 vector, got %d and %d", name, index.Dimension(), index.Len())
+		}
+	}
+}
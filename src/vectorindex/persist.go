@@ -0,0 +1,180 @@
+package vectorindex
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+const (
+	kindBruteForce = "bruteforce"
+	kindHNSW       = "hnsw"
+)
+
+// snapshot is the on-disk representation of an index.
+type snapshot struct {
+	Kind      string
+	Dimension int
+	Config    HNSWConfig
+	IDs       []string
+	Vectors   [][]float32
+	// Levels and Neighbors describe the HNSW graph, indexed like IDs.
+	Levels     []int
+	Neighbors  [][][]int
+	EntryPoint int
+}
+
+// Save writes the index to w.
+func (b *BruteForce) Save(w io.Writer) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return gob.NewEncoder(w).Encode(snapshot{
+		Kind:      kindBruteForce,
+		Dimension: b.dimension,
+		IDs:       b.ids,
+		Vectors:   b.vectors,
+	})
+}
+
+// Save writes the index to w. Removed vectors are dropped and the graph is compacted.
+func (h *HNSW) Save(w io.Writer) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	// Renumber live nodes, then rewrite the neighbour lists without tombstones.
+	renumbered := make(map[int]int, len(h.positions))
+	for i, node := range h.nodes {
+		if !node.deleted {
+			renumbered[i] = len(renumbered)
+		}
+	}
+
+	s := snapshot{
+		Kind:       kindHNSW,
+		Dimension:  h.dimension,
+		Config:     h.config,
+		EntryPoint: -1,
+	}
+	for _, node := range h.nodes {
+		if node.deleted {
+			continue
+		}
+		neighbors := make([][]int, len(node.neighbors))
+		for layer, list := range node.neighbors {
+			neighbors[layer] = make([]int, 0, len(list))
+			for _, neighbor := range list {
+				if n, ok := renumbered[neighbor]; ok {
+					neighbors[layer] = append(neighbors[layer], n)
+				}
+			}
+		}
+		s.IDs = append(s.IDs, node.id)
+		s.Vectors = append(s.Vectors, node.vector)
+		s.Levels = append(s.Levels, len(node.neighbors)-1)
+		s.Neighbors = append(s.Neighbors, neighbors)
+	}
+
+	// Keep the entry point unless it was removed, in which case the highest node replaces it.
+	if n, ok := renumbered[h.entryPoint]; ok {
+		s.EntryPoint = n
+	} else {
+		for i, level := range s.Levels {
+			if s.EntryPoint < 0 || level > s.Levels[s.EntryPoint] {
+				s.EntryPoint = i
+			}
+		}
+	}
+	return gob.NewEncoder(w).Encode(s)
+}
+
+// Load reads an index written by Save, returning a *BruteForce or an *HNSW.
+func Load(r io.Reader) (Index, error) {
+	var s snapshot
+	if err := gob.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to decode vector index: %w", err)
+	}
+	if len(s.Vectors) != len(s.IDs) {
+		return nil, fmt.Errorf("corrupted vector index: %d IDs for %d vectors", len(s.IDs), len(s.Vectors))
+	}
+
+	switch s.Kind {
+	case kindBruteForce:
+		b := NewBruteForce(s.Dimension)
+		for i, id := range s.IDs {
+			if err := b.Add(id, s.Vectors[i]); err != nil {
+				return nil, fmt.Errorf("corrupted vector index entry %q: %w", id, err)
+			}
+		}
+		return b, nil
+	case kindHNSW:
+		if len(s.Levels) != len(s.IDs) || len(s.Neighbors) != len(s.IDs) {
+			return nil, fmt.Errorf("corrupted vector index: graph does not match %d vectors", len(s.IDs))
+		}
+		h := NewHNSW(s.Dimension, s.Config)
+		for i, id := range s.IDs {
+			if len(s.Vectors[i]) != s.Dimension {
+				return nil, fmt.Errorf("corrupted vector index entry %q: %w", id,
+					&DimensionError{Expected: s.Dimension, Got: len(s.Vectors[i])})
+			}
+			if s.Levels[i] < 0 || s.Levels[i] != len(s.Neighbors[i])-1 {
+				return nil, fmt.Errorf("corrupted vector index entry %q: level %d for %d neighbour lists", id, s.Levels[i], len(s.Neighbors[i]))
+			}
+			if _, ok := h.positions[id]; ok {
+				return nil, fmt.Errorf("corrupted vector index: duplicate ID %q", id)
+			}
+			h.nodes = append(h.nodes, &hnswNode{id: id, vector: s.Vectors[i], neighbors: s.Neighbors[i]})
+			h.positions[id] = i
+		}
+		for _, node := range h.nodes {
+			for layer, list := range node.neighbors {
+				for _, neighbor := range list {
+					if neighbor < 0 || neighbor >= len(h.nodes) {
+						return nil, fmt.Errorf("corrupted vector index entry %q: neighbour %d out of range", node.id, neighbor)
+					}
+					// Searches follow the link on layer, which the neighbour must have too.
+					if len(h.nodes[neighbor].neighbors) <= layer {
+						return nil, fmt.Errorf("corrupted vector index entry %q: neighbour %d is not on layer %d", node.id, neighbor, layer)
+					}
+				}
+			}
+		}
+		if len(h.nodes) > 0 {
+			if s.EntryPoint < 0 || s.EntryPoint >= len(h.nodes) {
+				return nil, fmt.Errorf("corrupted vector index: entry point %d out of range", s.EntryPoint)
+			}
+			if top := slices.Max(s.Levels); s.Levels[s.EntryPoint] != top {
+				return nil, fmt.Errorf("corrupted vector index: entry point at level %d below the top level %d", s.Levels[s.EntryPoint], top)
+			}
+			h.entryPoint = s.EntryPoint
+			h.maxLevel = s.Levels[s.EntryPoint]
+		}
+		return h, nil
+	default:
+		return nil, fmt.Errorf("unknown vector index kind %q", s.Kind)
+	}
+}
+
+// SaveFile writes an index to the file at path, replacing it if it exists.
+func SaveFile(path string, index Index) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create vector index file: %w", err)
+	}
+	if err := index.Save(file); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to save vector index: %w", err)
+	}
+	return file.Close()
+}
+
+// LoadFile reads an index from the file at path.
+func LoadFile(path string) (Index, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vector index file: %w", err)
+	}
+	defer file.Close()
+	return Load(file)
+}
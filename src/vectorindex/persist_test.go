@@ -0,0 +1,101 @@
+package vectorindex_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/deadelus/go-clean-onnxruntime/src/vectorindex"
+)
+
+func TestSaveLoad_RoundTrip(t *testing.T) {
+	vectors := randomVectors(200, 8, 1)
+	indexes := []vectorindex.Index{
+		vectorindex.NewBruteForce(8),
+		vectorindex.NewHNSW(8, vectorindex.DefaultHNSWConfig()),
+	}
+	for _, index := range indexes {
+		for i, vector := range vectors {
+			if err := index.Add(strconv.Itoa(i), vector); err != nil {
+				t.Fatalf("Add returned error: %v", err)
+			}
+		}
+		index.Remove("0")
+
+		path := filepath.Join(t.TempDir(), "index.bin")
+		if err := vectorindex.SaveFile(path, index); err != nil {
+			t.Fatalf("SaveFile returned error: %v", err)
+		}
+		loaded, err := vectorindex.LoadFile(path)
+		if err != nil {
+			t.Fatalf("LoadFile returned error: %v", err)
+		}
+		if reflect.TypeOf(loaded) != reflect.TypeOf(index) {
+			t.Errorf("Expected a %T, got %T", index, loaded)
+		}
+		if loaded.Len() != index.Len() || loaded.Dimension() != 8 {
+			t.Errorf("Expected %d vectors of 8 dimensions, got %d of %d", index.Len(), loaded.Len(), loaded.Dimension())
+		}
+
+		query := vectors[10]
+		want, _ := index.Search(query, 5)
+		got, err := loaded.Search(query, 5)
+		if err != nil {
+			t.Fatalf("Search returned error: %v", err)
+		}
+		if len(got) == 0 || got[0].ID != want[0].ID {
+			t.Errorf("Expected the same best match after loading, got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestLoad_InvalidData(t *testing.T) {
+	if _, err := vectorindex.Load(bytes.NewReader([]byte("not an index"))); err == nil {
+		t.Errorf("Expected error when loading garbage")
+	}
+	if _, err := vectorindex.LoadFile(filepath.Join(t.TempDir(), "missing.bin")); err == nil {
+		t.Errorf("Expected error when loading a missing file")
+	}
+}
+
+// hnswSnapshot mirrors the fields of the on-disk index, to write corrupted graphs.
+type hnswSnapshot struct {
+	Kind       string
+	Dimension  int
+	IDs        []string
+	Vectors    [][]float32
+	Levels     []int
+	Neighbors  [][][]int
+	EntryPoint int
+}
+
+func TestLoad_CorruptedGraph(t *testing.T) {
+	vectors := [][]float32{{1, 0}, {0, 1}}
+	tests := map[string]hnswSnapshot{
+		"level above the neighbour lists": {Levels: []int{2, 0}, Neighbors: [][][]int{{{1}}, {{0}}}},
+		"negative level":                  {Levels: []int{-1, 0}, Neighbors: [][][]int{{}, {{0}}}},
+		"neighbour missing the layer":     {Levels: []int{1, 0}, Neighbors: [][][]int{{{1}, {1}}, {{0}}}},
+		"entry point below the top":       {Levels: []int{0, 1}, Neighbors: [][][]int{{{1}}, {{0}, {}}}},
+		"duplicate ID":                    {IDs: []string{"a", "a"}, Levels: []int{1, 0}, Neighbors: [][][]int{{{1}, {}}, {{0}}}},
+	}
+	tests["valid"] = hnswSnapshot{Levels: []int{1, 0}, Neighbors: [][][]int{{{1}, {}}, {{0}}}}
+	for name, s := range tests {
+		s.Kind, s.Dimension, s.Vectors = "hnsw", 2, vectors
+		if s.IDs == nil {
+			s.IDs = []string{"a", "b"}
+		}
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(s); err != nil {
+			t.Fatal(err)
+		}
+		_, err := vectorindex.Load(&buf)
+		if name == "valid" && err != nil {
+			t.Errorf("Expected a valid graph to load, got %v", err)
+		} else if name != "valid" && err == nil {
+			t.Errorf("Expected error for a %s", name)
+		}
+	}
+}